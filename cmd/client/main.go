@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/ssungk/ertmp/pkg/rtmp"
+	"github.com/ssungk/ertmp/pkg/rtmp/transport"
+)
+
+func main() {
+	url := flag.String("url", "rtmp://localhost:1935/live/stream", "RTMP URL to play")
+	flag.Parse()
+
+	client, err := rtmp.Dial(*url, rtmp.DefaultClientConfig())
+	if err != nil {
+		slog.Error("Connect failed", "error", err, "url", *url)
+		os.Exit(1)
+	}
+	defer client.Close()
+
+	slog.Info("Connected", "url", *url, "handshake", client.Conn().HandshakeMode())
+
+	stream, err := client.Play("")
+	if err != nil {
+		slog.Error("Play failed", "error", err)
+		os.Exit(1)
+	}
+	defer stream.Close()
+
+	slog.Info("Play started", "streamKey", stream.Key(), "streamID", stream.ID())
+
+	var videoFrames, audioFrames, totalBytes int
+	for {
+		msg, err := stream.ReadMessage()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				slog.Error("Read error", "error", err)
+			}
+			break
+		}
+
+		switch msg.Type() {
+		case transport.MsgTypeVideo:
+			videoFrames++
+		case transport.MsgTypeAudio:
+			audioFrames++
+		case transport.MsgTypeAMF0Data:
+			slog.Info("Metadata received", "bytes", len(msg.Data()))
+		}
+		totalBytes += len(msg.Data())
+
+		if (videoFrames+audioFrames)%500 == 0 {
+			slog.Info("Media received",
+				"video", videoFrames,
+				"audio", audioFrames,
+				"bytes", totalBytes,
+				"timestamp", msg.Timestamp())
+		}
+		msg.Buffer().Release()
+	}
+
+	slog.Info("Play finished", "video", videoFrames, "audio", audioFrames, "bytes", totalBytes)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"time"
+
+	"github.com/ssungk/ertmp/pkg/rtmp/transport"
+)
+
+// GOPConfig limits the GOP cache of a stream (0이면 해당 한도 없음)
+type GOPConfig struct {
+	MaxFrames   int
+	MaxBytes    int
+	MaxDuration time.Duration
+}
+
+// gopCache keeps the media messages since the last video keyframe.
+// 메시지 버퍼는 Retain하여 복사 없이 보관하며, 한도를 넘으면 다음 keyframe까지 캐시를 비움
+// (keyframe 없이 시작하는 GOP는 디코딩할 수 없으므로 앞부분만 버리지 않음)
+type gopCache struct {
+	config   GOPConfig
+	messages []transport.Message
+	bytes    int
+}
+
+// newGOPCache creates a GOP cache
+func newGOPCache(config GOPConfig) *gopCache {
+	return &gopCache{config: config}
+}
+
+// add caches a media message; a video keyframe starts a new GOP
+// 첫 keyframe 전이나 한도 초과 후의 메시지는 무시
+func (c *gopCache) add(msg transport.Message, keyFrame bool) {
+	if c == nil {
+		return
+	}
+	if keyFrame {
+		c.clear()
+	} else if len(c.messages) == 0 {
+		return
+	}
+
+	if c.exceeds(msg) {
+		c.clear()
+		return
+	}
+
+	msg.Buffer().Retain()
+	c.messages = append(c.messages, msg)
+	c.bytes += len(msg.Data())
+}
+
+// exceeds reports whether adding msg would exceed a limit
+func (c *gopCache) exceeds(msg transport.Message) bool {
+	if c.config.MaxFrames > 0 && len(c.messages)+1 > c.config.MaxFrames {
+		return true
+	}
+	if c.config.MaxBytes > 0 && c.bytes+len(msg.Data()) > c.config.MaxBytes {
+		return true
+	}
+	if c.config.MaxDuration > 0 && len(c.messages) > 0 {
+		first := c.messages[0].Timestamp()
+		if msg.Timestamp() > first && time.Duration(msg.Timestamp()-first)*time.Millisecond > c.config.MaxDuration {
+			return true
+		}
+	}
+	return false
+}
+
+// snapshot returns the cached messages with their buffers retained; caller must release them
+func (c *gopCache) snapshot() []transport.Message {
+	if c == nil || len(c.messages) == 0 {
+		return nil
+	}
+	msgs := make([]transport.Message, len(c.messages))
+	for i, msg := range c.messages {
+		msg.Buffer().Retain()
+		msgs[i] = msg
+	}
+	return msgs
+}
+
+// clear releases all cached messages
+func (c *gopCache) clear() {
+	if c == nil {
+		return
+	}
+	for _, msg := range c.messages {
+		msg.Buffer().Release()
+	}
+	clear(c.messages)
+	c.messages = c.messages[:0]
+	c.bytes = 0
+}
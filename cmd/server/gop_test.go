@@ -0,0 +1,194 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ssungk/ertmp/pkg/rtmp"
+	"github.com/ssungk/ertmp/pkg/rtmp/buf"
+	"github.com/ssungk/ertmp/pkg/rtmp/transport"
+)
+
+func TestGOPCache(t *testing.T) {
+	type frame struct {
+		ts   uint32
+		key  bool
+		size int
+	}
+	tests := []struct {
+		name   string
+		config GOPConfig
+		frames []frame
+		want   []uint32 // 캐시된 메시지의 timestamp
+	}{
+		{"before first keyframe", GOPConfig{}, []frame{{0, false, 10}, {40, false, 10}}, nil},
+		{"since first keyframe", GOPConfig{}, []frame{{0, false, 10}, {40, true, 10}, {80, false, 10}}, []uint32{40, 80}},
+		{"keyframe resets", GOPConfig{}, []frame{{0, true, 10}, {40, false, 10}, {80, true, 10}, {120, false, 10}}, []uint32{80, 120}},
+		{"max frames", GOPConfig{MaxFrames: 2}, []frame{{0, true, 10}, {40, false, 10}, {80, false, 10}, {120, false, 10}}, nil},
+		{"max frames then keyframe", GOPConfig{MaxFrames: 2}, []frame{{0, true, 10}, {40, false, 10}, {80, false, 10}, {120, true, 10}}, []uint32{120}},
+		{"within max bytes", GOPConfig{MaxBytes: 20}, []frame{{0, true, 10}, {40, false, 10}}, []uint32{0, 40}},
+		{"max bytes", GOPConfig{MaxBytes: 20}, []frame{{0, true, 10}, {40, false, 11}}, nil},
+		{"keyframe over max bytes", GOPConfig{MaxBytes: 20}, []frame{{0, true, 30}, {40, false, 10}}, nil},
+		{"within max duration", GOPConfig{MaxDuration: 80 * time.Millisecond}, []frame{{0, true, 10}, {40, false, 10}, {80, false, 10}}, []uint32{0, 40, 80}},
+		{"max duration", GOPConfig{MaxDuration: 80 * time.Millisecond}, []frame{{0, true, 10}, {40, false, 10}, {120, false, 10}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newGOPCache(tt.config)
+			released := 0
+			for _, f := range tt.frames {
+				msg := newTestMedia(f.ts, make([]byte, f.size), &released)
+				c.add(msg, f.key)
+				msg.Buffer().Release() // 호출자의 참조
+			}
+
+			var got []uint32
+			for _, msg := range c.messages {
+				got = append(got, msg.Timestamp())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("expected cached timestamps %v, got %v", tt.want, got)
+			}
+			if want := len(tt.frames) - len(tt.want); released != want {
+				t.Errorf("expected %d released buffers, got %d", want, released)
+			}
+
+			c.clear()
+			if released != len(tt.frames) || c.bytes != 0 {
+				t.Errorf("clear: expected all %d buffers released and 0 bytes, got %d released, %d bytes", len(tt.frames), released, c.bytes)
+			}
+		})
+	}
+}
+
+func TestGOPCache_Snapshot(t *testing.T) {
+	c := newGOPCache(GOPConfig{})
+	released := 0
+	for i, ts := range []uint32{0, 40, 80} {
+		msg := newTestMedia(ts, []byte{0x01}, &released)
+		c.add(msg, i == 0)
+		msg.Buffer().Release()
+	}
+
+	msgs := c.snapshot()
+	if len(msgs) != 3 {
+		t.Fatalf("expected 3 messages, got %d", len(msgs))
+	}
+
+	// 스냅샷은 캐시와 별개로 버퍼를 보유
+	c.clear()
+	if released != 0 {
+		t.Fatalf("expected snapshot to keep buffers, %d released", released)
+	}
+	for _, msg := range msgs {
+		msg.Buffer().Release()
+	}
+	if released != 3 {
+		t.Errorf("expected 3 released buffers, got %d", released)
+	}
+	if msgs := c.snapshot(); msgs != nil {
+		t.Errorf("expected nil snapshot of empty cache, got %d messages", len(msgs))
+	}
+}
+
+func TestGOPCache_Nil(t *testing.T) {
+	var c *gopCache
+	released := 0
+	msg := newTestMedia(0, []byte{0x01}, &released)
+	c.add(msg, true)
+	msg.Buffer().Release()
+	if released != 1 {
+		t.Errorf("expected nil cache not to retain, %d released", released)
+	}
+	if msgs := c.snapshot(); msgs != nil {
+		t.Errorf("expected nil snapshot, got %d messages", len(msgs))
+	}
+	c.clear()
+}
+
+func TestGOPCache_LateJoiner(t *testing.T) {
+	s := NewServer()
+	s.gop = &GOPConfig{}
+	addr := startTestServer(t, s)
+
+	client, err := rtmp.Dial("rtmp://"+addr+"/live/key", rtmp.DefaultClientConfig())
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	publisher, err := client.Publish("")
+	if err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+
+	frames := []struct {
+		ts   uint32
+		data []byte
+	}{
+		{0, testVideoHeader},
+		{0, testKeyFrame},
+		{40, testInterFrame},
+		{80, testKeyFrame},
+		{120, testInterFrame},
+	}
+	for _, f := range frames {
+		if err := publisher.WriteVideo(f.data, f.ts); err != nil {
+			t.Fatalf("WriteVideo failed: %v", err)
+		}
+	}
+
+	// 서버가 마지막 프레임까지 캐시할 때까지 대기
+	st := s.GetOrCreateStream("key")
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		st.mu.RLock()
+		n := len(st.gop.messages)
+		st.mu.RUnlock()
+		if n == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected 2 cached messages, got %d", n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	conn, streamID := dialTestPlayer(t, addr)
+	if err := rtmp.SendCommand(conn, streamID, "play", 0, nil, "key", -1.0); err != nil {
+		t.Fatalf("play failed: %v", err)
+	}
+
+	// sequence header, 마지막 GOP 다음에 live 프레임이 이어짐
+	videos := 0
+	events := readEvents(t, conn, func(e playEvent) bool {
+		if e.msgType == transport.MsgTypeVideo {
+			videos++
+			if videos == 3 {
+				if err := publisher.WriteVideo(testInterFrame, 160); err != nil {
+					t.Errorf("WriteVideo failed: %v", err)
+				}
+			}
+		}
+		return videos == 4
+	})
+
+	var got []uint32
+	for _, e := range events {
+		if e.msgType == transport.MsgTypeVideo {
+			got = append(got, e.timestamp)
+		}
+	}
+	if want := []uint32{0, 80, 120, 160}; !slices.Equal(got, want) {
+		t.Errorf("expected video timestamps %v, got %v", want, got)
+	}
+	if first := events[slices.IndexFunc(events, func(e playEvent) bool { return e.msgType == transport.MsgTypeVideo })]; string(first.data) != string(testVideoHeader) {
+		t.Errorf("expected the sequence header first, got %x", first.data)
+	}
+}
+
+// newTestMedia creates a video message whose buffer counts its release in released
+func newTestMedia(ts uint32, data []byte, released *int) transport.Message {
+	b := buf.NewWithFinalizer(data, func([]byte) { *released++ })
+	return transport.NewMessage(transport.NewMessageHeader(1, ts, transport.MsgTypeVideo), b)
+}
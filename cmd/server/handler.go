@@ -0,0 +1,437 @@
+package main
+
+import (
+	"log/slog"
+	"net/url"
+	"strings"
+
+	"github.com/ssungk/ertmp/pkg/media"
+	"github.com/ssungk/ertmp/pkg/rtmp"
+	"github.com/ssungk/ertmp/pkg/rtmp/buf"
+	"github.com/ssungk/ertmp/pkg/rtmp/transport"
+)
+
+// OnConnect accepts every connection
+func (s *Server) OnConnect(conn *rtmp.Conn, cmd *rtmp.ConnectCommand) error {
+	slog.Info("Client connected",
+		"address", conn.RemoteAddr(),
+		"handshake", conn.HandshakeMode(),
+		"app", cmd.App)
+	return nil
+}
+
+// OnCreateStream accepts every stream
+func (s *Server) OnCreateStream(conn *rtmp.Conn, stream *rtmp.Stream) error {
+	slog.Info("Stream created", "address", conn.RemoteAddr(), "streamID", stream.ID())
+	return nil
+}
+
+// OnPublish registers the publisher; a second publisher on the same key is rejected
+// publish type이 record/append이고 녹화 디렉터리가 설정되어 있으면 FLV 파일로 녹화
+func (s *Server) OnPublish(conn *rtmp.Conn, stream *rtmp.Stream, cmd *rtmp.PublishCommand) error {
+	key, _, _ := strings.Cut(cmd.StreamKey, "?") // 인코더가 붙이는 쿼리 제외
+	if key == "" {
+		return &rtmp.StatusError{Code: "NetStream.Publish.BadName", Description: "missing stream key"}
+	}
+	cmd.StreamKey = key
+
+	st := s.GetOrCreateStream(key)
+	if !st.SetPublisher(stream) {
+		slog.Warn("Publish rejected: already publishing", "streamKey", key)
+		return &rtmp.StatusError{Code: "NetStream.Publish.BadName", Description: "stream is already publishing"}
+	}
+
+	if s.record.Enabled() && (cmd.PublishType == "record" || cmd.PublishType == "append") {
+		recorder, err := NewRecorder(s.record, conn.ConnectInfo().App, key, cmd.PublishType, func() (video, audio [][]byte) {
+			return st.GetHeaders(nil, nil)
+		})
+		if err != nil {
+			slog.Error("Failed to start recording", "streamKey", key, "error", err)
+			st.RemovePublisher(stream)
+			s.RemoveStream(key)
+			return &rtmp.StatusError{Code: "NetStream.Record.NoAccess", Description: "cannot open record file"}
+		}
+		st.SetRecorder(recorder)
+		slog.Info("Recording started", "streamKey", key, "path", recorder.Path(), "type", cmd.PublishType)
+	}
+
+	slog.Info("Publish started",
+		"streamID", stream.ID(),
+		"streamKey", key,
+		"type", cmd.PublishType)
+	return nil
+}
+
+// OnPlay registers the subscriber or prepares playback of a recorded file
+// stream key 쿼리로 multitrack 트랙 선택: key?videoTracks=0,1&audioTracks=2
+// start: -2(기본)는 live 우선 후 녹화 파일, -1은 live만, 0 이상은 녹화 파일의 해당 위치(초)
+func (s *Server) OnPlay(conn *rtmp.Conn, stream *rtmp.Stream, cmd *rtmp.PlayCommand) error {
+	key, query, _ := strings.Cut(cmd.StreamKey, "?")
+	if key == "" {
+		return &rtmp.StatusError{Code: "NetStream.Play.StreamNotFound", Description: "missing stream key"}
+	}
+	cmd.StreamKey = key // 쿼리를 제외한 키로 스트림 등록
+
+	// 녹화 파일 재생 (VOD)
+	live := cmd.Start == -1 || (cmd.Start == -2 && s.hasPublisher(key))
+	if !live {
+		path, ok := s.RecordedFile(conn.ConnectInfo().App, key)
+		if ok {
+			stream.SetRecorded(true)
+			s.AddPlayback(stream, NewPlayback(conn, stream, path, cmd.Start, cmd.Duration))
+			slog.Info("Playback started", "streamID", stream.ID(), "path", path, "start", cmd.Start)
+			return nil
+		}
+		if cmd.Start >= 0 {
+			return &rtmp.StatusError{Code: "NetStream.Play.StreamNotFound", Description: "no recorded stream"}
+		}
+		// -2: 녹화 파일도 없으면 live 스트림의 publisher를 기다림
+	}
+
+	sub, err := newSubscriber(stream, query, s.queueSize)
+	if err != nil {
+		return &rtmp.StatusError{Code: "NetStream.Play.Failed", Description: err.Error()}
+	}
+
+	st := s.GetOrCreateStream(key)
+	st.AddSubscriber(sub)
+
+	slog.Info("Play started", "streamID", stream.ID(), "streamKey", key)
+	return nil
+}
+
+// OnPlayStart starts the playback of a recorded file, or queues cached metadata,
+// sequence headers and the GOP cache to a live subscriber and starts its writer
+func (s *Server) OnPlayStart(conn *rtmp.Conn, stream *rtmp.Stream) {
+	if playback := s.GetPlayback(stream); playback != nil {
+		go playback.Run()
+		return
+	}
+
+	st := s.GetOrCreateStream(stream.Key())
+	init := st.StartSubscriber(stream)
+	if init == nil {
+		return
+	}
+	if len(init.video)+len(init.audio) > 0 {
+		slog.Info("Sequence headers sent",
+			"streamKey", stream.Key(),
+			"video", len(init.video),
+			"audio", len(init.audio))
+	}
+	if len(init.gop) > 0 {
+		slog.Info("GOP cache sent", "streamKey", stream.Key(), "messages", len(init.gop))
+	}
+}
+
+// OnMedia caches sequence headers per track and the GOP, and broadcasts media to subscribers
+func (s *Server) OnMedia(conn *rtmp.Conn, stream *rtmp.Stream, msg transport.Message) {
+	st := s.GetOrCreateStream(stream.Key())
+	data := msg.Data()
+
+	// 구독자별 트랙 선택 (파싱 실패 시 원본 그대로 전송)
+	var selector trackSelector
+	kind := frameInter // 파싱 실패 시 폐기 가능한 프레임으로 취급
+	keyFrame, header := false, false
+
+	switch msg.Type() {
+	case transport.MsgTypeVideo:
+		// AVC(legacy) 및 E-RTMP(HEVC, AV1, VP9, multitrack) sequence header 캐시
+		tag, err := media.ParseVideoTag(data)
+		if err != nil {
+			slog.Debug("Invalid video tag", "streamKey", stream.Key(), "error", err)
+			break
+		}
+		if tag.IsSequenceHeader() {
+			slog.Info("Video sequence header cached",
+				"streamKey", stream.Key(),
+				"codec", tag.FourCC,
+				"tracks", max(len(tag.Tracks), 1),
+				"bytes", len(data))
+		}
+		packets := videoTrackPackets(tag, data)
+		st.UpdateHeaders(msg.Type(), packets)
+
+		header = len(packets) > 0 && packets[0].kind != headerNone
+		keyFrame = tag.IsKeyFrame() && !header
+		selector = videoSelector(tag)
+		kind = videoFrameKind(header, keyFrame)
+
+	case transport.MsgTypeAudio:
+		// AAC(legacy) 및 E-RTMP(Opus, FLAC 등, multitrack) sequence header 캐시
+		tag, err := media.ParseAudioTag(data)
+		if err != nil {
+			slog.Debug("Invalid audio tag", "streamKey", stream.Key(), "error", err)
+			break
+		}
+		if tag.IsSequenceHeader() {
+			slog.Info("Audio sequence header cached",
+				"streamKey", stream.Key(),
+				"codec", tag.FourCC,
+				"tracks", max(len(tag.Tracks), 1),
+				"bytes", len(data))
+		}
+		packets := audioTrackPackets(tag, data)
+		st.UpdateHeaders(msg.Type(), packets)
+
+		header = len(packets) > 0 && packets[0].kind != headerNone
+		selector = audioSelector(tag)
+		kind = audioFrameKind(header)
+	}
+
+	// sequence header는 헤더 캐시로 전송되므로 GOP에서 제외
+	if !header {
+		st.CacheGOP(msg, keyFrame)
+	}
+
+	record(st, msg)
+
+	for _, sub := range st.GetSubscribers() {
+		sendMedia(sub, msg, selector, kind)
+	}
+}
+
+// OnMetadata caches metadata and broadcasts it to subscribers
+func (s *Server) OnMetadata(conn *rtmp.Conn, stream *rtmp.Stream, msg transport.Message) {
+	slog.Info("Metadata received", "bytes", len(msg.Data()), "streamKey", stream.Key())
+
+	st := s.GetOrCreateStream(stream.Key())
+	st.SetMetadata(msg.Data())
+	record(st, msg)
+	broadcast(st, msg)
+}
+
+// OnDeleteStream removes the publisher or subscriber from the server stream
+func (s *Server) OnDeleteStream(conn *rtmp.Conn, stream *rtmp.Stream) {
+	if stream.Key() == "" {
+		return
+	}
+
+	if playback := s.RemovePlayback(stream); playback != nil {
+		playback.Stop()
+		slog.Info("Playback stopped", "streamID", stream.ID(), "streamKey", stream.Key())
+		return
+	}
+
+	st := s.GetOrCreateStream(stream.Key())
+	switch stream.Mode() {
+	case rtmp.StreamModePublish:
+		if st.GetPublisher() == stream {
+			stopRecording(st)
+		}
+		st.RemovePublisher(stream)
+		slog.Info("Publisher disconnected", "streamKey", stream.Key())
+	case rtmp.StreamModePlay:
+		st.RemoveSubscriber(stream)
+		slog.Info("Subscriber disconnected", "streamKey", stream.Key())
+	}
+
+	// 스트림이 비어있으면 제거
+	s.RemoveStream(stream.Key())
+}
+
+// OnClose logs the disconnect
+func (s *Server) OnClose(conn *rtmp.Conn, err error) {
+	if err != nil {
+		slog.Error("Client disconnected", "address", conn.RemoteAddr(), "error", err)
+		return
+	}
+	slog.Info("Client disconnected", "address", conn.RemoteAddr())
+}
+
+// hasPublisher reports whether a live stream is being published
+func (s *Server) hasPublisher(key string) bool {
+	s.mu.RLock()
+	st, ok := s.streams[key]
+	s.mu.RUnlock()
+	return ok && st.GetPublisher() != nil
+}
+
+// record writes a message to the stream recorder; 기록 실패 시 녹화만 중단하고 중계는 계속
+func record(st *Stream, msg transport.Message) {
+	recorder := st.GetRecorder()
+	if recorder == nil {
+		return
+	}
+	if err := recorder.WriteMessage(msg); err != nil {
+		slog.Error("Recording failed", "streamKey", st.key, "path", recorder.Path(), "error", err)
+		stopRecording(st)
+	}
+}
+
+// stopRecording finalizes and detaches the stream recorder
+func stopRecording(st *Stream) {
+	recorder := st.GetRecorder()
+	if recorder == nil {
+		return
+	}
+	st.SetRecorder(nil)
+	if err := recorder.Close(); err != nil {
+		slog.Error("Failed to finalize recording", "streamKey", st.key, "path", recorder.Path(), "error", err)
+		return
+	}
+	slog.Info("Recording stopped", "streamKey", st.key, "path", recorder.Path())
+}
+
+// broadcast queues a metadata message to all subscribers of a stream
+func broadcast(st *Stream, msg transport.Message) {
+	for _, sub := range st.GetSubscribers() {
+		sendShared(sub, msg, frameHeader)
+	}
+}
+
+// sendShared queues a message to a subscriber sharing the message buffer (zero-copy)
+func sendShared(sub *Subscriber, msg transport.Message, kind frameKind) {
+	buffer := msg.Buffer()
+	buffer.Retain()
+	header := transport.NewMessageHeader(sub.stream.ID(), msg.Timestamp(), msg.Type())
+	sub.queue.push(transport.NewMessage(header, buffer), kind)
+}
+
+// sendCopy queues a message with a subscriber specific body (트랙 필터링 결과)
+func sendCopy(sub *Subscriber, msg transport.Message, body []byte, kind frameKind) {
+	header := transport.NewMessageHeader(sub.stream.ID(), msg.Timestamp(), msg.Type())
+	sub.queue.push(transport.NewMessage(header, buf.New(body)), kind)
+}
+
+// trackSelector returns the body of a media message for a subscriber's track selection
+// body가 nil이면 원본 그대로, ok가 false이면 선택된 트랙 없음
+type trackSelector func(sub *Subscriber) (body []byte, ok bool)
+
+// videoSelector returns the track selector of a parsed video tag
+func videoSelector(tag *media.VideoTag) trackSelector {
+	return func(sub *Subscriber) ([]byte, bool) {
+		selected := tag.SelectTracks(sub.videoTracks)
+		if selected == nil {
+			return nil, false
+		}
+		if selected == tag || len(selected.Tracks) == len(tag.Tracks) {
+			return nil, true
+		}
+		return selected.Bytes(), true
+	}
+}
+
+// audioSelector returns the track selector of a parsed audio tag
+func audioSelector(tag *media.AudioTag) trackSelector {
+	return func(sub *Subscriber) ([]byte, bool) {
+		selected := tag.SelectTracks(sub.audioTracks)
+		if selected == nil {
+			return nil, false
+		}
+		if selected == tag || len(selected.Tracks) == len(tag.Tracks) {
+			return nil, true
+		}
+		return selected.Bytes(), true
+	}
+}
+
+// inspect parses a media message and returns its track selector and frame kind
+// (파싱 실패 시 selector는 nil)
+func inspect(msg transport.Message) (trackSelector, frameKind) {
+	switch msg.Type() {
+	case transport.MsgTypeVideo:
+		tag, err := media.ParseVideoTag(msg.Data())
+		if err != nil {
+			return nil, frameInter
+		}
+		header := tag.IsSequenceHeader() || tag.IsSequenceEnd()
+		return videoSelector(tag), videoFrameKind(header, tag.IsKeyFrame() && !header)
+	case transport.MsgTypeAudio:
+		tag, err := media.ParseAudioTag(msg.Data())
+		if err != nil {
+			return nil, frameAudio
+		}
+		header := tag.IsSequenceHeader() || tag.IsSequenceEnd() ||
+			(tag.IsExHeader && tag.PacketType == media.AudioPacketMultichannelConfig)
+		return audioSelector(tag), audioFrameKind(header)
+	}
+	return nil, frameHeader
+}
+
+// videoFrameKind returns the queue frame kind of a video message
+func videoFrameKind(header, keyFrame bool) frameKind {
+	switch {
+	case header:
+		return frameHeader
+	case keyFrame:
+		return frameKey
+	}
+	return frameInter
+}
+
+// audioFrameKind returns the queue frame kind of an audio message
+func audioFrameKind(header bool) frameKind {
+	if header {
+		return frameHeader
+	}
+	return frameAudio
+}
+
+// sendMedia queues a media message with the subscriber's track selection (selector가 nil이면 원본)
+func sendMedia(sub *Subscriber, msg transport.Message, selector trackSelector, kind frameKind) {
+	if selector == nil {
+		sendShared(sub, msg, kind)
+		return
+	}
+	body, ok := selector(sub)
+	switch {
+	case !ok:
+		// 선택된 트랙 없음
+	case body == nil:
+		sendShared(sub, msg, kind)
+	default:
+		sendCopy(sub, msg, body, kind)
+	}
+}
+
+// newSubscriber creates a subscriber with the track selection of a play query
+// 송신 큐는 queueSize개 메시지로 제한 (0이면 기본값)
+func newSubscriber(stream *rtmp.Stream, query string, queueSize int) (*Subscriber, error) {
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	videoTracks, err := media.ParseTrackSet(values.Get("videoTracks"))
+	if err != nil {
+		return nil, err
+	}
+	audioTracks, err := media.ParseTrackSet(values.Get("audioTracks"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Subscriber{
+		stream:      stream,
+		videoTracks: videoTracks,
+		audioTracks: audioTracks,
+		queue:       newSendQueue(stream.Conn(), queueSize),
+	}, nil
+}
+
+// sendCached queues a cached message body to a subscriber
+func sendCached(sub *Subscriber, msgType uint8, data []byte) {
+	header := transport.NewMessageHeader(sub.stream.ID(), 0, msgType)
+	sub.queue.push(transport.NewMessage(header, buf.New(data)), frameHeader)
+}
+
+// sendInit queues the initial data of a subscriber: metadata, sequence headers, GOP
+func sendInit(sub *Subscriber, init *playStart) {
+	if init.metadata != nil {
+		sendCached(sub, transport.MsgTypeAMF0Data, init.metadata)
+	}
+	// 선택된 트랙의 video/audio sequence header (audio는 multichannel config 포함)
+	for _, data := range init.video {
+		sendCached(sub, transport.MsgTypeVideo, data)
+	}
+	for _, data := range init.audio {
+		sendCached(sub, transport.MsgTypeAudio, data)
+	}
+	// 마지막 keyframe 이후의 GOP (바로 재생 시작)
+	for _, msg := range init.gop {
+		selector, kind := inspect(msg)
+		sendMedia(sub, msg, selector, kind)
+		msg.Buffer().Release()
+	}
+}
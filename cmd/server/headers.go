@@ -0,0 +1,153 @@
+package main
+
+import (
+	"slices"
+
+	"github.com/ssungk/ertmp/pkg/media"
+)
+
+// headerKind classifies a single-track media packet for the header cache
+type headerKind int
+
+const (
+	headerNone          headerKind = iota // 일반 프레임
+	headerSequenceStart                   // decoder configuration
+	headerSequenceEnd
+	headerChannelConfig // audio multichannel config
+)
+
+// trackPacket is one track of a media message, encoded as a single-track message
+type trackPacket struct {
+	id    uint8
+	codec media.FourCC
+	kind  headerKind
+	data  []byte
+}
+
+// trackHeader holds the cached headers of one track
+type trackHeader struct {
+	codec     media.FourCC
+	seqHeader []byte
+	channels  []byte // audio multichannel config
+}
+
+// headerCache caches sequence headers per track ID (단일 트랙 메시지는 track 0)
+type headerCache map[uint8]*trackHeader
+
+// update applies a track packet to the cache
+// 코덱이 바뀌면 이전 코덱의 헤더를 모두 제거
+func (c headerCache) update(p trackPacket) {
+	h := c[p.id]
+	if h != nil && h.codec != p.codec {
+		delete(c, p.id)
+		h = nil
+	}
+
+	switch p.kind {
+	case headerSequenceStart, headerChannelConfig:
+		if h == nil {
+			h = &trackHeader{codec: p.codec}
+			c[p.id] = h
+		}
+		if p.kind == headerSequenceStart {
+			h.seqHeader = p.data
+		} else {
+			h.channels = p.data
+		}
+	case headerSequenceEnd:
+		delete(c, p.id)
+	}
+}
+
+// messages returns the cached header messages of the selected tracks in track order
+func (c headerCache) messages(set media.TrackSet) [][]byte {
+	ids := make([]uint8, 0, len(c))
+	for id := range c {
+		if set.Contains(id) {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+
+	var msgs [][]byte
+	for _, id := range ids {
+		h := c[id]
+		if h.seqHeader != nil {
+			msgs = append(msgs, h.seqHeader)
+		}
+		if h.channels != nil {
+			msgs = append(msgs, h.channels)
+		}
+	}
+	return msgs
+}
+
+// videoTrackPackets splits a video message into single-track packets
+// 헤더 패킷만 복사본을 만들고, 일반 프레임은 data를 채우지 않음
+func videoTrackPackets(tag *media.VideoTag, data []byte) []trackPacket {
+	kind := headerNone
+	switch {
+	case tag.IsSequenceHeader():
+		kind = headerSequenceStart
+	case tag.IsSequenceEnd():
+		kind = headerSequenceEnd
+	}
+
+	if !tag.Multitrack {
+		p := trackPacket{id: 0, codec: tag.FourCC, kind: kind}
+		if kind != headerNone {
+			p.data = slices.Clone(data)
+		}
+		return []trackPacket{p}
+	}
+
+	packets := make([]trackPacket, 0, len(tag.Tracks))
+	for _, track := range tag.Tracks {
+		p := trackPacket{id: track.ID, codec: track.FourCC, kind: kind}
+		if kind != headerNone {
+			single := *tag
+			single.MultitrackType = media.MultitrackOneTrack
+			single.FourCC = track.FourCC
+			single.Tracks = []media.Track{track}
+			p.data = single.Bytes()
+		}
+		packets = append(packets, p)
+	}
+	return packets
+}
+
+// audioTrackPackets splits an audio message into single-track packets
+// 헤더 패킷만 복사본을 만들고, 일반 프레임은 data를 채우지 않음
+func audioTrackPackets(tag *media.AudioTag, data []byte) []trackPacket {
+	kind := headerNone
+	switch {
+	case tag.IsSequenceHeader():
+		kind = headerSequenceStart
+	case tag.IsSequenceEnd():
+		kind = headerSequenceEnd
+	case tag.PacketType == media.AudioPacketMultichannelConfig && tag.IsExHeader:
+		kind = headerChannelConfig
+	}
+
+	if !tag.Multitrack {
+		p := trackPacket{id: 0, codec: tag.FourCC, kind: kind}
+		if kind != headerNone {
+			p.data = slices.Clone(data)
+		}
+		return []trackPacket{p}
+	}
+
+	packets := make([]trackPacket, 0, len(tag.Tracks))
+	for _, track := range tag.Tracks {
+		p := trackPacket{id: track.ID, codec: track.FourCC, kind: kind}
+		if kind != headerNone {
+			single := *tag
+			single.MultitrackType = media.MultitrackOneTrack
+			single.FourCC = track.FourCC
+			single.Tracks = []media.Track{track}
+			p.data = single.Bytes()
+		}
+		packets = append(packets, p)
+	}
+	return packets
+}
@@ -1,5 +1,59 @@
 package main
 
+import (
+	"flag"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/ssungk/ertmp/pkg/rtmp"
+)
+
 func main() {
-	NewServer().Run()
+	addr := flag.String("addr", ":1935", "RTMP listen address")
+	tlsAddr := flag.String("rtmps-addr", ":443", "RTMPS listen address (used with -cert and -key)")
+	certFile := flag.String("cert", "", "TLS certificate file for RTMPS")
+	keyFile := flag.String("key", "", "TLS private key file for RTMPS")
+	rtmptAddr := flag.String("rtmpt-addr", "", "RTMPT (HTTP tunneling) listen address, e.g. :80")
+	recordDir := flag.String("record-dir", "", "directory for record/append publishing (empty disables recording)")
+	recordMaxSize := flag.Int64("record-max-size", 0, "rotate record files after this many bytes (0: unlimited)")
+	recordMaxDuration := flag.Duration("record-max-duration", 0, "rotate record files after this duration, e.g. 1h (0: unlimited)")
+	gopCache := flag.Bool("gop-cache", true, "send the media since the last keyframe to new players")
+	gopMaxFrames := flag.Int("gop-max-frames", 1000, "GOP cache limit in audio/video messages (0: unlimited)")
+	gopMaxBytes := flag.Int("gop-max-bytes", 32<<20, "GOP cache limit in bytes (0: unlimited)")
+	gopMaxDuration := flag.Duration("gop-max-duration", 10*time.Second, "GOP cache limit in duration (0: unlimited)")
+	queueSize := flag.Int("queue-size", defaultQueueSize, "per-player outbound queue size in messages")
+	handshakeTimeout := flag.Duration("handshake-timeout", rtmp.DefaultHandshakeTimeout, "close connections that do not complete the handshake in time (0: no limit)")
+	readTimeout := flag.Duration("read-timeout", 0, "close connections that send nothing for this long (0: no limit; players can be silent for minutes)")
+	writeTimeout := flag.Duration("write-timeout", 30*time.Second, "close connections whose writes make no progress for this long (0: no limit)")
+	flag.Parse()
+
+	server := NewServer()
+	server.addr = *addr
+	server.rtmptAddr = *rtmptAddr
+	server.queueSize = *queueSize
+	server.rtmp.HandshakeTimeout = *handshakeTimeout
+	server.rtmp.ReadTimeout = *readTimeout
+	server.rtmp.WriteTimeout = *writeTimeout
+	if *gopCache {
+		server.gop = &GOPConfig{
+			MaxFrames:   *gopMaxFrames,
+			MaxBytes:    *gopMaxBytes,
+			MaxDuration: *gopMaxDuration,
+		}
+	}
+	server.record = RecordConfig{
+		Dir:         *recordDir,
+		MaxSize:     *recordMaxSize,
+		MaxDuration: *recordMaxDuration,
+	}
+
+	if *certFile != "" || *keyFile != "" {
+		if err := server.EnableTLS(*tlsAddr, *certFile, *keyFile); err != nil {
+			slog.Error("Failed to enable RTMPS", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	server.Run()
 }
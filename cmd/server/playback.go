@@ -0,0 +1,260 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ssungk/ertmp/pkg/flv"
+	"github.com/ssungk/ertmp/pkg/media"
+	"github.com/ssungk/ertmp/pkg/rtmp"
+)
+
+// playbackLead is how far ahead of real time messages are sent (클라이언트 버퍼 유지)
+const playbackLead = time.Second
+
+// errPlaybackStopped is returned when playback is stopped by the client
+var errPlaybackStopped = errors.New("playback stopped")
+
+// Playback streams a recorded FLV file to a playing stream, paced by timestamp
+type Playback struct {
+	conn     *rtmp.Conn
+	stream   *rtmp.Stream
+	path     string
+	start    uint32 // 재생 시작 위치 (ms)
+	duration int64  // 재생 길이 (ms, 음수면 파일 끝까지)
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewPlayback creates a playback of path.
+// start/duration은 play 명령의 초 단위 값 (start < 0이면 처음부터, duration < 0이면 끝까지)
+func NewPlayback(conn *rtmp.Conn, stream *rtmp.Stream, path string, start, duration float64) *Playback {
+	p := &Playback{
+		conn:     conn,
+		stream:   stream,
+		path:     path,
+		duration: -1,
+		stop:     make(chan struct{}),
+	}
+	if start > 0 {
+		p.start = uint32(start * 1000)
+	}
+	if duration >= 0 {
+		p.duration = int64(duration * 1000)
+	}
+	return p
+}
+
+// Run plays the file and sends the completion events.
+// 파일 끝 또는 duration 도달 시 onPlayStatus NetStream.Play.Complete, StreamEOF, NetStream.Play.Stop 전송
+func (p *Playback) Run() {
+	id := p.stream.ID()
+
+	err := p.play()
+	if errors.Is(err, errPlaybackStopped) {
+		return
+	}
+	if err != nil {
+		slog.Error("Playback failed", "path", p.path, "error", err)
+		_ = rtmp.SendOnStatus(p.conn, id, "error", "NetStream.Play.Failed", err.Error())
+		return
+	}
+
+	slog.Info("Playback complete", "path", p.path, "streamID", id)
+	if err := rtmp.SendPlayStatus(p.conn, id, "status", "NetStream.Play.Complete"); err != nil {
+		return
+	}
+	if err := rtmp.SendStreamEOF(p.conn, id); err != nil {
+		return
+	}
+	_ = rtmp.SendOnStatus(p.conn, id, "status", "NetStream.Play.Stop", "Stopped playing")
+}
+
+// Stop stops the playback; Run returns without sending completion events
+func (p *Playback) Stop() {
+	p.stopOnce.Do(func() { close(p.stop) })
+}
+
+// play sends the tags from the seek point until the end or the duration limit
+func (p *Playback) play() error {
+	seekTS, audioOnly, err := p.seekPoint()
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(p.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := flv.NewReader(file)
+	if _, err := reader.ReadHeader(); err != nil {
+		return err
+	}
+
+	seeking := p.start > 0
+	var (
+		baseTime time.Time
+		baseTS   uint32
+		started  bool
+	)
+	for {
+		select {
+		case <-p.stop:
+			return errPlaybackStopped
+		default:
+		}
+
+		msg, err := reader.ReadMessage(p.stream.ID())
+		if err == io.EOF || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil // 녹화 중인 파일은 마지막 태그가 잘려 있을 수 있음
+		}
+		if err != nil {
+			return err
+		}
+		tag, _ := flv.TagFromMessage(msg)
+		header := isHeaderTag(tag)
+
+		// 탐색 중에는 메타데이터와 sequence header만 전송하고 탐색 지점의 keyframe까지 건너뜀
+		if seeking && !header {
+			if tag.Timestamp < seekTS || !isSeekFrame(tag, audioOnly) {
+				msg.Buffer().Release()
+				continue
+			}
+			seeking = false
+		}
+
+		if !started && !header {
+			started = true
+			baseTime = time.Now()
+			baseTS = tag.Timestamp
+		}
+
+		if started {
+			elapsed := int64(tag.Timestamp) - int64(baseTS)
+			if p.duration >= 0 && elapsed > p.duration {
+				msg.Buffer().Release()
+				return nil
+			}
+			if err := p.wait(baseTime.Add(time.Duration(elapsed)*time.Millisecond - playbackLead)); err != nil {
+				msg.Buffer().Release()
+				return err
+			}
+		}
+
+		err = p.conn.WriteMessage(msg)
+		msg.Buffer().Release()
+		if err != nil {
+			return fmt.Errorf("write: %w", err)
+		}
+	}
+}
+
+// seekPoint scans the file for the keyframe nearest to the start position.
+// 비디오가 없는 파일은 start 이후 첫 audio 프레임
+func (p *Playback) seekPoint() (ts uint32, audioOnly bool, err error) {
+	if p.start == 0 {
+		return 0, false, nil
+	}
+
+	file, err := os.Open(p.path)
+	if err != nil {
+		return 0, false, err
+	}
+	defer file.Close()
+
+	reader := flv.NewReader(file)
+	if _, err := reader.ReadHeader(); err != nil {
+		return 0, false, err
+	}
+
+	hasVideo := false
+	lastKey, hasKey := uint32(0), false
+	for {
+		tag, err := reader.ReadTag()
+		if err == io.EOF || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return 0, false, err
+		}
+		if isHeaderTag(tag) {
+			continue
+		}
+
+		if tag.Type == flv.TagTypeVideo {
+			hasVideo = true
+		}
+		if !hasVideo && tag.Type == flv.TagTypeAudio && tag.Timestamp >= p.start {
+			return tag.Timestamp, true, nil
+		}
+		if !isSeekFrame(tag, false) {
+			continue
+		}
+
+		if tag.Timestamp >= p.start {
+			// start 앞뒤 keyframe 중 가까운 쪽
+			if hasKey && p.start-lastKey <= tag.Timestamp-p.start {
+				return lastKey, false, nil
+			}
+			return tag.Timestamp, false, nil
+		}
+		lastKey, hasKey = tag.Timestamp, true
+	}
+
+	// start 이후 keyframe 없음: 마지막 keyframe부터 재생
+	if hasKey {
+		return lastKey, false, nil
+	}
+	return p.start, !hasVideo, nil
+}
+
+// wait sleeps until t or until the playback is stopped
+func (p *Playback) wait(t time.Time) error {
+	d := time.Until(t)
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-p.stop:
+		return errPlaybackStopped
+	}
+}
+
+// isHeaderTag reports whether a tag is metadata or decoder configuration
+// (재생 위치와 무관하게 항상 전송)
+func isHeaderTag(tag flv.Tag) bool {
+	switch tag.Type {
+	case flv.TagTypeScript:
+		return true
+	case flv.TagTypeVideo:
+		video, err := tag.Video()
+		return err == nil && video.IsSequenceHeader()
+	case flv.TagTypeAudio:
+		audio, err := tag.Audio()
+		return err == nil && (audio.IsSequenceHeader() ||
+			(audio.IsExHeader && audio.PacketType == media.AudioPacketMultichannelConfig))
+	}
+	return false
+}
+
+// isSeekFrame reports whether playback can start at a tag
+// (video keyframe, 오디오 전용 파일이면 audio 프레임)
+func isSeekFrame(tag flv.Tag, audioOnly bool) bool {
+	if audioOnly {
+		return tag.Type == flv.TagTypeAudio
+	}
+	return tag.IsKeyFrame()
+}
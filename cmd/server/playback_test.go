@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ssungk/ertmp/pkg/amf"
+	"github.com/ssungk/ertmp/pkg/flv"
+	"github.com/ssungk/ertmp/pkg/rtmp"
+	"github.com/ssungk/ertmp/pkg/rtmp/transport"
+)
+
+func TestPlayback(t *testing.T) {
+	tests := []struct {
+		name     string
+		start    float64
+		duration float64
+		reset    bool
+		want     []uint32 // 전송된 미디어 timestamp (header 제외)
+	}{
+		{"from start", -2, -1, false, []uint32{0, 100, 200, 300, 400, 500, 600, 700}},
+		{"start zero", 0, -1, false, []uint32{0, 100, 200, 300, 400, 500, 600, 700}},
+		{"seek to next keyframe", 0.35, -1, false, []uint32{400, 500, 600, 700}},
+		{"seek to previous keyframe", 0.25, -1, false, []uint32{200, 300, 400, 500, 600, 700}},
+		{"seek past last keyframe", 2, -1, false, []uint32{600, 700}},
+		{"duration", 0, 0.25, false, []uint32{0, 100, 200}},
+		{"seek and duration", 0.2, 0.1, false, []uint32{200, 300}},
+		{"reset", -2, -1, true, []uint32{0, 100, 200, 300, 400, 500, 600, 700}},
+	}
+
+	dir := t.TempDir()
+	writeTestFLV(t, filepath.Join(dir, "live", "vod.flv"))
+	s := NewServer()
+	s.record = RecordConfig{Dir: dir}
+	addr := startTestServer(t, s)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, streamID := dialTestPlayer(t, addr)
+			if err := rtmp.SendCommand(conn, streamID, "play", 0, nil, "vod", tt.start, tt.duration, tt.reset); err != nil {
+				t.Fatalf("play failed: %v", err)
+			}
+
+			events := readPlayEvents(t, conn, "NetStream.Play.Stop")
+			var got []uint32
+			headers := 0
+			for _, e := range events {
+				if e.msgType != transport.MsgTypeVideo {
+					continue
+				}
+				if e.data[1] == 0x00 { // AVC sequence header
+					headers++
+					continue
+				}
+				got = append(got, e.timestamp)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("expected media timestamps %v, got %v", tt.want, got)
+			}
+			if headers != 1 {
+				t.Errorf("expected the sequence header once, got %d", headers)
+			}
+
+			codes := eventCodes(events)
+			want := []string{"user:4", "user:0", "NetStream.Play.Start", "onMetaData", "NetStream.Play.Complete", "user:1", "NetStream.Play.Stop"}
+			if tt.reset {
+				want = slices.Insert(want, 2, "NetStream.Play.Reset")
+			}
+			if !slices.Equal(codes, want) {
+				t.Errorf("expected events %v, got %v", want, codes)
+			}
+		})
+	}
+}
+
+func TestPlayback_NotFound(t *testing.T) {
+	s := NewServer()
+	s.record = RecordConfig{Dir: t.TempDir()}
+	addr := startTestServer(t, s)
+
+	conn, streamID := dialTestPlayer(t, addr)
+	if err := rtmp.SendCommand(conn, streamID, "play", 0, nil, "missing", 0.0); err != nil {
+		t.Fatalf("play failed: %v", err)
+	}
+	events := readPlayEvents(t, conn, "NetStream.Play.StreamNotFound")
+	if codes := eventCodes(events); !slices.Contains(codes, "NetStream.Play.StreamNotFound") {
+		t.Errorf("expected NetStream.Play.StreamNotFound, got %v", codes)
+	}
+}
+
+// writeTestFLV writes a file with metadata, a sequence header, keyframes every 200ms
+// and inter frames in between (0 ~ 700ms)
+func writeTestFLV(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	w := flv.NewWriter(file)
+	if err := w.WriteHeader(flv.Header{HasVideo: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteScriptData(0, "onMetaData", amf.NewECMAArray(amf.Property{Key: "duration", Value: 0.7})); err != nil {
+		t.Fatal(err)
+	}
+	tags := []flv.Tag{{Type: flv.TagTypeVideo, Data: testVideoHeader}}
+	for ts := uint32(0); ts < 800; ts += 100 {
+		frame := testInterFrame
+		if ts%200 == 0 {
+			frame = testKeyFrame
+		}
+		tags = append(tags, flv.Tag{Type: flv.TagTypeVideo, Timestamp: ts, Data: frame})
+	}
+	for _, tag := range tags {
+		if err := w.WriteTag(tag); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// startTestServer serves s on a loopback listener and returns its address
+func startTestServer(t *testing.T, s *Server) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	go s.rtmp.Serve(listener)
+	t.Cleanup(func() { s.rtmp.Close() })
+	return listener.Addr().String()
+}
+
+// dialTestPlayer connects to app "live" and creates a stream
+func dialTestPlayer(t *testing.T, addr string) (*rtmp.Conn, uint32) {
+	t.Helper()
+	netConn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	conn, err := rtmp.DialConn(netConn)
+	if err != nil {
+		t.Fatalf("DialConn failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := conn.Call(ctx, "connect", map[string]interface{}{"app": "live"}); err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+	result, err := conn.Call(ctx, "createStream", nil)
+	if err != nil {
+		t.Fatalf("createStream failed: %v", err)
+	}
+	id, ok := result.Arguments[len(result.Arguments)-1].(float64)
+	if !ok {
+		t.Fatalf("unexpected createStream result %v", result.Arguments)
+	}
+	return conn, uint32(id)
+}
+
+// playEvent is a message received by a test player
+type playEvent struct {
+	msgType   uint8
+	timestamp uint32
+	data      []byte
+	code      string // onStatus/onPlayStatus code, data 이름 또는 "user:<event>"
+}
+
+// readPlayEvents reads messages until the status code last arrives
+func readPlayEvents(t *testing.T, conn *rtmp.Conn, last string) []playEvent {
+	t.Helper()
+	return readEvents(t, conn, func(e playEvent) bool { return e.code == last })
+}
+
+// readEvents reads messages until done returns true for a received message
+func readEvents(t *testing.T, conn *rtmp.Conn, done func(e playEvent) bool) []playEvent {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var events []playEvent
+	for {
+		msg, err := conn.ReadMessageContext(ctx)
+		if err != nil {
+			t.Fatalf("read failed after %v: %v", eventCodes(events), err)
+		}
+		e := playEvent{msgType: msg.Type(), timestamp: msg.Timestamp(), data: slices.Clone(msg.Data())}
+		msg.Buffer().Release()
+
+		switch e.msgType {
+		case transport.MsgTypeUserControl:
+			e.code = fmt.Sprintf("user:%d", binary.BigEndian.Uint16(e.data))
+		case transport.MsgTypeAMF0Command:
+			if cmd, err := rtmp.DecodeCommand(e.data); err == nil && len(cmd.Arguments) > 0 {
+				if info, ok := cmd.Arguments[0].(map[string]interface{}); ok {
+					e.code, _ = info["code"].(string)
+				}
+			}
+		case transport.MsgTypeAMF0Data:
+			values, err := flv.DecodeScriptData(e.data)
+			if err == nil && len(values) > 0 {
+				e.code, _ = values[0].(string)
+				if info, ok := values[len(values)-1].(map[string]interface{}); ok && e.code == "onPlayStatus" {
+					e.code, _ = info["code"].(string)
+				}
+			}
+		}
+		events = append(events, e)
+		if done(e) {
+			return events
+		}
+	}
+}
+
+// eventCodes returns the non-empty codes of events in order
+func eventCodes(events []playEvent) []string {
+	var codes []string
+	for _, e := range events {
+		if e.code != "" {
+			codes = append(codes, e.code)
+		}
+	}
+	return codes
+}
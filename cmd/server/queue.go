@@ -0,0 +1,279 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ssungk/ertmp/pkg/rtmp"
+	"github.com/ssungk/ertmp/pkg/rtmp/transport"
+)
+
+// defaultQueueSize is the default number of queued messages per subscriber
+const defaultQueueSize = 512
+
+// frameKind classifies a queued message for the drop policy
+type frameKind int
+
+const (
+	frameHeader frameKind = iota // 메타데이터, sequence header (폐기하지 않음)
+	frameKey                     // video keyframe
+	frameInter                   // video non-key frame
+	frameAudio
+)
+
+// sendQueue is a bounded outbound queue of a subscriber drained by its own goroutine.
+// 가득 차면 오래된 GOP부터 non-key video를 필요한 만큼 버리고 (남은 GOP는 keyframe까지 video를 건너뜀),
+// 그래도 부족하면 오래된 keyframe, 마지막으로 오래된 audio 순으로 버리며 header는 버리지 않음
+type sendQueue struct {
+	conn  *rtmp.Conn
+	limit int
+
+	mu      sync.Mutex
+	items   []queueItem
+	waitKey bool // video 폐기 후 다음 keyframe 대기 중
+	closed  bool
+	ready   chan struct{} // 1개 버퍼, 새 메시지 알림
+
+	droppedVideo atomic.Uint64
+	droppedAudio atomic.Uint64
+}
+
+// queueItem is a queued message; 버퍼는 큐가 소유하며 전송 또는 폐기 후 해제
+type queueItem struct {
+	msg  transport.Message
+	kind frameKind
+}
+
+// newSendQueue creates a queue for conn; limit <= 0이면 기본값 사용.
+// 상대의 송신 창이 가득 차면 non-key video는 전송 대신 버리도록 conn의 flow mode를 설정
+func newSendQueue(conn *rtmp.Conn, limit int) *sendQueue {
+	if limit <= 0 {
+		limit = defaultQueueSize
+	}
+	conn.SetFlowMode(transport.FlowShed)
+	return &sendQueue{
+		conn:  conn,
+		limit: limit,
+		ready: make(chan struct{}, 1),
+	}
+}
+
+// push queues a message and takes ownership of its buffer
+func (q *sendQueue) push(msg transport.Message, kind frameKind) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		msg.Buffer().Release()
+		return
+	}
+
+	// 참조 프레임이 빠진 non-key video는 디코딩할 수 없으므로 keyframe까지 버림
+	if kind == frameInter && q.waitKey {
+		q.drop(msg, kind)
+		return
+	}
+
+	if kind != frameHeader && len(q.items) >= q.limit {
+		q.shed()
+		if kind == frameInter && q.waitKey {
+			q.drop(msg, kind) // 앞선 참조 프레임이 방금 폐기됨
+			return
+		}
+		if len(q.items) >= q.limit && kind != frameAudio {
+			q.drop(msg, kind)
+			q.startWaitKey()
+			return
+		}
+	}
+
+	if kind == frameKey {
+		q.waitKey = false
+	}
+	q.items = append(q.items, queueItem{msg: msg, kind: kind})
+
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// shed frees a slot by the drop policy, oldest first (q.mu must be held)
+func (q *sendQueue) shed() {
+	// 1. 오래된 GOP의 non-key video
+	for len(q.items) >= q.limit && q.shedInter() {
+	}
+	// 2. 오래된 keyframe
+	for len(q.items) >= q.limit && q.shedFirst(frameKey) {
+	}
+	// 3. 오래된 audio
+	for len(q.items) >= q.limit && q.shedFirst(frameAudio) {
+	}
+}
+
+// shedInter drops the non-key video of the oldest GOP that has any (q.mu must be held)
+// 참조 프레임이 빠지므로 GOP 끝(다음 keyframe)까지 함께 버림
+func (q *sendQueue) shedInter() bool {
+	start := q.index(frameInter, 0)
+	if start < 0 {
+		return false
+	}
+	end := q.index(frameKey, start)
+	if end < 0 {
+		end = len(q.items)
+		q.startWaitKey() // 마지막 GOP: 이후 들어오는 non-key video도 디코딩 불가
+	}
+	q.remove(frameInter, start, end)
+	return true
+}
+
+// shedFirst drops the oldest queued item of kind (q.mu must be held)
+func (q *sendQueue) shedFirst(kind frameKind) bool {
+	i := q.index(kind, 0)
+	if i < 0 {
+		return false
+	}
+	q.remove(kind, i, i+1)
+	if kind == frameKey && q.index(frameKey, i) < 0 {
+		q.startWaitKey()
+	}
+	return true
+}
+
+// index returns the index of the first queued item of kind at or after from, or -1 (q.mu must be held)
+func (q *sendQueue) index(kind frameKind, from int) int {
+	for i := from; i < len(q.items); i++ {
+		if q.items[i].kind == kind {
+			return i
+		}
+	}
+	return -1
+}
+
+// remove drops the items of kind in q.items[start:end] (q.mu must be held)
+func (q *sendQueue) remove(kind frameKind, start, end int) {
+	kept := q.items[:start]
+	for _, item := range q.items[start:end] {
+		if item.kind == kind {
+			q.drop(item.msg, item.kind)
+			continue
+		}
+		kept = append(kept, item)
+	}
+	kept = append(kept, q.items[end:]...)
+	clear(q.items[len(kept):])
+	q.items = kept
+}
+
+// drop releases a message and counts it (q.mu must be held)
+func (q *sendQueue) drop(msg transport.Message, kind frameKind) {
+	msg.Buffer().Release()
+	if kind == frameAudio {
+		q.droppedAudio.Add(1)
+	} else {
+		q.droppedVideo.Add(1)
+	}
+}
+
+// startWaitKey skips video until the next keyframe (q.mu must be held)
+func (q *sendQueue) startWaitKey() {
+	if !q.waitKey {
+		slog.Warn("Subscriber queue full, dropping frames",
+			"address", q.conn.RemoteAddr(),
+			"queued", len(q.items),
+			"inFlight", q.conn.BytesInFlight())
+	}
+	q.waitKey = true
+}
+
+// pop waits for and removes the oldest queued item; returns false when closed
+// 한 번에 하나씩 꺼내므로 큐 밖에서 대기하는 메시지는 전송 중인 하나뿐 (limit이 실제 상한)
+func (q *sendQueue) pop() (queueItem, bool) {
+	for {
+		q.mu.Lock()
+		if q.closed {
+			q.mu.Unlock()
+			return queueItem{}, false
+		}
+		if len(q.items) > 0 {
+			item := q.items[0]
+			q.items[0] = queueItem{}
+			q.items = q.items[1:]
+			q.mu.Unlock()
+			return item, true
+		}
+		q.mu.Unlock()
+		<-q.ready
+	}
+}
+
+// run writes queued messages to the connection until the queue is closed or a write fails.
+// 송신 창이 가득 차 non-key video가 거부되면 다음 keyframe까지 video를 건너뜀
+func (q *sendQueue) run() {
+	skipVideo := false
+	for {
+		item, ok := q.pop()
+		if !ok {
+			return
+		}
+
+		switch {
+		case item.kind == frameKey:
+			skipVideo = false
+		case item.kind == frameInter && skipVideo:
+			q.discard(item)
+			continue
+		}
+
+		err := q.conn.WriteMessage(item.msg)
+		if errors.Is(err, transport.ErrWindowFull) {
+			skipVideo = true
+			q.discard(item)
+			continue
+		}
+		item.msg.Buffer().Release()
+		if err != nil {
+			slog.Error("Failed to send to subscriber", "type", item.msg.Type(), "error", err)
+			q.close()
+			// 일부만 전송된 메시지로 청크 상태가 어긋났을 수 있으므로 연결 종료
+			// (읽기 루프가 끝나며 구독자 정리)
+			q.conn.Close()
+			return
+		}
+	}
+}
+
+// discard drops an item taken out of the queue by the writer
+func (q *sendQueue) discard(item queueItem) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.drop(item.msg, item.kind)
+	q.startWaitKey()
+}
+
+// close releases the queued messages and stops the writer goroutine
+func (q *sendQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return
+	}
+	q.closed = true
+	for _, item := range q.items {
+		item.msg.Buffer().Release()
+	}
+	q.items = nil
+
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// dropped returns the number of dropped video and audio messages
+func (q *sendQueue) dropped() (video, audio uint64) {
+	return q.droppedVideo.Load(), q.droppedAudio.Load()
+}
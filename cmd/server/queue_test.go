@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"net"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ssungk/ertmp/pkg/rtmp"
+	"github.com/ssungk/ertmp/pkg/rtmp/buf"
+	"github.com/ssungk/ertmp/pkg/rtmp/transport"
+)
+
+func TestSendQueue_Shed(t *testing.T) {
+	const (
+		H = frameHeader
+		K = frameKey
+		I = frameInter
+		A = frameAudio
+	)
+	tests := []struct {
+		name         string
+		push         []frameKind // timestamp는 push 순서
+		want         []uint32    // 큐에 남은 timestamp
+		droppedVideo uint64
+		droppedAudio uint64
+		waitKey      bool
+	}{
+		{"under limit", []frameKind{H, K, I, I}, []uint32{0, 1, 2, 3}, 0, 0, false},
+		{"oldest GOP first", []frameKind{K, I, I, K, I}, []uint32{0, 3, 4}, 2, 0, false},
+		{"oldest GOP only", []frameKind{K, I, K, I, I}, []uint32{0, 2, 3, 4}, 1, 0, false},
+		{"last GOP waits for keyframe", []frameKind{K, I, I, I, I}, []uint32{0}, 4, 0, true},
+		{"keyframe ends wait", []frameKind{K, I, I, I, I, K}, []uint32{0, 5}, 4, 0, false},
+		{"oldest keyframe", []frameKind{K, K, K, K, K}, []uint32{1, 2, 3, 4}, 1, 0, false},
+		{"oldest audio", []frameKind{A, A, A, A, A}, []uint32{1, 2, 3, 4}, 0, 1, false},
+		{"video before audio", []frameKind{K, A, I, A, A}, []uint32{0, 1, 3, 4}, 1, 0, true},
+		{"headers kept", []frameKind{H, H, H, H, K, I, H}, []uint32{0, 1, 2, 3, 6}, 2, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, server := newTestConnPair(t)
+			q := newSendQueue(server, 4)
+			var released atomic.Int32
+			for i, kind := range tt.push {
+				q.push(newQueueMessage(uint32(i), kind, &released), kind)
+			}
+
+			var got []uint32
+			for _, item := range q.items {
+				got = append(got, item.msg.Timestamp())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("expected queued timestamps %v, got %v", tt.want, got)
+			}
+			if video, audio := q.dropped(); video != tt.droppedVideo || audio != tt.droppedAudio {
+				t.Errorf("expected dropped video/audio %d/%d, got %d/%d", tt.droppedVideo, tt.droppedAudio, video, audio)
+			}
+			if q.waitKey != tt.waitKey {
+				t.Errorf("expected waitKey %v, got %v", tt.waitKey, q.waitKey)
+			}
+			if want := int32(len(tt.push) - len(tt.want)); released.Load() != want {
+				t.Errorf("expected %d released buffers, got %d", want, released.Load())
+			}
+
+			q.close()
+			if released.Load() != int32(len(tt.push)) {
+				t.Errorf("close: expected all %d buffers released, got %d", len(tt.push), released.Load())
+			}
+		})
+	}
+}
+
+func TestSendQueue_Run(t *testing.T) {
+	client, server := newTestConnPair(t)
+	q := newSendQueue(server, 4)
+	done := make(chan struct{})
+	go func() {
+		q.run()
+		close(done)
+	}()
+
+	// 큐 한도보다 많은 메시지도 쓰는 쪽이 비우는 동안 순서대로 전달
+	kinds := []frameKind{frameHeader, frameKey, frameAudio, frameInter, frameAudio, frameInter, frameKey, frameInter}
+	var released atomic.Int32
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for i, kind := range kinds {
+		q.push(newQueueMessage(uint32(i), kind, &released), kind)
+		msg, err := client.ReadMessageContext(ctx)
+		if err != nil {
+			t.Fatalf("read failed: %v", err)
+		}
+		if msg.Timestamp() != uint32(i) {
+			t.Errorf("expected timestamp %d, got %d", i, msg.Timestamp())
+		}
+		msg.Buffer().Release()
+	}
+
+	q.close()
+	<-done
+	if released.Load() != int32(len(kinds)) {
+		t.Errorf("expected %d released buffers, got %d", len(kinds), released.Load())
+	}
+	if video, audio := q.dropped(); video+audio != 0 {
+		t.Errorf("expected no drops, got video %d audio %d", video, audio)
+	}
+}
+
+func TestSendQueue_WriteError(t *testing.T) {
+	client, server := newTestConnPair(t)
+	client.Close()
+	server.Close()
+
+	q := newSendQueue(server, 4)
+	var released atomic.Int32
+	for i, kind := range []frameKind{frameKey, frameInter, frameAudio} {
+		q.push(newQueueMessage(uint32(i), kind, &released), kind)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		q.run()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("writer did not stop after a write error")
+	}
+	if released.Load() != 3 {
+		t.Errorf("expected 3 released buffers, got %d", released.Load())
+	}
+}
+
+// newQueueMessage creates a media message of kind whose buffer counts its release in released
+func newQueueMessage(ts uint32, kind frameKind, released *atomic.Int32) transport.Message {
+	msgType := uint8(transport.MsgTypeVideo)
+	if kind == frameAudio {
+		msgType = transport.MsgTypeAudio
+	}
+	b := buf.NewWithFinalizer([]byte{0x17, 0x01}, func([]byte) { released.Add(1) })
+	return transport.NewMessage(transport.NewMessageHeader(1, ts, msgType), b)
+}
+
+// newTestConnPair creates a connected client/server Conn pair over loopback TCP
+func newTestConnPair(t *testing.T) (client, server *rtmp.Conn) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer listener.Close()
+
+	serverCh := make(chan *rtmp.Conn, 1)
+	go func() {
+		netConn, err := listener.Accept()
+		if err != nil {
+			serverCh <- nil
+			return
+		}
+		conn, err := rtmp.AcceptConn(netConn)
+		if err != nil {
+			netConn.Close()
+		}
+		serverCh <- conn
+	}()
+
+	netConn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	client, err = rtmp.DialConn(netConn)
+	if err != nil {
+		t.Fatalf("DialConn failed: %v", err)
+	}
+	server = <-serverCh
+	if server == nil {
+		t.Fatal("server handshake failed")
+	}
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+	return client, server
+}
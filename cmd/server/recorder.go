@@ -0,0 +1,484 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ssungk/ertmp/pkg/amf"
+	"github.com/ssungk/ertmp/pkg/flv"
+	"github.com/ssungk/ertmp/pkg/rtmp/transport"
+)
+
+// RecordConfig holds recording options
+type RecordConfig struct {
+	Dir         string        // 녹화 루트 디렉터리 (비어 있으면 녹화 비활성화)
+	MaxSize     int64         // 파일 최대 크기 (bytes, 0이면 무제한)
+	MaxDuration time.Duration // 파일 최대 길이 (0이면 무제한)
+}
+
+// Enabled reports whether recording is configured
+func (c RecordConfig) Enabled() bool {
+	return c.Dir != ""
+}
+
+// ErrInvalidRecordPath is returned when app/key would escape the record directory
+var ErrInvalidRecordPath = errors.New("invalid record path")
+
+// onMetaData에 기록 후 종료 시 갱신하는 항목
+const (
+	metaDuration = "duration"
+	metaFilesize = "filesize"
+)
+
+// Recorder writes a published stream to FLV files.
+// <dir>/<app>/<key>.flv에 기록하며, 회전 시 <key>-1.flv, <key>-2.flv ... 생성.
+// append는 가장 마지막 회전 파일에 이어서 기록하고, 기존 회전 파일은 덮어쓰지 않음.
+// publisher의 연결 고루틴에서만 호출되므로 동기화하지 않음
+type Recorder struct {
+	config  RecordConfig
+	base    string // 확장자를 제외한 경로
+	append  bool
+	headers func() (video, audio [][]byte) // 회전 시 새 파일에 다시 쓸 sequence header
+
+	index    int
+	file     *os.File
+	bw       *bufio.Writer
+	writer   *flv.Writer
+	fileSize int64 // 열었을 때의 파일 크기 (append)
+
+	started  bool   // 현재 파일에 첫 메시지 기록 여부
+	startTS  uint32 // 현재 파일의 첫 메시지 timestamp (입력 기준)
+	tsOffset uint32 // append 시 기존 파일의 마지막 timestamp + 프레임 간격
+	lastTS   uint32 // 마지막으로 기록한 timestamp (파일 기준)
+
+	durationPos int64 // duration 값 위치 (-1이면 없음)
+	filesizePos int64 // filesize 값 위치 (-1이면 없음)
+
+	metadata *amf.Object // publisher의 onMetaData, 순서 유지 (회전 시 재사용)
+	hasVideo bool
+	rotate   bool // 한도 초과, 다음 keyframe에서 회전
+}
+
+// NewRecorder opens the record file of app/key.
+// mode "record"는 기존 파일을 덮어쓰고 이전 녹화의 회전 파일을 삭제하며, "append"는 기존 파일 뒤에 timestamp를 이어서 기록
+func NewRecorder(config RecordConfig, app, key, mode string, headers func() (video, audio [][]byte)) (*Recorder, error) {
+	path, err := recordPath(config.Dir, app, key)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Recorder{
+		config:  config,
+		base:    path,
+		append:  mode == "append",
+		headers: headers,
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	if r.append {
+		r.index = r.lastIndex()
+	} else if err := r.removeRotations(); err != nil {
+		return nil, err
+	}
+	if err := r.open(r.append); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// recordPath returns <dir>/<app>/<key> without the extension.
+// app/key가 dir 밖을 가리키면 ErrInvalidRecordPath 반환
+func recordPath(dir, app, key string) (string, error) {
+	path := filepath.Join(dir, app, key)
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s/%s: %w", app, key, ErrInvalidRecordPath)
+	}
+	return path, nil
+}
+
+// Path returns the path of the current file
+func (r *Recorder) Path() string {
+	if r.index == 0 {
+		return r.base + ".flv"
+	}
+	return r.base + "-" + strconv.Itoa(r.index) + ".flv"
+}
+
+// lastIndex returns the highest rotation index whose file exists (없으면 0)
+func (r *Recorder) lastIndex() int {
+	last := 0
+	for i := 1; ; i++ {
+		if _, err := os.Stat(r.base + "-" + strconv.Itoa(i) + ".flv"); err != nil {
+			return last
+		}
+		last = i
+	}
+}
+
+// removeRotations deletes the rotation files of a previous recording.
+// 남겨 두면 이후 append가 이전 녹화의 마지막 회전 파일에 이어서 기록함
+func (r *Recorder) removeRotations() error {
+	for i := 1; ; i++ {
+		err := os.Remove(r.base + "-" + strconv.Itoa(i) + ".flv")
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// WriteMessage records an audio, video or AMF0 data message; other types are ignored
+func (r *Recorder) WriteMessage(msg transport.Message) error {
+	tag, ok := flv.TagFromMessage(msg)
+	if !ok {
+		return nil
+	}
+
+	if tag.Type == flv.TagTypeScript {
+		values, err := flv.DecodeScriptDataOrdered(tag.Data)
+		if err != nil {
+			return fmt.Errorf("decode script data: %w", err)
+		}
+		// @setDataFrame은 RTMP 전송용 래퍼이므로 파일에는 제외
+		// (rtmp.SendMetadata처럼 transaction ID, null이 뒤따르는 경우도 함께 제거)
+		if len(values) > 0 && values[0] == "@setDataFrame" {
+			values = values[1:]
+			for len(values) > 0 {
+				if _, ok := values[0].(string); ok {
+					break
+				}
+				values = values[1:]
+			}
+			if tag.Data, err = amf.EncodeAMF0Sequence(values...); err != nil {
+				return fmt.Errorf("encode script data: %w", err)
+			}
+		}
+		if len(values) > 1 && values[0] == "onMetaData" {
+			if meta := scriptObject(values[1]); meta != nil {
+				r.metadata = meta
+				if !r.started {
+					// 파일 선두의 onMetaData로 기록
+					return r.start(msg.Timestamp())
+				}
+			}
+		}
+	}
+
+	if tag.Type == flv.TagTypeVideo {
+		r.hasVideo = true
+	}
+
+	// 한도 초과 후 다음 video keyframe(오디오 전용이면 다음 audio)에서 회전
+	if r.rotate && (tag.IsKeyFrame() || (!r.hasVideo && tag.Type == flv.TagTypeAudio)) {
+		if err := r.next(tag.Timestamp); err != nil {
+			return err
+		}
+	}
+
+	if !r.started {
+		if err := r.start(tag.Timestamp); err != nil {
+			return err
+		}
+	}
+
+	tag.Timestamp = r.timestamp(tag.Timestamp)
+	if err := r.writer.WriteTag(tag); err != nil {
+		return err
+	}
+	r.lastTS = max(r.lastTS, tag.Timestamp)
+
+	r.checkLimits()
+	return nil
+}
+
+// Close finalizes duration/filesize metadata and closes the file
+func (r *Recorder) Close() error {
+	if r.file == nil {
+		return nil
+	}
+	err := r.finalize()
+	if closeErr := r.file.Close(); err == nil {
+		err = closeErr
+	}
+	r.file = nil
+	return err
+}
+
+// open opens the current file; resume이면 기존 파일이 있을 때 이어서 기록
+func (r *Recorder) open(resume bool) error {
+	r.started = false
+	r.lastTS = 0
+	r.tsOffset = 0
+	r.fileSize = 0
+	r.durationPos = -1
+	r.filesizePos = -1
+
+	path := r.Path()
+	flags := os.O_RDWR | os.O_CREATE | os.O_TRUNC
+	switch {
+	case resume:
+		// O_APPEND는 WriteAt(메타데이터 갱신)과 함께 쓸 수 없으므로 끝으로 Seek
+		flags = os.O_RDWR | os.O_CREATE
+	case r.append:
+		// append 중의 회전은 기존 파일을 덮어쓰지 않음
+		flags = os.O_RDWR | os.O_CREATE | os.O_EXCL
+	}
+	file, err := os.OpenFile(path, flags, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if resume {
+		if err := r.scan(file); err != nil {
+			file.Close()
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	r.file = file
+	r.bw = bufio.NewWriter(file)
+	r.writer = flv.NewWriter(r.bw)
+	return nil
+}
+
+// scan reads an existing file for its last timestamp and metadata positions,
+// truncates a partially written last tag, and seeks to the end.
+// 이어서 기록할 timestamp는 마지막 timestamp에 마지막 프레임 간격(최소 1ms)을 더한 값
+func (r *Recorder) scan(file *os.File) error {
+	reader := flv.NewReader(file)
+	if _, err := reader.ReadHeader(); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil // 빈 파일은 새로 기록
+		}
+		return err
+	}
+
+	// 이 recorder가 기록한 파일은 data offset이 HeaderSize
+	pos := int64(flv.HeaderSize + flv.PreviousTagSizeSize)
+	first := true
+	last := [3]int64{-1, -1, -1} // 태그 종류별 마지막 두 timestamp (audio, video, script)
+	prev := last
+	for {
+		tag, err := reader.ReadTag()
+		if err == io.EOF {
+			break
+		}
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			break // 비정상 종료로 잘린 태그는 버림
+		}
+		if err != nil {
+			return err
+		}
+
+		if first && tag.Type == flv.TagTypeScript {
+			dataPos := pos + flv.TagHeaderSize
+			r.durationPos = metaNumberPos(tag.Data, metaDuration, dataPos)
+			r.filesizePos = metaNumberPos(tag.Data, metaFilesize, dataPos)
+		}
+		first = false
+
+		if tag.Type == flv.TagTypeVideo {
+			r.hasVideo = true
+		}
+		if i := tagKind(tag.Type); int64(tag.Timestamp) > last[i] {
+			prev[i], last[i] = last[i], int64(tag.Timestamp)
+		}
+		r.lastTS = max(r.lastTS, tag.Timestamp)
+		pos += int64(flv.TagHeaderSize + len(tag.Data) + flv.PreviousTagSizeSize)
+	}
+
+	if err := file.Truncate(pos); err != nil {
+		return err
+	}
+	if _, err := file.Seek(pos, io.SeekStart); err != nil {
+		return err
+	}
+	r.fileSize = pos
+	if pos > flv.HeaderSize+flv.PreviousTagSizeSize {
+		// video가 있으면 video, 없으면 audio의 프레임 간격
+		i := tagKind(flv.TagTypeAudio)
+		if r.hasVideo {
+			i = tagKind(flv.TagTypeVideo)
+		}
+		r.tsOffset = r.lastTS + frameInterval(prev[i], last[i])
+	}
+	return nil
+}
+
+// tagKind returns the index of a tag type for per-type bookkeeping
+func tagKind(tagType uint8) int {
+	switch tagType {
+	case flv.TagTypeAudio:
+		return 0
+	case flv.TagTypeVideo:
+		return 1
+	}
+	return 2
+}
+
+// frameInterval returns the gap between the last two frames, 1ms if unknown or over a second
+func frameInterval(prev, last int64) uint32 {
+	if prev < 0 || last-prev > 1000 {
+		return 1
+	}
+	return uint32(last - prev)
+}
+
+// start writes the FLV header and onMetaData with duration/filesize placeholders
+func (r *Recorder) start(timestamp uint32) error {
+	r.started = true
+	r.startTS = timestamp
+	if r.fileSize > 0 {
+		return nil // append: 기존 파일의 header와 메타데이터 사용
+	}
+
+	header := flv.Header{HasAudio: true, HasVideo: true}
+	if r.metadata != nil {
+		_, hasVideo := r.metadata.Get("videocodecid")
+		_, hasAudio := r.metadata.Get("audiocodecid")
+		if hasVideo || hasAudio {
+			header = flv.Header{HasAudio: hasAudio, HasVideo: hasVideo}
+		}
+	}
+	if err := r.writer.WriteHeader(header); err != nil {
+		return err
+	}
+
+	// publisher가 보낸 순서를 유지하고 ECMA array로 기록 (FLV 관례)
+	meta := amf.NewECMAArray()
+	if r.metadata != nil {
+		meta.Properties = slices.Clone(r.metadata.Properties)
+	}
+	meta.Set(metaDuration, 0.0)
+	meta.Set(metaFilesize, 0.0)
+
+	data, err := amf.EncodeAMF0Sequence("onMetaData", meta)
+	if err != nil {
+		return fmt.Errorf("encode metadata: %w", err)
+	}
+	dataPos := r.writer.Written() + flv.TagHeaderSize
+	if err := r.writer.WriteTag(flv.Tag{Type: flv.TagTypeScript, Data: data}); err != nil {
+		return err
+	}
+	r.durationPos = metaNumberPos(data, metaDuration, dataPos)
+	r.filesizePos = metaNumberPos(data, metaFilesize, dataPos)
+	return nil
+}
+
+// next finalizes the current file and continues in the next rotation file
+// 새 파일은 header, 메타데이터, sequence header부터 기록하며 timestamp는 ts부터 0으로 시작
+func (r *Recorder) next(ts uint32) error {
+	if err := r.Close(); err != nil {
+		return err
+	}
+
+	r.rotate = false
+	r.index++
+	if r.append {
+		r.index = max(r.index, r.lastIndex()+1)
+	}
+	if err := r.open(false); err != nil {
+		return err
+	}
+	if err := r.start(ts); err != nil {
+		return err
+	}
+
+	video, audio := r.headers()
+	for _, data := range video {
+		if err := r.writer.WriteTag(flv.Tag{Type: flv.TagTypeVideo, Data: data}); err != nil {
+			return err
+		}
+	}
+	for _, data := range audio {
+		if err := r.writer.WriteTag(flv.Tag{Type: flv.TagTypeAudio, Data: data}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// timestamp converts an input timestamp to the file timeline
+func (r *Recorder) timestamp(ts uint32) uint32 {
+	if ts < r.startTS {
+		ts = r.startTS // 역행한 timestamp는 시작 시점으로 고정
+	}
+	return ts - r.startTS + r.tsOffset
+}
+
+// checkLimits marks the file for rotation when it exceeds the size or duration limit
+func (r *Recorder) checkLimits() {
+	size := r.fileSize + r.writer.Written()
+	if r.config.MaxSize > 0 && size >= r.config.MaxSize {
+		r.rotate = true
+	}
+	if r.config.MaxDuration > 0 && time.Duration(r.lastTS)*time.Millisecond >= r.config.MaxDuration {
+		r.rotate = true
+	}
+}
+
+// finalize flushes buffered tags and writes the final duration and filesize
+func (r *Recorder) finalize() error {
+	if err := r.bw.Flush(); err != nil {
+		return err
+	}
+
+	size := r.fileSize + r.writer.Written()
+	if err := writeMetaNumber(r.file, r.durationPos, float64(r.lastTS)/1000); err != nil {
+		return err
+	}
+	return writeMetaNumber(r.file, r.filesizePos, float64(size))
+}
+
+// scriptObject returns the properties of an onMetaData object or ECMA array
+func scriptObject(value any) *amf.Object {
+	switch v := value.(type) {
+	case *amf.Object:
+		return v
+	case *amf.ECMAArray:
+		return &v.Object
+	}
+	return nil
+}
+
+// metaNumberPos finds the number value of an AMF0 object property in script data
+// 반환값은 파일 내 8바이트 double 위치 (dataPos: script data 시작 위치), 없으면 -1
+func metaNumberPos(data []byte, name string, dataPos int64) int64 {
+	// UI16 길이 + 이름 + number marker(0x00)
+	pattern := make([]byte, 0, 2+len(name)+1)
+	pattern = binary.BigEndian.AppendUint16(pattern, uint16(len(name)))
+	pattern = append(pattern, name...)
+	pattern = append(pattern, 0x00)
+
+	i := bytes.Index(data, pattern)
+	if i < 0 || i+len(pattern)+8 > len(data) {
+		return -1
+	}
+	return dataPos + int64(i+len(pattern))
+}
+
+// writeMetaNumber overwrites a double value at pos (pos < 0이면 무시)
+func writeMetaNumber(file *os.File, pos int64, value float64) error {
+	if pos < 0 {
+		return nil
+	}
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], math.Float64bits(value))
+	_, err := file.WriteAt(b[:], pos)
+	return err
+}
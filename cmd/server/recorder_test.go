@@ -0,0 +1,356 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ssungk/ertmp/pkg/amf"
+	"github.com/ssungk/ertmp/pkg/flv"
+	"github.com/ssungk/ertmp/pkg/rtmp/buf"
+	"github.com/ssungk/ertmp/pkg/rtmp/transport"
+)
+
+var (
+	testVideoHeader = []byte{0x17, 0x00, 0x00, 0x00, 0x00, 0x01} // AVC sequence header
+	testKeyFrame    = []byte{0x17, 0x01, 0x00, 0x00, 0x00, 0xAA}
+	testInterFrame  = []byte{0x27, 0x01, 0x00, 0x00, 0x00, 0xBB}
+)
+
+func TestRecorder_Record(t *testing.T) {
+	dir := t.TempDir()
+	r := newTestRecorder(t, RecordConfig{Dir: dir}, "record")
+
+	writeMetadata(t, r, 1000, amf.Property{Key: "width", Value: 1280.0}, amf.Property{Key: "videocodecid", Value: 7.0})
+	writeVideo(t, r, 1000, testKeyFrame)
+	writeVideo(t, r, 1040, testInterFrame)
+	writeVideo(t, r, 1080, testInterFrame)
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	path := filepath.Join(dir, "live", "key.flv")
+	tags := readTags(t, path)
+	if len(tags) != 4 {
+		t.Fatalf("expected 4 tags, got %d", len(tags))
+	}
+
+	// onMetaData: publisher 순서 유지, duration/filesize 갱신
+	meta := readMetadata(t, tags[0])
+	if got := meta.Keys(); len(got) != 4 || got[0] != "width" || got[1] != "videocodecid" {
+		t.Errorf("unexpected metadata keys %v", got)
+	}
+	if duration, _ := meta.Get(metaDuration); duration != 0.08 {
+		t.Errorf("expected duration 0.08, got %v", duration)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filesize, _ := meta.Get(metaFilesize); filesize != float64(info.Size()) {
+		t.Errorf("expected filesize %d, got %v", info.Size(), filesize)
+	}
+
+	// timestamp는 첫 메시지 기준 0부터
+	for i, want := range []uint32{0, 40, 80} {
+		if got := tags[i+1].Timestamp; got != want {
+			t.Errorf("tag %d: expected timestamp %d, got %d", i+1, want, got)
+		}
+	}
+}
+
+func TestRecorder_Rotation(t *testing.T) {
+	tests := []struct {
+		name   string
+		config RecordConfig
+		files  []string
+	}{
+		{"duration", RecordConfig{MaxDuration: 100 * time.Millisecond}, []string{"key.flv", "key-1.flv", "key-2.flv"}},
+		{"size", RecordConfig{MaxSize: 150}, []string{"key.flv", "key-1.flv", "key-2.flv", "key-3.flv", "key-4.flv"}},
+		{"unlimited", RecordConfig{}, []string{"key.flv"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.Dir = t.TempDir()
+			r := newTestRecorder(t, tt.config, "record")
+
+			// keyframe 120ms 간격, 사이에 inter frame (회전은 keyframe에서만)
+			for ts := uint32(0); ts < 600; ts += 40 {
+				frame := testInterFrame
+				if ts%120 == 0 {
+					frame = testKeyFrame
+				}
+				writeVideo(t, r, ts, frame)
+			}
+			if err := r.Close(); err != nil {
+				t.Fatalf("Close failed: %v", err)
+			}
+
+			entries, err := os.ReadDir(filepath.Join(tt.config.Dir, "live"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != len(tt.files) {
+				t.Fatalf("expected files %v, got %d files", tt.files, len(entries))
+			}
+			for i, name := range tt.files {
+				tags := readTags(t, filepath.Join(tt.config.Dir, "live", name))
+				if i == 0 {
+					continue
+				}
+				// 회전 파일: onMetaData, sequence header, keyframe(timestamp 0) 순
+				if len(tags) < 3 || tags[0].Type != flv.TagTypeScript || string(tags[1].Data) != string(testVideoHeader) {
+					t.Fatalf("%s: rotation file does not start with metadata and sequence header", name)
+				}
+				if !tags[2].IsKeyFrame() || tags[2].Timestamp != 0 {
+					t.Errorf("%s: expected keyframe at 0, got keyframe=%v ts=%d", name, tags[2].IsKeyFrame(), tags[2].Timestamp)
+				}
+			}
+		})
+	}
+}
+
+func TestRecorder_Append(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous []uint32 // 이전 세션의 video timestamp
+		wantTS   []uint32 // 이어서 기록한 video timestamp (입력 0, 40)
+	}{
+		{"empty", nil, []uint32{0, 40}},
+		{"frame interval", []uint32{0, 40, 80}, []uint32{120, 160}},
+		{"single frame", []uint32{0}, []uint32{1, 41}},
+		{"long gap", []uint32{0, 5000}, []uint32{5001, 5041}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.previous != nil {
+				r := newTestRecorder(t, RecordConfig{Dir: dir}, "record")
+				for _, ts := range tt.previous {
+					writeVideo(t, r, ts, testKeyFrame)
+				}
+				if err := r.Close(); err != nil {
+					t.Fatalf("Close failed: %v", err)
+				}
+			}
+
+			r := newTestRecorder(t, RecordConfig{Dir: dir}, "append")
+			writeVideo(t, r, 3000, testKeyFrame) // 새 세션의 timestamp는 임의 값에서 시작
+			writeVideo(t, r, 3040, testInterFrame)
+			if err := r.Close(); err != nil {
+				t.Fatalf("Close failed: %v", err)
+			}
+
+			tags := readTags(t, filepath.Join(dir, "live", "key.flv"))
+			var got []uint32
+			for _, tag := range tags {
+				if tag.Type == flv.TagTypeVideo {
+					got = append(got, tag.Timestamp)
+				}
+			}
+			want := append(append([]uint32{}, tt.previous...), tt.wantTS...)
+			if len(got) != len(want) {
+				t.Fatalf("expected timestamps %v, got %v", want, got)
+			}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Fatalf("expected timestamps %v, got %v", want, got)
+				}
+			}
+			if tags[0].Type != flv.TagTypeScript {
+				t.Fatal("expected a single onMetaData at the start")
+			}
+			if duration, _ := readMetadata(t, tags[0]).Get(metaDuration); duration != float64(want[len(want)-1])/1000 {
+				t.Errorf("expected duration %v, got %v", float64(want[len(want)-1])/1000, duration)
+			}
+		})
+	}
+}
+
+func TestRecorder_AppendRotation(t *testing.T) {
+	dir := t.TempDir()
+	config := RecordConfig{Dir: dir, MaxDuration: 100 * time.Millisecond}
+
+	// 이전 세션: key.flv, key-1.flv
+	r := newTestRecorder(t, config, "record")
+	for _, ts := range []uint32{0, 120, 240} {
+		writeVideo(t, r, ts, testKeyFrame)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	base := filepath.Join(dir, "live", "key")
+	before := readTags(t, base+".flv")
+	beforeRotated := readTags(t, base+"-1.flv")
+
+	// append: 마지막 회전 파일(key-1.flv)에 이어서 기록하고, 다음 회전은 key-2.flv
+	r = newTestRecorder(t, config, "append")
+	if r.Path() != base+"-1.flv" {
+		t.Fatalf("expected append to resume at %s, got %s", base+"-1.flv", r.Path())
+	}
+	for _, ts := range []uint32{0, 120, 240} {
+		writeVideo(t, r, ts, testKeyFrame)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if got := readTags(t, base+".flv"); len(got) != len(before) {
+		t.Errorf("key.flv changed: %d tags, expected %d", len(got), len(before))
+	}
+	rotated := readTags(t, base+"-1.flv")
+	if len(rotated) != len(beforeRotated)+2 {
+		t.Errorf("key-1.flv: expected %d tags, got %d", len(beforeRotated)+2, len(rotated))
+	}
+	if last := rotated[len(rotated)-1]; last.Timestamp <= beforeRotated[len(beforeRotated)-1].Timestamp {
+		t.Errorf("appended timestamp %d does not follow %d", last.Timestamp, beforeRotated[len(beforeRotated)-1].Timestamp)
+	}
+	if _, err := os.Stat(base + "-2.flv"); err != nil {
+		t.Errorf("expected rotation to key-2.flv: %v", err)
+	}
+}
+
+func TestRecorder_RecordThenAppend(t *testing.T) {
+	dir := t.TempDir()
+	config := RecordConfig{Dir: dir, MaxDuration: 100 * time.Millisecond}
+
+	// 이전 녹화: key.flv, key-1.flv, key-2.flv
+	r := newTestRecorder(t, config, "record")
+	for _, ts := range []uint32{0, 120, 240, 360, 480} {
+		writeVideo(t, r, ts, testKeyFrame)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	base := filepath.Join(dir, "live", "key")
+	if _, err := os.Stat(base + "-2.flv"); err != nil {
+		t.Fatalf("expected rotation to key-2.flv: %v", err)
+	}
+
+	// 새 record는 이전 회전 파일을 삭제
+	r = newTestRecorder(t, config, "record")
+	writeVideo(t, r, 0, testKeyFrame)
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	for _, path := range []string{base + "-1.flv", base + "-2.flv"} {
+		if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("expected %s to be removed, got %v", path, err)
+		}
+	}
+
+	before := readTags(t, base+".flv")
+
+	// append는 새 녹화의 key.flv에 이어서 기록
+	r = newTestRecorder(t, config, "append")
+	if r.Path() != base+".flv" {
+		t.Fatalf("expected append to resume at %s, got %s", base+".flv", r.Path())
+	}
+	writeVideo(t, r, 0, testKeyFrame)
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if got := readTags(t, base+".flv"); len(got) != len(before)+1 {
+		t.Errorf("key.flv: expected %d tags, got %d", len(before)+1, len(got))
+	}
+}
+
+func TestRecordPath(t *testing.T) {
+	tests := []struct {
+		app, key string
+		wantErr  bool
+	}{
+		{"live", "key", false},
+		{"live", "sub/key", false},
+		{"live", "../key", false},
+		{"live", "../../key", true},
+		{"..", "key", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		_, err := recordPath("/records", tt.app, tt.key)
+		if gotErr := errors.Is(err, ErrInvalidRecordPath); gotErr != tt.wantErr {
+			t.Errorf("recordPath(%q, %q): expected error %v, got %v", tt.app, tt.key, tt.wantErr, err)
+		}
+	}
+}
+
+// newTestRecorder creates a recorder of live/key whose rotation headers are testVideoHeader
+func newTestRecorder(t *testing.T, config RecordConfig, mode string) *Recorder {
+	t.Helper()
+	r, err := NewRecorder(config, "live", "key", mode, func() (video, audio [][]byte) {
+		return [][]byte{testVideoHeader}, nil
+	})
+	if err != nil {
+		t.Fatalf("NewRecorder failed: %v", err)
+	}
+	return r
+}
+
+// writeVideo records a video message
+func writeVideo(t *testing.T, r *Recorder, ts uint32, data []byte) {
+	t.Helper()
+	msg := transport.NewMessage(transport.NewMessageHeader(1, ts, transport.MsgTypeVideo), buf.New(data))
+	if err := r.WriteMessage(msg); err != nil {
+		t.Fatalf("WriteMessage failed: %v", err)
+	}
+}
+
+// writeMetadata records an @setDataFrame onMetaData message
+func writeMetadata(t *testing.T, r *Recorder, ts uint32, props ...amf.Property) {
+	t.Helper()
+	data, err := amf.EncodeAMF0Sequence("@setDataFrame", "onMetaData", amf.NewECMAArray(props...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := transport.NewMessage(transport.NewMessageHeader(1, ts, transport.MsgTypeAMF0Data), buf.New(data))
+	if err := r.WriteMessage(msg); err != nil {
+		t.Fatalf("WriteMessage failed: %v", err)
+	}
+}
+
+// readTags reads all tags of an FLV file
+func readTags(t *testing.T, path string) []flv.Tag {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	reader := flv.NewReader(file)
+	if _, err := reader.ReadHeader(); err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+	var tags []flv.Tag
+	for {
+		tag, err := reader.ReadTag()
+		if err == io.EOF {
+			return tags
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		tags = append(tags, tag)
+	}
+}
+
+// readMetadata decodes the onMetaData properties of a script tag
+func readMetadata(t *testing.T, tag flv.Tag) *amf.Object {
+	t.Helper()
+	values, err := flv.DecodeScriptDataOrdered(tag.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 2 || values[0] != "onMetaData" {
+		t.Fatalf("expected onMetaData, got %v", values)
+	}
+	meta := scriptObject(values[1])
+	if meta == nil {
+		t.Fatalf("unexpected metadata value %T", values[1])
+	}
+	return meta
+}
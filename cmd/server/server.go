@@ -1,36 +1,91 @@
 package main
 
 import (
+	"bytes"
+	"crypto/tls"
 	"log/slog"
 	"net"
+	"net/http"
 	"os"
 	"sync"
+
+	"github.com/ssungk/ertmp/pkg/media"
+	"github.com/ssungk/ertmp/pkg/rtmp"
+	"github.com/ssungk/ertmp/pkg/rtmp/rtmpt"
+	"github.com/ssungk/ertmp/pkg/rtmp/transport"
 )
 
 // Server represents RTMP server
+// rtmp.Handler를 구현하여 publisher의 미디어를 subscriber에게 중계
 type Server struct {
-	addr    string
-	streams map[string]*Stream
-	mu      sync.RWMutex
+	addr      string
+	tlsAddr   string
+	tlsConfig *tls.Config
+	rtmptAddr string
+	record    RecordConfig
+	gop       *GOPConfig // nil이면 GOP 캐시 비활성화
+	queueSize int        // 구독자별 송신 큐 크기 (0이면 기본값)
+	rtmp      *rtmp.Server
+	streams   map[string]*Stream
+	playbacks map[*rtmp.Stream]*Playback // 녹화 파일 재생 중인 play 스트림
+	mu        sync.RWMutex
 }
 
 // Stream represents a publish/play stream
 type Stream struct {
-	key            string
-	publisher      *Session
-	subscribers    map[*Session]bool
-	metadata       []byte
-	videoSeqHeader []byte
-	audioSeqHeader []byte
-	mu             sync.RWMutex
+	key          string
+	publisher    *rtmp.Stream
+	subscribers  map[*rtmp.Stream]*Subscriber
+	metadata     []byte
+	videoHeaders headerCache // 트랙별 video sequence header
+	audioHeaders headerCache // 트랙별 audio sequence header, multichannel config
+	gop          *gopCache   // 마지막 keyframe 이후 미디어 (nil이면 비활성화)
+	recorder     *Recorder   // publish type이 record/append일 때
+	mu           sync.RWMutex
+}
+
+// Subscriber is a playing stream with its selected tracks
+type Subscriber struct {
+	stream      *rtmp.Stream
+	videoTracks media.TrackSet // nil이면 모든 트랙
+	audioTracks media.TrackSet
+	queue       *sendQueue // 구독자 고루틴이 비우는 송신 큐
+	started     bool       // 초기 데이터 전송 후 live 미디어 수신 (Stream.mu로 보호)
+}
+
+// Dropped returns the number of video and audio messages dropped for a slow subscriber
+func (sub *Subscriber) Dropped() (video, audio uint64) {
+	return sub.queue.dropped()
+}
+
+// playStart is the initial data sent to a subscriber before live media
+type playStart struct {
+	metadata []byte
+	video    [][]byte
+	audio    [][]byte
+	gop      []transport.Message // Retain된 버퍼, 전송 후 해제 필요
 }
 
 // NewServer creates a new RTMP server
 func NewServer() *Server {
-	return &Server{
-		addr:    ":1935",
-		streams: make(map[string]*Stream),
+	s := &Server{
+		addr:      ":1935",
+		streams:   make(map[string]*Stream),
+		playbacks: make(map[*rtmp.Stream]*Playback),
+	}
+	s.rtmp = rtmp.NewServer(s)
+	return s
+}
+
+// EnableTLS enables the RTMPS listener with a certificate/key pair
+func (s *Server) EnableTLS(addr, certFile, keyFile string) error {
+	config, err := rtmp.LoadTLSConfig(certFile, keyFile)
+	if err != nil {
+		return err
 	}
+	s.tlsAddr = addr
+	s.tlsConfig = config
+	return nil
 }
 
 // Run starts the RTMP server and blocks forever
@@ -43,15 +98,40 @@ func (s *Server) Run() {
 
 	slog.Info("RTMP server started", "addr", s.addr)
 
-	for {
-		netConn, err := listener.Accept()
+	// RTMPS 리스너 (설정된 경우)
+	if s.tlsConfig != nil {
+		tlsListener, err := rtmp.ListenTLS(s.tlsAddr, s.tlsConfig)
 		if err != nil {
-			slog.Error("Accept failed", "error", err)
-			continue
+			slog.Error("Failed to start RTMPS server", "error", err, "addr", s.tlsAddr)
+			os.Exit(1)
 		}
 
-		session := NewSession(netConn, s)
-		go session.Run()
+		slog.Info("RTMPS server started", "addr", s.tlsAddr)
+		go s.serve(tlsListener)
+	}
+
+	// RTMPT 리스너 (설정된 경우)
+	if s.rtmptAddr != "" {
+		tunnel := rtmpt.NewServer()
+		go func() {
+			if err := http.ListenAndServe(s.rtmptAddr, tunnel); err != nil {
+				slog.Error("Failed to start RTMPT server", "error", err, "addr", s.rtmptAddr)
+				os.Exit(1)
+			}
+		}()
+
+		slog.Info("RTMPT server started", "addr", s.rtmptAddr)
+		go s.serve(tunnel)
+	}
+
+	s.serve(listener)
+}
+
+// serve accepts connections from listener until it fails
+func (s *Server) serve(listener net.Listener) {
+	if err := s.rtmp.Serve(listener); err != nil {
+		slog.Error("Serve failed", "error", err, "addr", listener.Addr())
+		os.Exit(1)
 	}
 }
 
@@ -63,8 +143,13 @@ func (s *Server) GetOrCreateStream(key string) *Stream {
 	stream, ok := s.streams[key]
 	if !ok {
 		stream = &Stream{
-			key:         key,
-			subscribers: make(map[*Session]bool),
+			key:          key,
+			subscribers:  make(map[*rtmp.Stream]*Subscriber),
+			videoHeaders: make(headerCache),
+			audioHeaders: make(headerCache),
+		}
+		if s.gop != nil {
+			stream.gop = newGOPCache(*s.gop)
 		}
 		s.streams[key] = stream
 	}
@@ -92,111 +177,189 @@ func (s *Server) RemoveStream(key string) {
 	}
 }
 
+// AddPlayback registers the playback of a play stream
+func (s *Server) AddPlayback(stream *rtmp.Stream, playback *Playback) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.playbacks[stream] = playback
+}
+
+// GetPlayback returns the playback of a play stream (nil이면 live 재생)
+func (s *Server) GetPlayback(stream *rtmp.Stream) *Playback {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.playbacks[stream]
+}
+
+// RemovePlayback removes and returns the playback of a play stream
+func (s *Server) RemovePlayback(stream *rtmp.Stream) *Playback {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	playback := s.playbacks[stream]
+	delete(s.playbacks, stream)
+	return playback
+}
+
+// RecordedFile returns the record file of app/key if it exists
+func (s *Server) RecordedFile(app, key string) (string, bool) {
+	if !s.record.Enabled() {
+		return "", false
+	}
+	path, err := recordPath(s.record.Dir, app, key)
+	if err != nil {
+		return "", false
+	}
+	path += ".flv"
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return "", false
+	}
+	return path, true
+}
+
 // SetPublisher sets the publisher for a stream
-func (st *Stream) SetPublisher(session *Session) {
+// 이미 publisher가 있으면 false 반환
+func (st *Stream) SetPublisher(publisher *rtmp.Stream) bool {
 	st.mu.Lock()
 	defer st.mu.Unlock()
-	st.publisher = session
+	if st.publisher != nil {
+		return false
+	}
+	st.publisher = publisher
+	return true
 }
 
-// RemovePublisher removes the publisher from a stream
-func (st *Stream) RemovePublisher() {
+// RemovePublisher removes the publisher from a stream if it is the current one
+func (st *Stream) RemovePublisher(publisher *rtmp.Stream) {
 	st.mu.Lock()
 	defer st.mu.Unlock()
-	st.publisher = nil
+	if st.publisher == publisher {
+		st.publisher = nil
+		st.gop.clear()
+	}
+}
+
+// SetRecorder sets the recorder of the current publisher (nil이면 해제)
+func (st *Stream) SetRecorder(recorder *Recorder) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.recorder = recorder
+}
+
+// GetRecorder returns the recorder of the current publisher
+func (st *Stream) GetRecorder() *Recorder {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return st.recorder
 }
 
 // GetPublisher gets the publisher of a stream
-func (st *Stream) GetPublisher() *Session {
+func (st *Stream) GetPublisher() *rtmp.Stream {
 	st.mu.RLock()
 	defer st.mu.RUnlock()
 	return st.publisher
 }
 
 // AddSubscriber adds a subscriber to the stream
-func (st *Stream) AddSubscriber(session *Session) {
+func (st *Stream) AddSubscriber(subscriber *Subscriber) {
 	st.mu.Lock()
 	defer st.mu.Unlock()
-	st.subscribers[session] = true
+	st.subscribers[subscriber.stream] = subscriber
 	slog.Info("Subscriber added", "streamKey", st.key, "total", len(st.subscribers))
 }
 
-// RemoveSubscriber removes a subscriber from the stream
-func (st *Stream) RemoveSubscriber(session *Session) {
+// RemoveSubscriber removes a subscriber from the stream and stops its queue
+func (st *Stream) RemoveSubscriber(subscriber *rtmp.Stream) {
 	st.mu.Lock()
 	defer st.mu.Unlock()
-	delete(st.subscribers, session)
-	slog.Info("Subscriber removed", "streamKey", st.key, "total", len(st.subscribers))
+
+	sub, ok := st.subscribers[subscriber]
+	if !ok {
+		return
+	}
+	delete(st.subscribers, subscriber)
+	sub.queue.close()
+
+	droppedVideo, droppedAudio := sub.Dropped()
+	slog.Info("Subscriber removed",
+		"streamKey", st.key,
+		"total", len(st.subscribers),
+		"droppedVideo", droppedVideo,
+		"droppedAudio", droppedAudio)
 }
 
-// GetSubscribers returns a copy of subscribers
-func (st *Stream) GetSubscribers() []*Session {
+// GetSubscribers returns a copy of the started subscribers
+func (st *Stream) GetSubscribers() []*Subscriber {
 	st.mu.RLock()
 	defer st.mu.RUnlock()
 
-	subscribers := make([]*Session, 0, len(st.subscribers))
-	for sub := range st.subscribers {
-		subscribers = append(subscribers, sub)
+	subscribers := make([]*Subscriber, 0, len(st.subscribers))
+	for _, sub := range st.subscribers {
+		if sub.started {
+			subscribers = append(subscribers, sub)
+		}
 	}
 	return subscribers
 }
 
-// SetMetadata sets the metadata for the stream
-func (st *Stream) SetMetadata(data []byte) {
+// StartSubscriber queues the initial data of a subscriber, starts its writer goroutine
+// and its live media. Returns the queued initial data (nil if not subscribed)
+// 잠금 안에서 초기 데이터를 큐에 넣어 GOP와 live 미디어 사이에 누락이나 역전이 없도록 함
+func (st *Stream) StartSubscriber(stream *rtmp.Stream) *playStart {
 	st.mu.Lock()
 	defer st.mu.Unlock()
-	st.metadata = make([]byte, len(data))
-	copy(st.metadata, data)
-}
 
-// GetMetadata returns a copy of the metadata
-func (st *Stream) GetMetadata() []byte {
-	st.mu.RLock()
-	defer st.mu.RUnlock()
-	if st.metadata == nil {
+	sub := st.subscribers[stream]
+	if sub == nil || sub.started {
 		return nil
 	}
-	data := make([]byte, len(st.metadata))
-	copy(data, st.metadata)
-	return data
+
+	init := &playStart{
+		metadata: bytes.Clone(st.metadata),
+		video:    st.videoHeaders.messages(sub.videoTracks),
+		audio:    st.audioHeaders.messages(sub.audioTracks),
+		gop:      st.gop.snapshot(),
+	}
+	sendInit(sub, init)
+
+	sub.started = true
+	go sub.queue.run()
+	return init
 }
 
-// SetVideoSeqHeader sets the video sequence header
-func (st *Stream) SetVideoSeqHeader(data []byte) {
+// CacheGOP adds a media message to the GOP cache
+func (st *Stream) CacheGOP(msg transport.Message, keyFrame bool) {
 	st.mu.Lock()
 	defer st.mu.Unlock()
-	st.videoSeqHeader = make([]byte, len(data))
-	copy(st.videoSeqHeader, data)
+	st.gop.add(msg, keyFrame)
 }
 
-// GetVideoSeqHeader returns a copy of the video sequence header
-func (st *Stream) GetVideoSeqHeader() []byte {
-	st.mu.RLock()
-	defer st.mu.RUnlock()
-	if st.videoSeqHeader == nil {
-		return nil
-	}
-	data := make([]byte, len(st.videoSeqHeader))
-	copy(data, st.videoSeqHeader)
-	return data
+// SetMetadata sets the metadata for the stream
+func (st *Stream) SetMetadata(data []byte) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.metadata = make([]byte, len(data))
+	copy(st.metadata, data)
 }
 
-// SetAudioSeqHeader sets the audio sequence header
-func (st *Stream) SetAudioSeqHeader(data []byte) {
+// UpdateHeaders applies the sequence header/end packets of a media message to the cache
+func (st *Stream) UpdateHeaders(msgType uint8, packets []trackPacket) {
 	st.mu.Lock()
 	defer st.mu.Unlock()
-	st.audioSeqHeader = make([]byte, len(data))
-	copy(st.audioSeqHeader, data)
+
+	cache := st.audioHeaders
+	if msgType == transport.MsgTypeVideo {
+		cache = st.videoHeaders
+	}
+	for _, p := range packets {
+		cache.update(p)
+	}
 }
 
-// GetAudioSeqHeader returns a copy of the audio sequence header
-func (st *Stream) GetAudioSeqHeader() []byte {
+// GetHeaders returns the cached video and audio headers of the selected tracks
+// 캐시 항목은 교체만 되고 수정되지 않으므로 복사 없이 반환
+func (st *Stream) GetHeaders(videoTracks, audioTracks media.TrackSet) (video, audio [][]byte) {
 	st.mu.RLock()
 	defer st.mu.RUnlock()
-	if st.audioSeqHeader == nil {
-		return nil
-	}
-	data := make([]byte, len(st.audioSeqHeader))
-	copy(data, st.audioSeqHeader)
-	return data
+	return st.videoHeaders.messages(videoTracks), st.audioHeaders.messages(audioTracks)
 }
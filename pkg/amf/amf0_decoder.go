@@ -9,11 +9,92 @@ import (
 	"time"
 )
 
+// Decoder reads AMF0 values from a stream.
+// object, typed object, ECMA array, strict array는 참조 테이블에 등록되어
+// 참조(0x07)로 다시 가리킬 수 있음. 메시지마다 Reset으로 테이블을 비워야 함
+type Decoder struct {
+	src     io.Reader    // 원래 reader
+	r       io.Reader    // MaxBytes가 있으면 limitReader로 감싼 src
+	refs    []any        // AMF0 참조 테이블
+	amf3    *AMF3Context // AVM+ 값용 (nil이면 값마다 새 context)
+	compat  bool         // undefined를 nil로 반환 (함수형 API 호환)
+	ordered bool         // object/ECMA array를 *Object/*ECMAArray로 반환
+	limits  Limits
+	depth   int   // 현재 중첩 깊이
+	read    int64 // Reset 이후 읽은 바이트 수 (MaxBytes가 있을 때만)
+	scratch [8]byte
+}
+
+// NewDecoder creates a decoder that reads from r with DefaultLimits.
+// AVM+ 마커 뒤의 AMF3 값들은 Decoder의 AMF3 참조 테이블을 공유함
+func NewDecoder(r io.Reader) *Decoder {
+	d := &Decoder{amf3: NewAMF3Context(), limits: DefaultLimits}
+	d.setReader(r)
+	return d
+}
+
+// newCompatDecoder creates the decoder of the package-level functions
+func newCompatDecoder(r io.Reader, ctx *AMF3Context) *Decoder {
+	d := &Decoder{amf3: ctx, compat: true, limits: DefaultLimits}
+	d.setReader(r)
+	return d
+}
+
+// Reset clears the reference tables and makes the decoder read from r.
+func (d *Decoder) Reset(r io.Reader) {
+	clear(d.refs)
+	d.refs = d.refs[:0]
+	d.depth = 0
+	if d.amf3 != nil {
+		d.amf3.Reset()
+	}
+	d.setReader(r)
+}
+
+// SetLimits sets the limits of the decoder and its AMF3 context.
+// MaxBytes는 이 시점부터 다시 계산됨
+func (d *Decoder) SetLimits(limits Limits) {
+	d.limits = limits
+	if d.amf3 != nil {
+		d.amf3.SetLimits(limits)
+	}
+	d.setReader(d.src)
+}
+
+// setReader sets the source reader, wrapping it when MaxBytes is set
+func (d *Decoder) setReader(r io.Reader) {
+	d.src = r
+	d.r = r
+	d.read = 0
+	if d.limits.MaxBytes > 0 {
+		d.r = &limitReader{r: r, n: &d.read, max: d.limits.MaxBytes}
+	}
+}
+
+// UseOrderedObjects makes the decoder return objects as *Object and ECMA arrays as *ECMAArray,
+// keeping property order. 기본값은 둘 다 map[string]any
+func (d *Decoder) UseOrderedObjects() {
+	d.ordered = true
+}
+
+// Decode reads a single value; returns io.EOF when the stream ends between values.
+// undefined는 Undefined{}, typed object는 *TypedObject, XML document는 XMLDocument로 반환
+func (d *Decoder) Decode() (any, error) {
+	return d.decodeValue()
+}
+
 func DecodeAMF0Sequence(r io.Reader) ([]any, error) {
+	return DecodeAMF0SequenceContext(r, nil)
+}
+
+// DecodeAMF0SequenceContext decodes AMF0 values like DecodeAMF0Sequence.
+// AVM+ 마커(0x11) 뒤의 AMF3 값은 ctx의 참조 테이블로 디코딩 (nil이면 값마다 새 context)
+func DecodeAMF0SequenceContext(r io.Reader, ctx *AMF3Context) ([]any, error) {
+	d := newCompatDecoder(r, ctx)
 	values := make([]any, 0, 5)
 
 	for {
-		val, err := DecodeAMF0(r)
+		val, err := d.decodeValue()
 		switch {
 		case err == nil:
 			values = append(values, val)
@@ -26,136 +107,268 @@ func DecodeAMF0Sequence(r io.Reader) ([]any, error) {
 }
 
 func DecodeAMF0(r io.Reader) (any, error) {
-	marker := make([]byte, 1)
-	if _, err := io.ReadFull(r, marker); err != nil {
+	return newCompatDecoder(r, nil).decodeValue()
+}
+
+// decodeValue decodes a value, switching to AMF3 on the AVM+ marker.
+// 마커 앞에서 끝나면 io.EOF, 값(하위 값 포함) 중간에서 끝나면 io.ErrUnexpectedEOF
+func (d *Decoder) decodeValue() (any, error) {
+	marker, err := d.readByte()
+	if err != nil {
 		return nil, err
 	}
+	val, err := d.decodeMarker(marker)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF // 값 중간에서 끝남
+	}
+	return val, err
+}
 
-	switch marker[0] {
+// decodeMarker decodes the value following marker
+func (d *Decoder) decodeMarker(marker byte) (any, error) {
+	switch marker {
 	case numberMarker:
-		return decodeNumber(r)
+		return d.decodeNumber()
 	case booleanMarker:
-		return decodeBoolean(r)
+		return d.decodeBoolean()
 	case stringMarker:
-		return decodeString(r)
+		return d.decodeString()
+	case objectMarker, ecmaArrayMarker, strictArrayMarker, typedObjectMarker:
+		return d.decodeComplex(marker)
+	case nullMarker:
+		return nil, nil
+	case undefinedMarker:
+		if d.compat {
+			return nil, nil
+		}
+		return Undefined{}, nil
+	case referenceMarker:
+		return d.decodeReference()
+	case dateMarker:
+		return d.decodeDate()
+	case longStringMarker:
+		return d.decodeLongString()
+	case xmlDocumentMarker:
+		s, err := d.decodeLongString()
+		return XMLDocument(s), err
+	case avmPlusMarker:
+		return d.decodeAVMPlus()
+	default:
+		return nil, fmt.Errorf("unsupported AMF0 marker: 0x%x", marker)
+	}
+}
+
+// decodeComplex decodes an object, ECMA array, strict array or typed object within MaxDepth
+func (d *Decoder) decodeComplex(marker byte) (any, error) {
+	d.depth++
+	defer func() { d.depth-- }()
+	if err := d.limits.checkDepth(d.depth); err != nil {
+		return nil, err
+	}
+
+	switch marker {
 	case objectMarker:
-		return decodeObject(r)
-	case nullMarker, undefinedMarker:
-		return decodeNull(r)
+		if d.ordered {
+			return d.decodeOrderedObject()
+		}
+		return d.decodeObject()
 	case ecmaArrayMarker:
-		return decodeECMAArray(r)
+		return d.decodeECMAArray()
 	case strictArrayMarker:
-		return decodeStrictArray(r)
-	case dateMarker:
-		return decodeDate(r)
-	case longStringMarker:
-		return decodeLongString(r)
+		return d.decodeStrictArray()
 	default:
-		return nil, fmt.Errorf("unsupported AMF0 marker: 0x%x", marker[0])
+		return d.decodeTypedObject()
 	}
 }
 
-func decodeNumber(r io.Reader) (float64, error) {
-	var num float64
-	err := binary.Read(r, binary.BigEndian, &num)
-	return num, err
+// decodeAVMPlus decodes an AMF3 value; 중첩 깊이는 AMF3 context로 이어짐
+func (d *Decoder) decodeAVMPlus() (any, error) {
+	ctx := d.amf3
+	if ctx == nil {
+		ctx = NewAMF3Context()
+	}
+	saved := ctx.depth
+	ctx.depth = d.depth
+	defer func() { ctx.depth = saved }()
+	return ctx.DecodeAMF3(d.r)
 }
 
-func decodeBoolean(r io.Reader) (bool, error) {
-	b := make([]byte, 1)
-	if _, err := io.ReadFull(r, b); err != nil {
-		return false, err
+func (d *Decoder) decodeNumber() (float64, error) {
+	if _, err := io.ReadFull(d.r, d.scratch[:8]); err != nil {
+		return 0, err
 	}
-	return b[0] != 0, nil
+	return math.Float64frombits(binary.BigEndian.Uint64(d.scratch[:8])), nil
 }
 
-func decodeString(r io.Reader) (string, error) {
-	var length uint16
-	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+func (d *Decoder) decodeBoolean() (bool, error) {
+	b, err := d.readByte()
+	return b != 0, err
+}
+
+func (d *Decoder) decodeString() (string, error) {
+	length, err := d.readUint16()
+	if err != nil {
 		return "", err
 	}
-	buf := make([]byte, length)
-	if _, err := io.ReadFull(r, buf); err != nil {
+	return d.readString(int(length))
+}
+
+func (d *Decoder) decodeLongString() (string, error) {
+	length, err := d.readUint32()
+	if err != nil {
 		return "", err
 	}
-	return string(buf), nil
+	return d.readString(int(length))
 }
 
-func decodeLongString(r io.Reader) (string, error) {
-	var length uint32
-	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+// readString reads a UTF-8 payload of length bytes within MaxStringLength
+func (d *Decoder) readString(length int) (string, error) {
+	if err := d.limits.checkString(length); err != nil {
 		return "", err
 	}
-	buf := make([]byte, length)
-	if _, err := io.ReadFull(r, buf); err != nil {
+	buf, err := readBytes(d.r, length)
+	if err != nil {
 		return "", err
 	}
 	return string(buf), nil
 }
 
-func decodeNull(_ io.Reader) (any, error) {
-	return nil, nil
+// decodeReference resolves a reference to a previously decoded complex value
+func (d *Decoder) decodeReference() (any, error) {
+	idx, err := d.readUint16()
+	if err != nil {
+		return nil, err
+	}
+	if int(idx) >= len(d.refs) {
+		return nil, fmt.Errorf("reference out of bounds: %d", idx)
+	}
+	return d.refs[idx], nil
 }
 
-func decodeECMAArray(r io.Reader) (map[string]any, error) {
-	var length uint32
-	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+func (d *Decoder) decodeECMAArray() (any, error) {
+	// associative count는 신뢰할 수 없으므로 end marker까지 읽음
+	// (ordered면 같은 바이트로 다시 인코딩할 수 있도록 Count에 보관)
+	count, err := d.readUint32()
+	if err != nil {
+		return nil, err
+	}
+	if !d.ordered {
+		return d.decodeObject()
+	}
+
+	arr := &ECMAArray{Count: count}
+	d.refs = append(d.refs, arr)
+	if err := d.decodeProperties(&arr.Object); err != nil {
 		return nil, err
 	}
-	return decodeObject(r)
+	return arr, nil
 }
 
-func decodeObject(r io.Reader) (map[string]any, error) {
+func (d *Decoder) decodeObject() (map[string]any, error) {
 	obj := make(map[string]any)
-	end := make([]byte, 1)
+	d.refs = append(d.refs, obj) // 멤버보다 먼저 등록해야 순환 참조가 가능
+	if err := d.decodeMembers(obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
 
-	for {
-		key, err := decodeString(r)
+// decodeOrderedObject decodes an anonymous object keeping the property order
+func (d *Decoder) decodeOrderedObject() (*Object, error) {
+	obj := &Object{}
+	d.refs = append(d.refs, obj)
+	if err := d.decodeProperties(obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// decodeTypedObject decodes the class name and members of a typed object
+func (d *Decoder) decodeTypedObject() (*TypedObject, error) {
+	className, err := d.decodeString()
+	if err != nil {
+		return nil, err
+	}
+	obj := NewTypedObject(className)
+	d.refs = append(d.refs, obj)
+	if err := d.decodeMembers(obj.Members); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// decodeMembers reads properties into obj until the object end marker
+func (d *Decoder) decodeMembers(obj map[string]any) error {
+	return d.decodeEach(func(key string, val any) {
+		obj[key] = val
+	})
+}
+
+// decodeProperties appends properties to obj in wire order (중복 키도 유지)
+func (d *Decoder) decodeProperties(obj *Object) error {
+	return d.decodeEach(func(key string, val any) {
+		obj.Properties = append(obj.Properties, Property{Key: key, Value: val})
+	})
+}
+
+// decodeEach reads key/value pairs until the object end marker (MaxCollectionLength개까지)
+func (d *Decoder) decodeEach(set func(key string, val any)) error {
+	for n := 1; ; n++ {
+		key, err := d.decodeString()
 		if err != nil {
-			return nil, err
+			return err
 		}
 		if len(key) == 0 {
-			if _, err := io.ReadFull(r, end); err != nil {
-				return nil, err
+			end, err := d.readByte()
+			if err != nil {
+				return err
 			}
-			if end[0] == objectEndMarker {
-				break
+			if end == objectEndMarker {
+				return nil
 			}
-			return nil, errors.New("expected object end marker")
+			return errors.New("expected object end marker")
+		}
+		if err := d.limits.checkCollection(n); err != nil {
+			return err
 		}
-		val, err := DecodeAMF0(r)
+		val, err := d.decodeValue()
 		if err != nil {
-			return nil, err
+			return err
 		}
-		obj[key] = val
+		set(key, val)
 	}
-	return obj, nil
 }
 
-func decodeStrictArray(r io.Reader) ([]any, error) {
-	var count uint32
-	if err := binary.Read(r, binary.BigEndian, &count); err != nil {
+func (d *Decoder) decodeStrictArray() ([]any, error) {
+	count, err := d.readUint32()
+	if err != nil {
 		return nil, err
 	}
-	arr := make([]any, count)
-	for i := uint32(0); i < count; i++ {
-		v, err := DecodeAMF0(r)
+	if err := d.limits.checkCollection(int(count)); err != nil {
+		return nil, err
+	}
+	arr := newItems[any](int(count))
+	idx := len(d.refs)
+	d.refs = append(d.refs, arr)
+	for i := range int(count) {
+		v, err := d.decodeValue()
 		if err != nil {
 			return nil, err
 		}
-		arr[i] = v
+		arr = setItem(arr, i, v)
 	}
+	d.refs[idx] = arr // maxPrealloc보다 큰 배열은 읽으면서 늘어난 slice로 교체
 	return arr, nil
 }
 
-func decodeDate(r io.Reader) (time.Time, error) {
-	var millis float64
-	if err := binary.Read(r, binary.BigEndian, &millis); err != nil {
+func (d *Decoder) decodeDate() (time.Time, error) {
+	millis, err := d.decodeNumber()
+	if err != nil {
 		return time.Time{}, err
 	}
 
-	offset := make([]byte, 2)
-	if _, err := io.ReadFull(r, offset); err != nil {
+	// timezone offset은 사용하지 않음
+	if _, err := d.readUint16(); err != nil {
 		return time.Time{}, err
 	}
 
@@ -165,3 +378,24 @@ func decodeDate(r io.Reader) (time.Time, error) {
 
 	return time.Unix(sec, nanoSec).UTC(), nil
 }
+
+func (d *Decoder) readByte() (byte, error) {
+	if _, err := io.ReadFull(d.r, d.scratch[:1]); err != nil {
+		return 0, err
+	}
+	return d.scratch[0], nil
+}
+
+func (d *Decoder) readUint16() (uint16, error) {
+	if _, err := io.ReadFull(d.r, d.scratch[:2]); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint16(d.scratch[:2]), nil
+}
+
+func (d *Decoder) readUint32() (uint32, error) {
+	if _, err := io.ReadFull(d.r, d.scratch[:4]); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(d.scratch[:4]), nil
+}
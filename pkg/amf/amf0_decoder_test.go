@@ -2,6 +2,8 @@ package amf
 
 import (
 	"bytes"
+	"errors"
+	"io"
 	"testing"
 	"time"
 )
@@ -42,6 +44,36 @@ func TestDecodeAMF0Sequence_MalformedData(t *testing.T) {
 	}
 }
 
+func TestDecodeAMF0Sequence_Truncated(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"number marker only", []byte{0x00}},
+		{"string", []byte{0x02, 0x00, 0x03, 'a'}},
+		{"strict array", []byte{0x0A, 0x00, 0x00, 0x00, 0x02}},
+		{"nested strict array", []byte{0x0A, 0x00, 0x00, 0x00, 0x01, 0x0A, 0x00, 0x00, 0x00, 0x02, 0x05}},
+		{"object without end", []byte{0x03, 0x00, 0x01, 'a', 0x05}},
+		{"ECMA array", []byte{0x08, 0x00, 0x00, 0x00, 0x01, 0x00, 0x01, 'a'}},
+		{"after complete value", []byte{0x01, 0x01, 0x0A, 0x00, 0x00, 0x00, 0x01}},
+		{"AVM+ array", []byte{0x11, 0x09, 0x05, 0x01, 0x01}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := DecodeAMF0Sequence(bytes.NewReader(tt.data))
+			if !errors.Is(err, io.ErrUnexpectedEOF) {
+				t.Errorf("expected io.ErrUnexpectedEOF, got %v (values %v)", err, values)
+			}
+		})
+	}
+
+	// 값 사이에서 끝나면 정상 종료
+	values, err := DecodeAMF0Sequence(bytes.NewReader([]byte{0x01, 0x01, 0x05}))
+	if err != nil || len(values) != 2 {
+		t.Errorf("expected 2 values, got %v, %v", values, err)
+	}
+}
+
 func TestDecodeAMF0_InvalidInput_EmptyReader(t *testing.T) {
 	_, err := DecodeAMF0(bytes.NewReader(nil))
 	if err == nil {
@@ -334,3 +366,177 @@ func TestDecodeAMF0_LongString_MalformedShortData(t *testing.T) {
 		t.Fatal("expected error for incomplete long string data")
 	}
 }
+
+func TestDecodeAMF0Sequence_AVMPlus(t *testing.T) {
+	data := []byte{
+		0x02, 0x00, 0x04, 'c', 'a', 'l', 'l', // AMF0 string
+		0x11, 0x06, 0x07, 'f', 'o', 'o', // AVM+ AMF3 string "foo"
+		0x11, 0x06, 0x00, // AVM+ AMF3 string reference 0
+		0x11, 0x04, 0x7F, // AVM+ AMF3 integer 127
+	}
+
+	// 같은 context를 쓰면 값 사이의 참조가 유지됨
+	values, err := DecodeAMF0SequenceContext(bytes.NewReader(data), NewAMF3Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []any{"call", "foo", "foo", int32(127)}
+	if len(values) != len(want) {
+		t.Fatalf("got %d values, want %d", len(values), len(want))
+	}
+	for i := range want {
+		if values[i] != want[i] {
+			t.Errorf("value %d: got %v (%T), want %v", i, values[i], values[i], want[i])
+		}
+	}
+
+	// context 없이 디코딩하면 값마다 새 context이므로 참조가 깨짐
+	if _, err := DecodeAMF0Sequence(bytes.NewReader(data)); err == nil {
+		t.Error("expected reference error without shared context")
+	}
+}
+
+func TestDecodeAMF0_AVMPlusInObject(t *testing.T) {
+	data := []byte{0x03,
+		0x00, 0x01, 'a', 0x11, 0x06, 0x05, 'h', 'i',
+		0x00, 0x00, 0x09}
+	val, err := DecodeAMF0(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj, ok := val.(map[string]any)
+	if !ok || obj["a"] != "hi" {
+		t.Errorf("got %v, want map[a:hi]", val)
+	}
+}
+
+func TestDecoder_Reference(t *testing.T) {
+	data := []byte{
+		// object #0
+		0x03,
+		0x00, 0x04, 's', 'e', 'l', 'f', 0x07, 0x00, 0x00, // 자기 자신 참조
+		0x00, 0x04, 'l', 'i', 's', 't', 0x0A, 0x00, 0x00, 0x00, 0x01, // strict array #1
+		0x03, 0x00, 0x00, 0x09, // object #2
+		0x00, 0x00, 0x09,
+		0x07, 0x00, 0x02, // object #2 참조
+	}
+
+	d := NewDecoder(bytes.NewReader(data))
+	val, err := d.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj := val.(map[string]any)
+	self, ok := obj["self"].(map[string]any)
+	if !ok || len(self) != 2 {
+		t.Fatalf("self = %v", obj["self"])
+	}
+
+	ref, err := d.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	inner := obj["list"].([]any)[0].(map[string]any)
+	inner["x"] = 1.0
+	if ref.(map[string]any)["x"] != 1.0 {
+		t.Error("reference does not point to the same object")
+	}
+
+	if _, err := d.Decode(); err != io.EOF {
+		t.Errorf("got %v, want io.EOF", err)
+	}
+}
+
+func TestDecoder_ReferenceOutOfBounds(t *testing.T) {
+	d := NewDecoder(bytes.NewReader([]byte{0x07, 0x00, 0x00}))
+	if _, err := d.Decode(); err == nil {
+		t.Error("expected error for reference out of bounds")
+	}
+}
+
+func TestDecoder_TypedObject(t *testing.T) {
+	data := []byte{0x10,
+		0x00, 0x05, 'V', 'i', 'd', 'e', 'o',
+		0x00, 0x05, 'c', 'o', 'd', 'e', 'c', 0x02, 0x00, 0x03, 'a', 'v', 'c',
+		0x00, 0x00, 0x09}
+
+	val, err := NewDecoder(bytes.NewReader(data)).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj, ok := val.(*TypedObject)
+	if !ok {
+		t.Fatalf("got %T, want *TypedObject", val)
+	}
+	if obj.ClassName != "Video" || obj.Members["codec"] != "avc" {
+		t.Errorf("got %+v", obj)
+	}
+}
+
+func TestDecoder_UndefinedAndXML(t *testing.T) {
+	data := []byte{0x05, 0x06, 0x0F, 0x00, 0x00, 0x00, 0x04, '<', 'a', '/', '>'}
+
+	d := NewDecoder(bytes.NewReader(data))
+	want := []any{nil, Undefined{}, XMLDocument("<a/>")}
+	for i, w := range want {
+		val, err := d.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if val != w {
+			t.Errorf("value %d: got %v (%T), want %v (%T)", i, val, val, w, w)
+		}
+	}
+
+	// 함수형 API는 undefined를 nil로 반환
+	values, err := DecodeAMF0Sequence(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values[1] != nil || values[2] != XMLDocument("<a/>") {
+		t.Errorf("got %v", values)
+	}
+}
+
+func TestDecoder_AVMPlus(t *testing.T) {
+	data := []byte{
+		0x11, 0x06, 0x07, 'f', 'o', 'o', // AMF3 string "foo"
+		0x02, 0x00, 0x01, 'x', // AMF0 string
+		0x11, 0x06, 0x00, // AMF3 string reference 0
+	}
+
+	d := NewDecoder(bytes.NewReader(data))
+	for _, want := range []any{"foo", "x", "foo"} {
+		val, err := d.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if val != want {
+			t.Errorf("got %v, want %v", val, want)
+		}
+	}
+
+	// Reset하면 AMF3 참조 테이블도 비워짐
+	d.Reset(bytes.NewReader([]byte{0x11, 0x06, 0x00}))
+	if _, err := d.Decode(); err == nil {
+		t.Error("expected reference error after Reset")
+	}
+}
+
+func FuzzDecodeAMF0(f *testing.F) {
+	seed, _ := EncodeAMF0Sequence(map[string]any{"app": "live", "list": []any{1.0, "a", nil}},
+		time.UnixMilli(0), XMLDocument("<a/>"), NewTypedObject("Video"))
+	f.Add(seed)
+	f.Add([]byte{0x03, 0x00, 0x04, 's', 'e', 'l', 'f', 0x07, 0x00, 0x00, 0x00, 0x00, 0x09})
+	f.Add([]byte{0x08, 0x00, 0x00, 0x00, 0x01, 0x00, 0x01, 'k', 0x11, 0x09, 0x03, 0x01, 0x04, 0x01, 0x00, 0x00, 0x09})
+	f.Add([]byte{0x0C, 0xFF, 0xFF, 0xFF, 0xFF})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		val, err := DecodeAMF0(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+		// 디코딩된 값은 다시 인코딩할 수 있거나 에러를 반환해야 함 (panic 금지)
+		_, _ = EncodeAMF0Sequence(val)
+	})
+}
@@ -5,13 +5,65 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"math"
+	"reflect"
+	"strconv"
 	"time"
 )
 
+// Encoder writes AMF0 values to a stream.
+// 같은 Encoder로 쓴 object, typed object, array는 참조 테이블에 등록되어
+// 다시 나오면 참조(0x07)로 인코딩됨. 메시지마다 Reset으로 테이블을 비워야 함
+type Encoder struct {
+	w       io.Writer
+	refs    map[refKey]int // 값 식별자 -> 참조 인덱스
+	nrefs   int            // 지금까지 쓴 complex 값 수 (디코더의 참조 테이블과 같은 순서)
+	amf3    *AMF3Context   // AVM+ 값용, 처음 쓸 때 생성
+	scratch [8]byte
+}
+
+// refKey identifies a map, pointer or slice by its backing memory and type
+// (*ECMAArray와 내장된 *Object처럼 주소가 같은 값을 구분)
+type refKey struct {
+	ptr uintptr
+	len int
+	typ reflect.Type
+}
+
+// NewEncoder creates an encoder that writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Reset clears the reference tables and makes the encoder write to w.
+func (e *Encoder) Reset(w io.Writer) {
+	e.w = w
+	clear(e.refs)
+	e.nrefs = 0
+	if e.amf3 != nil {
+		e.amf3.Reset()
+	}
+}
+
+// Encode writes a single AMF0 value.
+func (e *Encoder) Encode(value any) error {
+	return e.encodeValue(value)
+}
+
+// EncodeAMF3 writes value as AMF3 after the AVM+ marker (0x11).
+// AMF3 참조 테이블은 Encoder에 유지되어 이후 AVM+ 값들과 공유됨
+func (e *Encoder) EncodeAMF3(value any) error {
+	if e.amf3 == nil {
+		e.amf3 = NewAMF3Context()
+	}
+	return e.amf3.EncodeAVMPlus(e.w, value)
+}
+
 func EncodeAMF0Sequence(values ...any) ([]byte, error) {
 	buf := new(bytes.Buffer)
+	enc := NewEncoder(buf)
 	for _, val := range values {
-		if err := encodeValue(buf, val); err != nil {
+		if err := enc.Encode(val); err != nil {
 			return nil, err
 		}
 	}
@@ -19,130 +71,286 @@ func EncodeAMF0Sequence(values ...any) ([]byte, error) {
 }
 
 func encodeValue(w io.Writer, value any) error {
+	return NewEncoder(w).encodeValue(value)
+}
+
+func (e *Encoder) encodeValue(value any) error {
 	switch v := value.(type) {
 	case nil:
-		_, err := w.Write([]byte{nullMarker})
-		return err
+		return e.writeByte(nullMarker)
+	case Undefined:
+		return e.writeByte(undefinedMarker)
 	case bool:
 		b := byte(0)
 		if v {
 			b = 1
 		}
-		_, err := w.Write([]byte{booleanMarker, b})
+		_, err := e.w.Write([]byte{booleanMarker, b})
 		return err
 	case float64:
-		if err := writeByte(w, numberMarker); err != nil {
-			return err
-		}
-		return binary.Write(w, binary.BigEndian, v)
+		return e.encodeNumber(v)
 	case float32:
-		if err := writeByte(w, numberMarker); err != nil {
-			return err
-		}
-		return binary.Write(w, binary.BigEndian, float64(v))
+		return e.encodeNumber(float64(v))
 	case int:
-		if err := writeByte(w, numberMarker); err != nil {
-			return err
-		}
-		return binary.Write(w, binary.BigEndian, float64(v))
+		return e.encodeNumber(float64(v))
 	case int32:
-		if err := writeByte(w, numberMarker); err != nil {
-			return err
-		}
-		return binary.Write(w, binary.BigEndian, float64(v))
+		return e.encodeNumber(float64(v))
 	case int64:
-		if err := writeByte(w, numberMarker); err != nil {
-			return err
-		}
-		return binary.Write(w, binary.BigEndian, float64(v))
+		return e.encodeNumber(float64(v))
 	case uint:
-		if err := writeByte(w, numberMarker); err != nil {
-			return err
-		}
-		return binary.Write(w, binary.BigEndian, float64(v))
+		return e.encodeNumber(float64(v))
 	case uint32:
-		if err := writeByte(w, numberMarker); err != nil {
-			return err
-		}
-		return binary.Write(w, binary.BigEndian, float64(v))
+		return e.encodeNumber(float64(v))
 	case uint64:
-		if err := writeByte(w, numberMarker); err != nil {
-			return err
-		}
-		return binary.Write(w, binary.BigEndian, float64(v))
+		return e.encodeNumber(float64(v))
 	case string:
-		return encodeString(w, v)
+		return e.encodeString(v)
+	case XMLDocument:
+		return e.encodeXMLDocument(v)
 	case map[string]any:
-		return encodeObject(w, v)
+		return e.encodeObject(v)
+	case *Object:
+		if v == nil {
+			return e.writeByte(nullMarker)
+		}
+		return e.encodeOrderedObject(v, refKeyOf(v))
+	case Object:
+		return e.encodeOrderedObject(&v, refKey{})
+	case *ECMAArray:
+		if v == nil {
+			return e.writeByte(nullMarker)
+		}
+		return e.encodeECMAArray(v, refKeyOf(v))
+	case ECMAArray:
+		return e.encodeECMAArray(&v, refKey{})
+	case *MixedArray:
+		if v == nil {
+			return e.writeByte(nullMarker)
+		}
+		return e.encodeMixedArray(v, refKeyOf(v))
+	case MixedArray:
+		return e.encodeMixedArray(&v, refKey{})
+	case *TypedObject:
+		if v == nil {
+			return e.writeByte(nullMarker)
+		}
+		return e.encodeTypedObject(v, refKeyOf(v))
+	case TypedObject:
+		// 값으로 전달된 객체는 식별자가 없으므로 참조하지 않음
+		return e.encodeTypedObject(&v, refKey{})
 	case []any:
-		return encodeStrictArray(w, v)
+		return e.encodeStrictArray(v)
 	case time.Time:
-		return encodeDate(w, v)
+		return e.encodeDate(v)
 	default:
 		return fmt.Errorf("unsupported AMF0 type: %T", value)
 	}
 }
 
+func (e *Encoder) encodeNumber(v float64) error {
+	if err := e.writeByte(numberMarker); err != nil {
+		return err
+	}
+	binary.BigEndian.PutUint64(e.scratch[:], math.Float64bits(v))
+	_, err := e.w.Write(e.scratch[:8])
+	return err
+}
+
 func encodeString(w io.Writer, s string) error {
-	byteLen := len([]byte(s)) // UTF-8 바이트 길이로 정확히 측정
+	return NewEncoder(w).encodeString(s)
+}
+
+func (e *Encoder) encodeString(s string) error {
+	byteLen := len(s) // UTF-8 바이트 길이
 	if byteLen < 65536 {
-		if err := writeByte(w, stringMarker); err != nil {
+		if err := e.writeByte(stringMarker); err != nil {
 			return err
 		}
-		if err := binary.Write(w, binary.BigEndian, uint16(byteLen)); err != nil {
+		if err := e.writeUint16(uint16(byteLen)); err != nil {
 			return err
 		}
-		_, err := io.WriteString(w, s)
+		_, err := io.WriteString(e.w, s)
 		return err
 	} else {
-		if err := writeByte(w, longStringMarker); err != nil {
+		return e.encodeLongString(longStringMarker, s)
+	}
+}
+
+// encodeXMLDocument writes an XML document; same layout as a long string
+func (e *Encoder) encodeXMLDocument(doc XMLDocument) error {
+	return e.encodeLongString(xmlDocumentMarker, string(doc))
+}
+
+func (e *Encoder) encodeLongString(marker byte, s string) error {
+	if err := e.writeByte(marker); err != nil {
+		return err
+	}
+	if err := e.writeUint32(uint32(len(s))); err != nil {
+		return err
+	}
+	_, err := io.WriteString(e.w, s)
+	return err
+}
+
+func encodeObject(w io.Writer, obj map[string]any) error {
+	return NewEncoder(w).encodeObject(obj)
+}
+
+// encodeObject writes a map as an anonymous object with sorted keys
+func (e *Encoder) encodeObject(obj map[string]any) error {
+	if ok, err := e.encodeReference(refKeyOf(obj)); ok || err != nil {
+		return err
+	}
+	if err := e.writeByte(objectMarker); err != nil {
+		return err
+	}
+	for _, key := range sortedKeys(obj) {
+		if err := e.encodeObjectProperty(key, obj[key]); err != nil {
+			return err
+		}
+	}
+	return e.encodeObjectEnd()
+}
+
+// encodeOrderedObject writes an anonymous object in property order
+func (e *Encoder) encodeOrderedObject(obj *Object, key refKey) error {
+	if ok, err := e.encodeReference(key); ok || err != nil {
+		return err
+	}
+	if err := e.writeByte(objectMarker); err != nil {
+		return err
+	}
+	return e.encodeProperties(obj.Properties)
+}
+
+// encodeECMAArray writes the associative count followed by the properties in order
+func (e *Encoder) encodeECMAArray(arr *ECMAArray, key refKey) error {
+	if ok, err := e.encodeReference(key); ok || err != nil {
+		return err
+	}
+	if err := e.writeByte(ecmaArrayMarker); err != nil {
+		return err
+	}
+	count := arr.Count
+	if count == 0 {
+		count = uint32(len(arr.Properties))
+	}
+	if err := e.writeUint32(count); err != nil {
+		return err
+	}
+	return e.encodeProperties(arr.Properties)
+}
+
+// encodeMixedArray writes an AMF3 mixed array as an ECMA array.
+// dense 요소는 "0", "1", ... 키로 먼저 쓰고 연관 부분을 이어서 씀
+func (e *Encoder) encodeMixedArray(arr *MixedArray, key refKey) error {
+	if ok, err := e.encodeReference(key); ok || err != nil {
+		return err
+	}
+	if err := e.writeByte(ecmaArrayMarker); err != nil {
+		return err
+	}
+	if err := e.writeUint32(uint32(len(arr.Dense) + len(arr.Properties))); err != nil {
+		return err
+	}
+	for i, v := range arr.Dense {
+		if err := e.encodeObjectProperty(strconv.Itoa(i), v); err != nil {
 			return err
 		}
-		if err := binary.Write(w, binary.BigEndian, uint32(byteLen)); err != nil {
+	}
+	return e.encodeProperties(arr.Properties)
+}
+
+// encodeProperties writes properties followed by the object end marker
+func (e *Encoder) encodeProperties(props []Property) error {
+	for _, p := range props {
+		if err := e.encodeObjectProperty(p.Key, p.Value); err != nil {
 			return err
 		}
-		_, err := io.WriteString(w, s)
-		return err
 	}
+	return e.encodeObjectEnd()
 }
 
-func encodeObject(w io.Writer, obj map[string]any) error {
-	if err := writeByte(w, objectMarker); err != nil {
+// encodeTypedObject writes the class name followed by the members like an object.
+// sealed 멤버를 순서대로 먼저 쓰고 나머지는 키 정렬 순서
+func (e *Encoder) encodeTypedObject(obj *TypedObject, key refKey) error {
+	if ok, err := e.encodeReference(key); ok || err != nil {
+		return err
+	}
+	if err := e.writeByte(typedObjectMarker); err != nil {
 		return err
 	}
-	for key, val := range obj {
-		if err := encodeObjectProperty(w, key, val); err != nil {
+	if err := e.encodeKey(obj.ClassName); err != nil {
+		return err
+	}
+
+	written := make(map[string]bool, len(obj.Sealed))
+	for _, name := range obj.Sealed {
+		if written[name] {
+			continue
+		}
+		written[name] = true
+		if err := e.encodeObjectProperty(name, obj.Members[name]); err != nil {
+			return err
+		}
+	}
+	for _, key := range sortedKeys(obj.Members) {
+		if written[key] {
+			continue
+		}
+		if err := e.encodeObjectProperty(key, obj.Members[key]); err != nil {
 			return err
 		}
 	}
-	// object end marker: 0x00 0x00 0x09
-	_, err := w.Write([]byte{0x00, 0x00, objectEndMarker})
+	return e.encodeObjectEnd()
+}
+
+// encodeObjectEnd writes the object end marker: 0x00 0x00 0x09
+func (e *Encoder) encodeObjectEnd() error {
+	_, err := e.w.Write([]byte{0x00, 0x00, objectEndMarker})
 	return err
 }
 
 func encodeObjectProperty(w io.Writer, key string, val any) error {
-	keyByteLen := len([]byte(key)) // UTF-8 바이트 길이로 정확히 측정
+	return NewEncoder(w).encodeObjectProperty(key, val)
+}
+
+func (e *Encoder) encodeObjectProperty(key string, val any) error {
+	if err := e.encodeKey(key); err != nil {
+		return err
+	}
+	return e.encodeValue(val)
+}
+
+// encodeKey writes a property name or class name (UTF-8 without marker)
+func (e *Encoder) encodeKey(key string) error {
+	keyByteLen := len(key) // UTF-8 바이트 길이
 	if keyByteLen > 65535 {
 		return fmt.Errorf("object key too long: %d bytes (max 65535)", keyByteLen)
 	}
-	if err := binary.Write(w, binary.BigEndian, uint16(keyByteLen)); err != nil {
-		return err
-	}
-	if _, err := io.WriteString(w, key); err != nil {
+	if err := e.writeUint16(uint16(keyByteLen)); err != nil {
 		return err
 	}
-	return encodeValue(w, val)
+	_, err := io.WriteString(e.w, key)
+	return err
 }
 
 func encodeStrictArray(w io.Writer, arr []any) error {
-	if err := writeByte(w, strictArrayMarker); err != nil {
+	return NewEncoder(w).encodeStrictArray(arr)
+}
+
+func (e *Encoder) encodeStrictArray(arr []any) error {
+	if ok, err := e.encodeReference(refKeyOf(arr)); ok || err != nil {
+		return err
+	}
+	if err := e.writeByte(strictArrayMarker); err != nil {
 		return err
 	}
-	if err := binary.Write(w, binary.BigEndian, uint32(len(arr))); err != nil {
+	if err := e.writeUint32(uint32(len(arr))); err != nil {
 		return err
 	}
 	for _, v := range arr {
-		if err := encodeValue(w, v); err != nil {
+		if err := e.encodeValue(v); err != nil {
 			return err
 		}
 	}
@@ -150,15 +358,76 @@ func encodeStrictArray(w io.Writer, arr []any) error {
 }
 
 func encodeDate(w io.Writer, t time.Time) error {
-	if err := writeByte(w, dateMarker); err != nil {
+	return NewEncoder(w).encodeDate(t)
+}
+
+func (e *Encoder) encodeDate(t time.Time) error {
+	if err := e.writeByte(dateMarker); err != nil {
 		return err
 	}
 	ms := float64(t.UnixNano()) / 1e6
-	if err := binary.Write(w, binary.BigEndian, ms); err != nil {
+	binary.BigEndian.PutUint64(e.scratch[:], math.Float64bits(ms))
+	if _, err := e.w.Write(e.scratch[:8]); err != nil {
 		return err
 	}
 	// timezone, always 0
-	return binary.Write(w, binary.BigEndian, int16(0))
+	return e.writeUint16(0)
 }
 
+// encodeReference writes a reference if the value was already encoded.
+// 처음 나온 값이면 다음 인덱스를 등록하고 false를 반환 (호출자가 값을 씀)
+func (e *Encoder) encodeReference(key refKey) (bool, error) {
+	if key.ptr != 0 {
+		if idx, ok := e.refs[key]; ok {
+			if err := e.writeByte(referenceMarker); err != nil {
+				return true, err
+			}
+			return true, e.writeUint16(uint16(idx))
+		}
+	}
 
+	idx := e.nrefs
+	e.nrefs++
+	// 빈 값이나 u16 범위를 넘는 인덱스는 참조하지 않음 (인덱스는 디코더와 맞추기 위해 증가)
+	if key.ptr != 0 && idx <= math.MaxUint16 {
+		if e.refs == nil {
+			e.refs = make(map[refKey]int)
+		}
+		e.refs[key] = idx
+	}
+	return false, nil
+}
+
+// refKeyOf returns the identity of a map, pointer or slice (zero if it has none)
+func refKeyOf(v any) refKey {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice:
+		if rv.Len() == 0 {
+			return refKey{}
+		}
+		return refKey{ptr: rv.Pointer(), len: rv.Len(), typ: rv.Type()}
+	case reflect.Map, reflect.Pointer:
+		return refKey{ptr: rv.Pointer(), len: -1, typ: rv.Type()}
+	default:
+		return refKey{}
+	}
+}
+
+func (e *Encoder) writeByte(b byte) error {
+	e.scratch[0] = b
+	_, err := e.w.Write(e.scratch[:1])
+	return err
+}
+
+func (e *Encoder) writeUint16(v uint16) error {
+	binary.BigEndian.PutUint16(e.scratch[:], v)
+	_, err := e.w.Write(e.scratch[:2])
+	return err
+}
+
+func (e *Encoder) writeUint32(v uint32) error {
+	binary.BigEndian.PutUint32(e.scratch[:], v)
+	_, err := e.w.Write(e.scratch[:4])
+	return err
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"strings"
 	"testing"
 	"time"
@@ -727,3 +728,110 @@ func BenchmarkEncodeAMF0_Object(b *testing.B) {
 		_, _ = EncodeAMF0Sequence(obj)
 	}
 }
+
+func TestEncoder_RoundTrip(t *testing.T) {
+	video := NewTypedObject("Video")
+	video.Members["codec"] = "avc"
+	shared := map[string]any{"n": 1.0}
+
+	buf := new(bytes.Buffer)
+	enc := NewEncoder(buf)
+	for _, v := range []any{Undefined{}, nil, XMLDocument("<a/>"), video, shared, []any{shared, video}} {
+		if err := enc.Encode(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	d := NewDecoder(bytes.NewReader(buf.Bytes()))
+	var values []any
+	for {
+		val, err := d.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		values = append(values, val)
+	}
+	if len(values) != 6 {
+		t.Fatalf("got %d values, want 6", len(values))
+	}
+	if values[0] != (Undefined{}) || values[1] != nil || values[2] != XMLDocument("<a/>") {
+		t.Errorf("got %v", values[:3])
+	}
+	obj, ok := values[3].(*TypedObject)
+	if !ok || obj.ClassName != "Video" || obj.Members["codec"] != "avc" {
+		t.Fatalf("got %v", values[3])
+	}
+
+	// 두 번째로 나온 값은 참조로 인코딩되어 같은 값으로 디코딩됨
+	arr := values[5].([]any)
+	if arr[1] != obj {
+		t.Error("typed object reference not resolved")
+	}
+	arr[0].(map[string]any)["n"] = 2.0
+	if values[4].(map[string]any)["n"] != 2.0 {
+		t.Error("object reference not resolved")
+	}
+}
+
+func TestEncoder_ReferenceBytes(t *testing.T) {
+	obj := map[string]any{}
+	buf := new(bytes.Buffer)
+	enc := NewEncoder(buf)
+	if err := enc.Encode([]any{obj, obj}); err != nil {
+		t.Fatal(err)
+	}
+
+	// strict array #0, object #1, 참조 1
+	expected := []byte{0x0A, 0x00, 0x00, 0x00, 0x02, 0x03, 0x00, 0x00, 0x09, 0x07, 0x00, 0x01}
+	if !bytes.Equal(buf.Bytes(), expected) {
+		t.Errorf("expected %v, got %v", expected, buf.Bytes())
+	}
+
+	// Reset 후에는 다시 값 전체를 씀
+	buf.Reset()
+	enc.Reset(buf)
+	if err := enc.Encode(obj); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), []byte{0x03, 0x00, 0x00, 0x09}) {
+		t.Errorf("got %v after Reset", buf.Bytes())
+	}
+}
+
+func TestEncoder_Cycle(t *testing.T) {
+	obj := map[string]any{}
+	obj["self"] = obj
+
+	data, err := EncodeAMF0Sequence(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	val, err := DecodeAMF0(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := val.(map[string]any)
+	decoded["x"] = true
+	if decoded["self"].(map[string]any)["x"] != true {
+		t.Error("cyclic reference not preserved")
+	}
+}
+
+func TestEncoder_EncodeAMF3(t *testing.T) {
+	buf := new(bytes.Buffer)
+	enc := NewEncoder(buf)
+	for _, v := range []any{"foo", "foo"} {
+		if err := enc.EncodeAMF3(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 두 번째 AMF3 string은 Encoder의 AMF3 context에서 참조로 인코딩됨
+	expected := []byte{0x11, 0x06, 0x07, 'f', 'o', 'o', 0x11, 0x06, 0x00}
+	if !bytes.Equal(buf.Bytes(), expected) {
+		t.Errorf("expected %v, got %v", expected, buf.Bytes())
+	}
+}
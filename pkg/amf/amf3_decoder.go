@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"slices"
 	"time"
 )
 
@@ -76,6 +77,9 @@ func (ctx *AMF3Context) decodeStringValue(r io.Reader) (string, error) {
 	if length == 0 {
 		return "", nil
 	}
+	if err := ctx.limits.checkString(length); err != nil {
+		return "", err
+	}
 
 	buf, err := readBytes(r, length)
 	if err != nil {
@@ -88,6 +92,7 @@ func (ctx *AMF3Context) decodeStringValue(r io.Reader) (string, error) {
 }
 
 // decodeObject decodes an AMF3 object.
+// 클래스 이름이 없으면 map[string]any, 있으면 *TypedObject, externalizable이면 등록된 타입으로 반환
 func (ctx *AMF3Context) decodeObject(r io.Reader) (any, error) {
 	u29, err := ctx.decodeU29(r)
 	if err != nil {
@@ -102,92 +107,380 @@ func (ctx *AMF3Context) decodeObject(r io.Reader) (any, error) {
 		return ctx.objectTable[idx], nil
 	}
 
-	// Inline object, traits may be referenced or inline
+	traits, err := ctx.decodeTraits(r, u29)
+	if err != nil {
+		return nil, err
+	}
+
+	if traits.Externalizable {
+		ext, err := newExternalizable(traits.ClassName)
+		if err != nil {
+			return nil, err
+		}
+		ctx.objectTable = append(ctx.objectTable, ext)
+		if err := ext.ReadExternal(ctx, r); err != nil {
+			return nil, fmt.Errorf("%s: %w", traits.ClassName, err)
+		}
+		return ext, nil
+	}
+
+	// 멤버보다 먼저 테이블에 추가해야 순환 참조가 동작
+	members := make(map[string]any, len(traits.Sealed))
+	var obj any = members
+	if traits.ClassName != "" {
+		obj = &TypedObject{
+			ClassName: traits.ClassName,
+			Sealed:    traits.Sealed,
+			Dynamic:   traits.Dynamic,
+			Members:   members,
+		}
+	}
+	ctx.objectTable = append(ctx.objectTable, obj)
+
+	for _, name := range traits.Sealed {
+		value, err := ctx.DecodeAMF3(r)
+		if err != nil {
+			return nil, err
+		}
+		members[name] = value
+	}
+
+	if traits.Dynamic {
+		for n := len(traits.Sealed) + 1; ; n++ {
+			key, err := ctx.decodeStringValue(r)
+			if err != nil {
+				return nil, err
+			}
+			if key == "" {
+				break
+			}
+			if err := ctx.limits.checkCollection(n); err != nil {
+				return nil, err
+			}
+			value, err := ctx.DecodeAMF3(r)
+			if err != nil {
+				return nil, err
+			}
+			members[key] = value
+		}
+	}
+
+	return obj, nil
+}
+
+// decodeTraits decodes the traits of an inline object (u29의 bit 0은 이미 처리됨).
+// bit 1이 0이면 trait 참조, 아니면 inline traits (bit 2 externalizable, bit 3 dynamic, 나머지 sealed 개수)
+func (ctx *AMF3Context) decodeTraits(r io.Reader, u29 uint32) (*Traits, error) {
 	if u29&2 == 0 { // Trait reference
-		return nil, errors.New("trait references not supported")
+		idx := int(u29 >> 2)
+		if idx >= len(ctx.traitTable) {
+			return nil, errors.New("trait reference out of bounds")
+		}
+		return ctx.traitTable[idx], nil
 	}
 
-	// Inline traits
-	// For simplicity, we assume not dynamic and no externalizable
+	traits := &Traits{
+		Externalizable: u29&4 != 0,
+		Dynamic:        u29&8 != 0,
+	}
 	className, err := ctx.decodeStringValue(r)
 	if err != nil {
 		return nil, err
 	}
+	traits.ClassName = className
 
-	obj := make(map[string]any)
-	if className != "" {
-		// This could be a typed object, store class name if needed
+	if count := int(u29 >> 4); count > 0 {
+		if err := ctx.limits.checkCollection(count); err != nil {
+			return nil, err
+		}
+		traits.Sealed = newItems[string](count)
+		for i := range count {
+			name, err := ctx.decodeStringValue(r)
+			if err != nil {
+				return nil, err
+			}
+			traits.Sealed = setItem(traits.Sealed, i, name)
+		}
 	}
 
-	ctx.objectTable = append(ctx.objectTable, obj)
+	ctx.traitTable = append(ctx.traitTable, traits)
+	return traits, nil
+}
 
-	for {
-		key, err := ctx.decodeStringValue(r)
+// decodeArray decodes an AMF3 array.
+// 연관 부분이 없으면 []any, 있으면 dense와 연관 부분을 모두 가진 *MixedArray로 반환
+func (ctx *AMF3Context) decodeArray(r io.Reader) (any, error) {
+	u29, err := ctx.decodeU29(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if u29&1 == 0 { // Reference
+		ref, err := ctx.objectReference(u29, "array")
 		if err != nil {
 			return nil, err
 		}
-		if key == "" {
-			break
+		switch ref.(type) {
+		case []any, *MixedArray:
+			return ref, nil
 		}
-		value, err := ctx.DecodeAMF3(r)
+		return nil, errors.New("referenced object is not an array")
+	}
+
+	length := int(u29 >> 1)
+	if err := ctx.limits.checkCollection(length); err != nil {
+		return nil, err
+	}
+
+	// 첫 키는 string 테이블만 사용하므로 object 테이블 등록 전에 읽어도 됨
+	key, err := ctx.decodeStringValue(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if key == "" {
+		arr := newItems[any](length)
+		idx := len(ctx.objectTable)
+		ctx.objectTable = append(ctx.objectTable, arr)
+		if arr, err = ctx.decodeDense(r, arr, length); err != nil {
+			return nil, err
+		}
+		ctx.objectTable[idx] = arr // maxPrealloc보다 큰 배열은 읽으면서 늘어난 slice로 교체
+		return arr, nil
+	}
+
+	mixed := &MixedArray{Dense: newItems[any](length)}
+	ctx.objectTable = append(ctx.objectTable, mixed)
+	for key != "" {
+		if err := ctx.limits.checkCollection(len(mixed.Properties) + 1); err != nil {
+			return nil, err
+		}
+		val, err := ctx.DecodeAMF3(r)
 		if err != nil {
 			return nil, err
 		}
-		obj[key] = value
+		mixed.Properties = append(mixed.Properties, Property{Key: key, Value: val})
+
+		if key, err = ctx.decodeStringValue(r); err != nil {
+			return nil, err
+		}
 	}
+	if mixed.Dense, err = ctx.decodeDense(r, mixed.Dense, length); err != nil {
+		return nil, err
+	}
+	return mixed, nil
+}
 
-	return obj, nil
+// decodeDense decodes count dense elements into arr allocated by newItems.
+func (ctx *AMF3Context) decodeDense(r io.Reader, arr []any, count int) ([]any, error) {
+	for i := range count {
+		val, err := ctx.DecodeAMF3(r)
+		if err != nil {
+			return nil, err
+		}
+		arr = setItem(arr, i, val)
+	}
+	return arr, nil
 }
 
-// decodeArray decodes an AMF3 array.
-func (ctx *AMF3Context) decodeArray(r io.Reader) (any, error) {
+// decodeByteArray decodes an AMF3 ByteArray.
+func (ctx *AMF3Context) decodeByteArray(r io.Reader) (any, error) {
 	u29, err := ctx.decodeU29(r)
 	if err != nil {
 		return nil, err
 	}
 
 	if u29&1 == 0 { // Reference
-		idx := int(u29 >> 1)
-		if idx >= len(ctx.objectTable) {
-			return nil, errors.New("array reference out of bounds")
+		ref, err := ctx.objectReference(u29, "byte array")
+		if err != nil {
+			return nil, err
 		}
-		arr, ok := ctx.objectTable[idx].([]any)
+		b, ok := ref.(ByteArray)
 		if !ok {
-			return nil, errors.New("referenced object is not an array")
+			return nil, errors.New("referenced object is not a byte array")
 		}
-		return arr, nil
+		return b, nil
 	}
 
-	length := int(u29 >> 1)
-	arr := make([]any, length)
-	ctx.objectTable = append(ctx.objectTable, arr)
+	if err := ctx.limits.checkString(int(u29 >> 1)); err != nil {
+		return nil, err
+	}
+	buf, err := readBytes(r, int(u29>>1))
+	if err != nil {
+		return nil, err
+	}
+	b := ByteArray(buf)
+	ctx.objectTable = append(ctx.objectTable, b)
+	return b, nil
+}
 
-	// Associative part (not handled in this simplified version)
-	for {
-		key, err := ctx.decodeStringValue(r)
+// decodeXML decodes an XML (0x0B) or legacy XMLDocument (0x07) value.
+func (ctx *AMF3Context) decodeXML(r io.Reader, marker byte) (any, error) {
+	u29, err := ctx.decodeU29(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if u29&1 == 0 { // Reference
+		ref, err := ctx.objectReference(u29, "xml")
 		if err != nil {
 			return nil, err
 		}
-		if key == "" {
-			break
+		switch ref.(type) {
+		case XML, XMLDocument:
+			return ref, nil
 		}
-		// Skip associative values
-		val, err := ctx.DecodeAMF3(r)
+		return nil, errors.New("referenced object is not xml")
+	}
+
+	if err := ctx.limits.checkString(int(u29 >> 1)); err != nil {
+		return nil, err
+	}
+	buf, err := readBytes(r, int(u29>>1))
+	if err != nil {
+		return nil, err
+	}
+	var v any = XML(buf)
+	if marker == amf3XMLDocMarker {
+		v = XMLDocument(buf)
+	}
+	ctx.objectTable = append(ctx.objectTable, v)
+	return v, nil
+}
+
+// decodeVector decodes a Vector.<int>, Vector.<uint>, Vector.<Number> or object vector.
+// 각각 *VectorInt, *VectorUint, *VectorDouble, *VectorObject로 반환
+func (ctx *AMF3Context) decodeVector(r io.Reader, marker byte) (any, error) {
+	u29, err := ctx.decodeU29(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if u29&1 == 0 { // Reference
+		ref, err := ctx.objectReference(u29, "vector")
 		if err != nil {
 			return nil, err
 		}
-		_ = val
+		var ok bool
+		switch marker {
+		case amf3VectorIntMarker:
+			_, ok = ref.(*VectorInt)
+		case amf3VectorUintMarker:
+			_, ok = ref.(*VectorUint)
+		case amf3VectorDoubleMarker:
+			_, ok = ref.(*VectorDouble)
+		default:
+			_, ok = ref.(*VectorObject)
+		}
+		if !ok {
+			return nil, errors.New("referenced object is not a vector of the same type")
+		}
+		return ref, nil
 	}
 
-	for i := 0; i < length; i++ {
-		val, err := ctx.DecodeAMF3(r)
+	count := int(u29 >> 1)
+	if err := ctx.limits.checkCollection(count); err != nil {
+		return nil, err
+	}
+	fixed, err := readByte(r)
+	if err != nil {
+		return nil, err
+	}
+
+	switch marker {
+	case amf3VectorIntMarker:
+		v := &VectorInt{Fixed: fixed != 0}
+		ctx.objectTable = append(ctx.objectTable, v)
+		v.Items, err = readVector[int32](r, count)
+		return v, err
+	case amf3VectorUintMarker:
+		v := &VectorUint{Fixed: fixed != 0}
+		ctx.objectTable = append(ctx.objectTable, v)
+		v.Items, err = readVector[uint32](r, count)
+		return v, err
+	case amf3VectorDoubleMarker:
+		v := &VectorDouble{Fixed: fixed != 0}
+		ctx.objectTable = append(ctx.objectTable, v)
+		v.Items, err = readVector[float64](r, count)
+		return v, err
+	}
+
+	typeName, err := ctx.decodeStringValue(r)
+	if err != nil {
+		return nil, err
+	}
+	v := &VectorObject{Fixed: fixed != 0, TypeName: typeName, Items: newItems[any](count)}
+	ctx.objectTable = append(ctx.objectTable, v)
+	if v.Items, err = ctx.decodeDense(r, v.Items, count); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// readVector reads count big-endian numbers, maxPrealloc개씩 읽은 만큼만 할당
+func readVector[T int32 | uint32 | float64](r io.Reader, count int) ([]T, error) {
+	items := make([]T, 0, min(count, maxPrealloc))
+	for len(items) < count {
+		n := min(count-len(items), maxPrealloc)
+		items = slices.Grow(items, n)
+		if err := binary.Read(r, binary.BigEndian, items[len(items):len(items)+n]); err != nil {
+			return nil, err
+		}
+		items = items[:len(items)+n]
+	}
+	return items, nil
+}
+
+// decodeDictionary decodes an AMF3 Dictionary into *Dictionary.
+func (ctx *AMF3Context) decodeDictionary(r io.Reader) (any, error) {
+	u29, err := ctx.decodeU29(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if u29&1 == 0 { // Reference
+		ref, err := ctx.objectReference(u29, "dictionary")
 		if err != nil {
 			return nil, err
 		}
-		arr[i] = val
+		d, ok := ref.(*Dictionary)
+		if !ok {
+			return nil, errors.New("referenced object is not a dictionary")
+		}
+		return d, nil
 	}
 
-	return arr, nil
+	count := int(u29 >> 1)
+	if err := ctx.limits.checkCollection(count); err != nil {
+		return nil, err
+	}
+	weak, err := readByte(r)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Dictionary{WeakKeys: weak != 0, Entries: newItems[DictionaryEntry](count)}
+	ctx.objectTable = append(ctx.objectTable, d)
+	for i := range count {
+		var e DictionaryEntry
+		if e.Key, err = ctx.DecodeAMF3(r); err != nil {
+			return nil, err
+		}
+		if e.Value, err = ctx.DecodeAMF3(r); err != nil {
+			return nil, err
+		}
+		d.Entries = setItem(d.Entries, i, e)
+	}
+	return d, nil
+}
+
+// objectReference returns the object table entry of a reference (u29의 bit 0이 0).
+func (ctx *AMF3Context) objectReference(u29 uint32, kind string) (any, error) {
+	idx := int(u29 >> 1)
+	if idx >= len(ctx.objectTable) {
+		return nil, fmt.Errorf("%s reference out of bounds", kind)
+	}
+	return ctx.objectTable[idx], nil
 }
 
 // decodeDate decodes an AMF3 date.
@@ -221,6 +514,13 @@ func (ctx *AMF3Context) decodeDate(r io.Reader) (any, error) {
 
 // DecodeAMF3 decodes a single AMF3 value.
 func (ctx *AMF3Context) DecodeAMF3(r io.Reader) (any, error) {
+	// 최상위 호출에서만 MaxBytes 카운터로 감쌈 (중첩 호출은 이미 감싼 reader를 받음)
+	if ctx.depth == 0 && ctx.limits.MaxBytes > 0 {
+		if lr, ok := r.(*limitReader); !ok || lr.n != &ctx.read {
+			r = &limitReader{r: r, n: &ctx.read, max: ctx.limits.MaxBytes}
+		}
+	}
+
 	marker, err := readByte(r)
 	if err != nil {
 		return nil, err
@@ -241,12 +541,36 @@ func (ctx *AMF3Context) DecodeAMF3(r io.Reader) (any, error) {
 		return ctx.decodeString(r)
 	case amf3DateMarker:
 		return ctx.decodeDate(r)
+	case amf3ArrayMarker, amf3ObjectMarker, amf3VectorObjectMarker, amf3DictionaryMarker:
+		return ctx.decodeComplex(r, marker)
+	case amf3XMLDocMarker, amf3XMLMarker:
+		return ctx.decodeXML(r, marker)
+	case amf3ByteArrayMarker:
+		return ctx.decodeByteArray(r)
+	case amf3VectorIntMarker, amf3VectorUintMarker, amf3VectorDoubleMarker:
+		return ctx.decodeVector(r, marker)
+	default:
+		return nil, fmt.Errorf("unsupported AMF3 marker: 0x%02x", marker)
+	}
+}
+
+// decodeComplex decodes a value that contains other values within MaxDepth.
+func (ctx *AMF3Context) decodeComplex(r io.Reader, marker byte) (any, error) {
+	ctx.depth++
+	defer func() { ctx.depth-- }()
+	if err := ctx.limits.checkDepth(ctx.depth); err != nil {
+		return nil, err
+	}
+
+	switch marker {
 	case amf3ArrayMarker:
 		return ctx.decodeArray(r)
 	case amf3ObjectMarker:
 		return ctx.decodeObject(r)
+	case amf3VectorObjectMarker:
+		return ctx.decodeVector(r, marker)
 	default:
-		return nil, fmt.Errorf("unsupported AMF3 marker: 0x%02x", marker)
+		return ctx.decodeDictionary(r)
 	}
 }
 
@@ -538,14 +538,14 @@ func TestDecodeObject_ReferenceWrongType(t *testing.T) {
 func TestDecodeObject_TraitReference(t *testing.T) {
 	ctx := NewAMF3Context()
 	
-	// 트레이트 참조 (현재 지원하지 않음)
+	// 트레이트 참조 (인덱스 1, 테이블에 없음)
 	data := []byte{0x05} // 트레이트 참조 플래그
 	_, err := ctx.decodeObject(bytes.NewReader(data))
 	if err == nil {
 		t.Fatal("expected trait reference error")
 	}
-	if !strings.Contains(err.Error(), "trait references not supported") {
-		t.Errorf("expected error to contain 'trait references not supported', got %v", err.Error())
+	if !strings.Contains(err.Error(), "trait reference out of bounds") {
+		t.Errorf("expected error to contain 'trait reference out of bounds', got %v", err.Error())
 	}
 }
 
@@ -563,8 +563,8 @@ func TestDecodeObject_ClassNameReadError(t *testing.T) {
 func TestDecodeObject_KeyReadError(t *testing.T) {
 	ctx := NewAMF3Context()
 	
-	// 인라인 + 트레이트 플래그, 빈 클래스명, 하지만 키 읽기 실패
-	data := []byte{0x03, 0x01} // 인라인 + 트레이트 플래그, 빈 문자열 (클래스명)
+	// 인라인 + 트레이트 + dynamic 플래그, 빈 클래스명, 하지만 키 읽기 실패
+	data := []byte{0x0B, 0x01} // 인라인 + 트레이트 + dynamic 플래그, 빈 문자열 (클래스명)
 	_, err := ctx.decodeObject(bytes.NewReader(data))
 	if err == nil {
 		t.Fatal("expected key read error")
@@ -574,8 +574,8 @@ func TestDecodeObject_KeyReadError(t *testing.T) {
 func TestDecodeObject_ValueReadError(t *testing.T) {
 	ctx := NewAMF3Context()
 	
-	// 인라인 + 트레이트 플래그, 빈 클래스명, 키는 있지만 값 읽기 실패
-	data := []byte{0x03, 0x01, 0x07, 'k', 'e', 'y'} // 인라인 + 트레이트 플래그, 빈 클래스명, "key"
+	// 인라인 + 트레이트 + dynamic 플래그, 빈 클래스명, 키는 있지만 값 읽기 실패
+	data := []byte{0x0B, 0x01, 0x07, 'k', 'e', 'y'} // 인라인 + 트레이트 + dynamic 플래그, 빈 클래스명, "key"
 	_, err := ctx.decodeObject(bytes.NewReader(data))
 	if err == nil {
 		t.Fatal("expected value read error")
@@ -831,4 +831,18 @@ func BenchmarkDecodeAMF3_Object(b *testing.B) {
 		reader.Reset(encoded)
 		_, _ = DecodeAMF3Sequence(reader)
 	}
-}
\ No newline at end of file
+}
+
+func FuzzDecodeAMF3Sequence(f *testing.F) {
+	seed, _ := EncodeAMF3Sequence(map[string]any{"app": "live"}, []any{int32(1), "a"},
+		&VectorObject{TypeName: "*", Items: []any{"x"}}, &Dictionary{Entries: []DictionaryEntry{{Key: int32(1), Value: "v"}}},
+		ByteArray{1, 2}, NewTypedObject("Video"), &ArrayCollection{"a"})
+	f.Add(seed)
+	f.Add([]byte{amf3ObjectMarker, 0x0B, 0x01, 0x09, 's', 'e', 'l', 'f', amf3ObjectMarker, 0x00, 0x01})
+	f.Add([]byte{amf3ArrayMarker, 0x03, 0x03, 'k', amf3ArrayMarker, 0x00, 0x01, amf3TrueMarker})
+	f.Add([]byte{amf3StringMarker, 0xFF, 0xFF, 0xFF, 0xFF})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		_, _ = DecodeAMF3Sequence(bytes.NewReader(data))
+	})
+}
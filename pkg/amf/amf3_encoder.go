@@ -85,30 +85,108 @@ func (ctx *AMF3Context) encodeStringValue(w io.Writer, value string) error {
 	return err
 }
 
-// encodeObject encodes a map[string]any value.
+// encodeObject encodes a map[string]any value as an anonymous dynamic object.
 func (ctx *AMF3Context) encodeObject(w io.Writer, value map[string]any) error {
+	if ok, err := ctx.encodeObjectReference(w, amf3ObjectMarker, refKeyOf(value)); ok || err != nil {
+		return err
+	}
 	if err := writeByte(w, amf3ObjectMarker); err != nil {
 		return err
 	}
+	if err := ctx.encodeTraits(w, &Traits{Dynamic: true}); err != nil {
+		return err
+	}
+	return ctx.encodeDynamicMembers(w, value, nil)
+}
 
-	// For simplicity, this implementation does not use object reference table.
-	// Always encode as inline object with inline traits.
+// encodeTypedObject encodes a typed object with its sealed and dynamic members.
+// Members에 없는 sealed 멤버는 null로 인코딩
+func (ctx *AMF3Context) encodeTypedObject(w io.Writer, value *TypedObject, key refKey) error {
+	if ok, err := ctx.encodeObjectReference(w, amf3ObjectMarker, key); ok || err != nil {
+		return err
+	}
+	if err := writeByte(w, amf3ObjectMarker); err != nil {
+		return err
+	}
+	if err := ctx.encodeTraits(w, value.traits()); err != nil {
+		return err
+	}
+
+	for _, name := range value.Sealed {
+		if err := ctx.encodeValue(w, value.Members[name]); err != nil {
+			return err
+		}
+	}
+	if !value.Dynamic {
+		return nil
+	}
+
+	var sealed map[string]bool
+	if len(value.Sealed) > 0 {
+		sealed = make(map[string]bool, len(value.Sealed))
+		for _, name := range value.Sealed {
+			sealed[name] = true
+		}
+	}
+	return ctx.encodeDynamicMembers(w, value.Members, sealed)
+}
 
-	if err := ctx.encodeU29(w, 0x0B); err != nil {
+// encodeExternalizable encodes an externalizable object with its custom body.
+func (ctx *AMF3Context) encodeExternalizable(w io.Writer, value Externalizable) error {
+	if ok, err := ctx.encodeObjectReference(w, amf3ObjectMarker, refKeyOf(value)); ok || err != nil {
 		return err
 	}
+	if err := writeByte(w, amf3ObjectMarker); err != nil {
+		return err
+	}
+	if err := ctx.encodeTraits(w, &Traits{ClassName: value.ClassName(), Externalizable: true}); err != nil {
+		return err
+	}
+	return value.WriteExternal(ctx, w)
+}
 
-	// Class name (empty)
-	if err := ctx.encodeStringValue(w, ""); err != nil {
+// encodeTraits writes the object header: a trait reference if the same traits were written before,
+// otherwise inline traits (class name, sealed member names).
+func (ctx *AMF3Context) encodeTraits(w io.Writer, traits *Traits) error {
+	key := traits.key()
+	if idx, ok := ctx.traitTableMap[key]; ok {
+		return ctx.encodeU29(w, uint32(idx)<<2|0x01)
+	}
+	ctx.traitTable = append(ctx.traitTable, traits)
+	ctx.traitTableMap[key] = len(ctx.traitTable) - 1
+
+	flags := uint32(len(traits.Sealed))<<4 | 0x03 // inline object, inline traits
+	if traits.Externalizable {
+		flags |= 0x04
+	}
+	if traits.Dynamic {
+		flags |= 0x08
+	}
+	if err := ctx.encodeU29(w, flags); err != nil {
 		return err
 	}
 
-	// Encode properties
-	for key, val := range value {
+	if err := ctx.encodeStringValue(w, traits.ClassName); err != nil {
+		return err
+	}
+	for _, name := range traits.Sealed {
+		if err := ctx.encodeStringValue(w, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeDynamicMembers writes dynamic members except skip in key order, terminated by an empty key.
+func (ctx *AMF3Context) encodeDynamicMembers(w io.Writer, members map[string]any, skip map[string]bool) error {
+	for _, key := range sortedKeys(members) {
+		if key == "" || skip[key] {
+			continue // 빈 키는 종료 표시와 구분할 수 없음
+		}
 		if err := ctx.encodeStringValue(w, key); err != nil {
 			return err
 		}
-		if err := ctx.encodeValue(w, val); err != nil {
+		if err := ctx.encodeValue(w, members[key]); err != nil {
 			return err
 		}
 	}
@@ -117,13 +195,58 @@ func (ctx *AMF3Context) encodeObject(w io.Writer, value map[string]any) error {
 	return ctx.encodeStringValue(w, "")
 }
 
+// encodeOrderedObject encodes an *Object as an anonymous dynamic object in property order.
+func (ctx *AMF3Context) encodeOrderedObject(w io.Writer, value *Object, key refKey) error {
+	if ok, err := ctx.encodeObjectReference(w, amf3ObjectMarker, key); ok || err != nil {
+		return err
+	}
+	if err := writeByte(w, amf3ObjectMarker); err != nil {
+		return err
+	}
+	if err := ctx.encodeTraits(w, &Traits{Dynamic: true}); err != nil {
+		return err
+	}
+	return ctx.encodeProperties(w, value.Properties)
+}
+
+// encodeECMAArray encodes an *ECMAArray as an array with only an associative part.
+func (ctx *AMF3Context) encodeECMAArray(w io.Writer, value *ECMAArray, key refKey) error {
+	if ok, err := ctx.encodeObjectReference(w, amf3ArrayMarker, key); ok || err != nil {
+		return err
+	}
+	if err := writeByte(w, amf3ArrayMarker); err != nil {
+		return err
+	}
+	if err := ctx.encodeU29(w, 1); err != nil { // dense length 0, inline
+		return err
+	}
+	return ctx.encodeProperties(w, value.Properties)
+}
+
+// encodeProperties writes key/value pairs in order, terminated by an empty key.
+func (ctx *AMF3Context) encodeProperties(w io.Writer, props []Property) error {
+	for _, p := range props {
+		if p.Key == "" {
+			continue // 빈 키는 종료 표시와 구분할 수 없음
+		}
+		if err := ctx.encodeStringValue(w, p.Key); err != nil {
+			return err
+		}
+		if err := ctx.encodeValue(w, p.Value); err != nil {
+			return err
+		}
+	}
+	return ctx.encodeStringValue(w, "")
+}
+
 // encodeArray encodes a []any value.
 func (ctx *AMF3Context) encodeArray(w io.Writer, value []any) error {
+	if ok, err := ctx.encodeObjectReference(w, amf3ArrayMarker, refKeyOf(value)); ok || err != nil {
+		return err
+	}
 	if err := writeByte(w, amf3ArrayMarker); err != nil {
 		return err
 	}
-
-	// For simplicity, this implementation does not use object reference table.
 	if err := ctx.encodeU29(w, uint32(len(value)<<1)|1); err != nil { // Length, inline
 		return err
 	}
@@ -142,23 +265,150 @@ func (ctx *AMF3Context) encodeArray(w io.Writer, value []any) error {
 	return nil
 }
 
+// encodeMixedArray encodes an array with both associative and dense parts.
+func (ctx *AMF3Context) encodeMixedArray(w io.Writer, value *MixedArray, key refKey) error {
+	if ok, err := ctx.encodeObjectReference(w, amf3ArrayMarker, key); ok || err != nil {
+		return err
+	}
+	if err := writeByte(w, amf3ArrayMarker); err != nil {
+		return err
+	}
+	if err := ctx.encodeU29(w, uint32(len(value.Dense)<<1)|1); err != nil {
+		return err
+	}
+	if err := ctx.encodeProperties(w, value.Properties); err != nil {
+		return err
+	}
+	for _, item := range value.Dense {
+		if err := ctx.encodeValue(w, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeBytes encodes a ByteArray, XML or XMLDocument: inline length followed by the raw bytes.
+// 참조하지 않지만 디코더와 인덱스를 맞추기 위해 객체 테이블 인덱스는 증가
+func (ctx *AMF3Context) encodeBytes(w io.Writer, marker byte, value []byte) error {
+	ctx.encodeObjectReference(w, marker, refKey{})
+	if err := writeByte(w, marker); err != nil {
+		return err
+	}
+	if err := ctx.encodeU29(w, uint32(len(value)<<1)|1); err != nil {
+		return err
+	}
+	_, err := w.Write(value)
+	return err
+}
+
+// encodeVectorHeader writes the marker, inline count and fixed flag of a vector,
+// or a reference if the vector was already encoded (true).
+func (ctx *AMF3Context) encodeVectorHeader(w io.Writer, marker byte, key refKey, count int, fixed bool) (bool, error) {
+	if ok, err := ctx.encodeObjectReference(w, marker, key); ok || err != nil {
+		return ok, err
+	}
+	if err := writeByte(w, marker); err != nil {
+		return false, err
+	}
+	if err := ctx.encodeU29(w, uint32(count<<1)|1); err != nil {
+		return false, err
+	}
+	b := byte(0)
+	if fixed {
+		b = 1
+	}
+	return false, writeByte(w, b)
+}
+
+// encodeVectorObject encodes an object vector with its element type name.
+func (ctx *AMF3Context) encodeVectorObject(w io.Writer, value *VectorObject, key refKey) error {
+	if ok, err := ctx.encodeVectorHeader(w, amf3VectorObjectMarker, key, len(value.Items), value.Fixed); ok || err != nil {
+		return err
+	}
+	typeName := value.TypeName
+	if typeName == "" {
+		typeName = "*"
+	}
+	if err := ctx.encodeStringValue(w, typeName); err != nil {
+		return err
+	}
+	for _, item := range value.Items {
+		if err := ctx.encodeValue(w, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeDictionary encodes a dictionary; keys are written as AMF3 values.
+func (ctx *AMF3Context) encodeDictionary(w io.Writer, value *Dictionary, key refKey) error {
+	if ok, err := ctx.encodeObjectReference(w, amf3DictionaryMarker, key); ok || err != nil {
+		return err
+	}
+	if err := writeByte(w, amf3DictionaryMarker); err != nil {
+		return err
+	}
+	if err := ctx.encodeU29(w, uint32(len(value.Entries)<<1)|1); err != nil {
+		return err
+	}
+	weak := byte(0)
+	if value.WeakKeys {
+		weak = 1
+	}
+	if err := writeByte(w, weak); err != nil {
+		return err
+	}
+	for _, e := range value.Entries {
+		if err := ctx.encodeValue(w, e.Key); err != nil {
+			return err
+		}
+		if err := ctx.encodeValue(w, e.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // encodeDate encodes a time.Time value.
 func (ctx *AMF3Context) encodeDate(w io.Writer, value time.Time) error {
+	ctx.encodeObjectReference(w, amf3DateMarker, refKey{}) // 값 타입이므로 인덱스만 증가
 	if err := writeByte(w, amf3DateMarker); err != nil {
 		return err
 	}
-	// For simplicity, does not use object reference table.
 	if err := ctx.encodeU29(w, 1); err != nil { // Inline, not a reference
 		return err
 	}
 	return binary.Write(w, binary.BigEndian, float64(value.UnixMilli()))
 }
 
+// encodeObjectReference writes a U29 object reference if the value was already encoded.
+// 처음 나온 값이면 다음 인덱스를 등록하고 false를 반환 (호출자가 inline으로 씀).
+// 식별자가 없는 값(값 타입, 빈 slice)도 디코더와 인덱스를 맞추기 위해 인덱스는 증가
+func (ctx *AMF3Context) encodeObjectReference(w io.Writer, marker byte, key refKey) (bool, error) {
+	if key.ptr != 0 {
+		if idx, ok := ctx.objectTableMap[key]; ok {
+			if err := writeByte(w, marker); err != nil {
+				return true, err
+			}
+			return true, ctx.encodeU29(w, uint32(idx)<<1)
+		}
+	}
+
+	idx := ctx.objectCount
+	ctx.objectCount++
+	if key.ptr != 0 && idx < 1<<28 { // U29 참조 인덱스 범위
+		ctx.objectTableMap[key] = idx
+	}
+	return false, nil
+}
+
 // encodeValue encodes a single value of any supported type.
 func (ctx *AMF3Context) encodeValue(w io.Writer, value any) error {
 	switch v := value.(type) {
 	case nil:
 		return writeByte(w, amf3NullMarker)
+	case Undefined:
+		return writeByte(w, amf3UndefinedMarker)
 	case bool:
 		if v {
 			return writeByte(w, amf3TrueMarker)
@@ -185,8 +435,99 @@ func (ctx *AMF3Context) encodeValue(w io.Writer, value any) error {
 		return ctx.encodeString(w, v)
 	case map[string]any:
 		return ctx.encodeObject(w, v)
+	case *Object:
+		if v == nil {
+			return writeByte(w, amf3NullMarker)
+		}
+		return ctx.encodeOrderedObject(w, v, refKeyOf(v))
+	case Object:
+		return ctx.encodeOrderedObject(w, &v, refKey{})
+	case *ECMAArray:
+		if v == nil {
+			return writeByte(w, amf3NullMarker)
+		}
+		return ctx.encodeECMAArray(w, v, refKeyOf(v))
+	case ECMAArray:
+		return ctx.encodeECMAArray(w, &v, refKey{})
+	case *TypedObject:
+		if v == nil {
+			return writeByte(w, amf3NullMarker)
+		}
+		return ctx.encodeTypedObject(w, v, refKeyOf(v))
+	case TypedObject:
+		return ctx.encodeTypedObject(w, &v, refKey{})
+	case Externalizable:
+		return ctx.encodeExternalizable(w, v)
 	case []any:
 		return ctx.encodeArray(w, v)
+	case *MixedArray:
+		if v == nil {
+			return writeByte(w, amf3NullMarker)
+		}
+		return ctx.encodeMixedArray(w, v, refKeyOf(v))
+	case MixedArray:
+		return ctx.encodeMixedArray(w, &v, refKey{})
+	case ByteArray:
+		return ctx.encodeBytes(w, amf3ByteArrayMarker, v)
+	case []byte:
+		return ctx.encodeBytes(w, amf3ByteArrayMarker, v)
+	case XML:
+		return ctx.encodeBytes(w, amf3XMLMarker, []byte(v))
+	case XMLDocument:
+		return ctx.encodeBytes(w, amf3XMLDocMarker, []byte(v))
+	case *VectorInt:
+		if v == nil {
+			return writeByte(w, amf3NullMarker)
+		}
+		if ok, err := ctx.encodeVectorHeader(w, amf3VectorIntMarker, refKeyOf(v), len(v.Items), v.Fixed); ok || err != nil {
+			return err
+		}
+		return binary.Write(w, binary.BigEndian, v.Items)
+	case *VectorUint:
+		if v == nil {
+			return writeByte(w, amf3NullMarker)
+		}
+		if ok, err := ctx.encodeVectorHeader(w, amf3VectorUintMarker, refKeyOf(v), len(v.Items), v.Fixed); ok || err != nil {
+			return err
+		}
+		return binary.Write(w, binary.BigEndian, v.Items)
+	case *VectorDouble:
+		if v == nil {
+			return writeByte(w, amf3NullMarker)
+		}
+		if ok, err := ctx.encodeVectorHeader(w, amf3VectorDoubleMarker, refKeyOf(v), len(v.Items), v.Fixed); ok || err != nil {
+			return err
+		}
+		return binary.Write(w, binary.BigEndian, v.Items)
+	case *VectorObject:
+		if v == nil {
+			return writeByte(w, amf3NullMarker)
+		}
+		return ctx.encodeVectorObject(w, v, refKeyOf(v))
+	case VectorInt:
+		if ok, err := ctx.encodeVectorHeader(w, amf3VectorIntMarker, refKey{}, len(v.Items), v.Fixed); ok || err != nil {
+			return err
+		}
+		return binary.Write(w, binary.BigEndian, v.Items)
+	case VectorUint:
+		if ok, err := ctx.encodeVectorHeader(w, amf3VectorUintMarker, refKey{}, len(v.Items), v.Fixed); ok || err != nil {
+			return err
+		}
+		return binary.Write(w, binary.BigEndian, v.Items)
+	case VectorDouble:
+		if ok, err := ctx.encodeVectorHeader(w, amf3VectorDoubleMarker, refKey{}, len(v.Items), v.Fixed); ok || err != nil {
+			return err
+		}
+		return binary.Write(w, binary.BigEndian, v.Items)
+	case VectorObject:
+		return ctx.encodeVectorObject(w, &v, refKey{})
+	case *Dictionary:
+		if v == nil {
+			return writeByte(w, amf3NullMarker)
+		}
+		return ctx.encodeDictionary(w, v, refKeyOf(v))
+	case Dictionary:
+		return ctx.encodeDictionary(w, &v, refKey{})
 	case time.Time:
 		return ctx.encodeDate(w, v)
 	default:
@@ -194,6 +535,19 @@ func (ctx *AMF3Context) encodeValue(w io.Writer, value any) error {
 	}
 }
 
+// EncodeAMF3 encodes a single AMF3 value using the reference tables of ctx.
+func (ctx *AMF3Context) EncodeAMF3(w io.Writer, value any) error {
+	return ctx.encodeValue(w, value)
+}
+
+// EncodeAVMPlus encodes value as an AMF3 value inside an AMF0 stream (AVM+ marker 0x11 + AMF3).
+func (ctx *AMF3Context) EncodeAVMPlus(w io.Writer, value any) error {
+	if err := writeByte(w, avmPlusMarker); err != nil {
+		return err
+	}
+	return ctx.encodeValue(w, value)
+}
+
 // EncodeAMF3Sequence encodes a sequence of values into a byte slice.
 func EncodeAMF3Sequence(values ...any) ([]byte, error) {
 	buf := new(bytes.Buffer)
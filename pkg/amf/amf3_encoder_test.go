@@ -289,9 +289,17 @@ func TestEncodeAMF3_Array(t *testing.T) {
 }
 
 func TestEncodeAMF3_Array_Reference(t *testing.T) {
-	// 현재 구현은 참조를 사용하지 않고 항상 인라인으로 인코딩
-	// 이 테스트는 건너뜀
-	t.Skip("Array references not implemented in current simplified version")
+	arr := []any{"a"}
+	data, err := EncodeAMF3Sequence([]any{arr, arr})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 바깥 배열 #0, arr #1, 참조 1
+	expected := []byte{0x09, 0x05, 0x01, 0x09, 0x03, 0x01, 0x06, 0x03, 'a', 0x09, 0x02}
+	if !bytes.Equal(data, expected) {
+		t.Errorf("expected %x, got %x", expected, data)
+	}
 }
 
 func TestEncodeAMF3_Array_WriteError(t *testing.T) {
@@ -356,9 +364,25 @@ func TestEncodeAMF3_Object(t *testing.T) {
 }
 
 func TestEncodeAMF3_Object_Reference(t *testing.T) {
-	// 현재 구현은 참조를 사용하지 않고 항상 인라인으로 인코딩
-	// 이 테스트는 건너뜀
-	t.Skip("Object references not implemented in current simplified version")
+	obj := map[string]any{}
+	data, err := EncodeAMF3Sequence(time.UnixMilli(0), obj, obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// date #0 (참조하지 않지만 인덱스 차지), obj #1, 참조 1
+	expected := []byte{0x0A, 0x0B, 0x01, 0x01, 0x0A, 0x02}
+	if !bytes.HasSuffix(data, expected) {
+		t.Errorf("expected suffix %x, got %x", expected, data)
+	}
+	values, err := DecodeAMF3Sequence(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	values[1].(map[string]any)["x"] = true
+	if values[2].(map[string]any)["x"] != true {
+		t.Error("object reference not resolved to the same object")
+	}
 }
 
 func TestEncodeAMF3_Object_WriteError(t *testing.T) {
@@ -647,6 +671,36 @@ func TestEncodeAMF3_RoundTrip(t *testing.T) {
 	}
 }
 
+func TestEncodeAMF3_Cycle(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"object", []byte{0x0A, 0x0B, 0x01, 0x09, 's', 'e', 'l', 'f', 0x0A, 0x00, 0x01}},
+		{"typed object", []byte{0x0A, 0x13, 0x09, 'N', 'o', 'd', 'e', 0x09, 'n', 'e', 'x', 't', 0x0A, 0x00}},
+		{"array", []byte{0x09, 0x03, 0x01, 0x09, 0x00}},
+		{"mixed array", []byte{0x09, 0x01, 0x09, 's', 'e', 'l', 'f', 0x09, 0x00, 0x01}},
+		{"vector", []byte{0x10, 0x03, 0x00, 0x03, '*', 0x10, 0x00}},
+		{"dictionary", []byte{0x11, 0x03, 0x00, 0x06, 0x03, 'a', 0x11, 0x00}},
+		{"nested", []byte{0x09, 0x03, 0x01, 0x0A, 0x0B, 0x01, 0x05, 'u', 'p', 0x09, 0x00, 0x01}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := DecodeAMF3Sequence(bytes.NewReader(tt.data))
+			if err != nil {
+				t.Fatalf("decode failed: %v", err)
+			}
+			data, err := EncodeAMF3Sequence(values...)
+			if err != nil {
+				t.Fatalf("encode failed: %v", err)
+			}
+			if !bytes.Equal(data, tt.data) {
+				t.Errorf("expected %x, got %x", tt.data, data)
+			}
+		})
+	}
+}
+
 // 벤치마크 테스트
 func BenchmarkEncodeAMF3_Integer(b *testing.B) {
 	for i := 0; i < b.N; i++ {
@@ -671,4 +725,40 @@ func BenchmarkEncodeAMF3_Object(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		_, _ = EncodeAMF3Sequence(obj)
 	}
-}
\ No newline at end of file
+}
+
+func TestAMF3Context_Reset(t *testing.T) {
+	ctx := NewAMF3Context()
+
+	var first bytes.Buffer
+	if err := ctx.EncodeAMF3(&first, "foo"); err != nil {
+		t.Fatal(err)
+	}
+	var ref bytes.Buffer
+	if err := ctx.EncodeAMF3(&ref, "foo"); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(ref.Bytes(), []byte{0x06, 0x00}) {
+		t.Fatalf("expected string reference, got %x", ref.Bytes())
+	}
+
+	// Reset 후에는 다시 inline으로 인코딩
+	ctx.Reset()
+	var again bytes.Buffer
+	if err := ctx.EncodeAMF3(&again, "foo"); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again.Bytes(), first.Bytes()) {
+		t.Errorf("after Reset got %x, want %x", again.Bytes(), first.Bytes())
+	}
+}
+
+func TestEncodeAVMPlus(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewAMF3Context().EncodeAVMPlus(&buf, 5); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), []byte{0x11, 0x04, 0x05}) {
+		t.Errorf("got %x, want 110405", buf.Bytes())
+	}
+}
@@ -0,0 +1,125 @@
+package amf
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Traits describes the class of an AMF3 object.
+type Traits struct {
+	ClassName      string
+	Sealed         []string // sealed member names, in wire order
+	Dynamic        bool
+	Externalizable bool
+}
+
+// key returns the identity of the traits in the encoder trait table.
+func (t *Traits) key() string {
+	var b strings.Builder
+	b.WriteString(t.ClassName)
+	fmt.Fprintf(&b, "\x00%t\x00%t", t.Dynamic, t.Externalizable)
+	for _, name := range t.Sealed {
+		b.WriteByte(0)
+		b.WriteString(name)
+	}
+	return b.String()
+}
+
+// TypedObject is an AMF3 object with a class name.
+// Sealed와 Dynamic을 보존하므로 디코딩한 객체를 같은 traits로 다시 인코딩 가능.
+// Members는 sealed 멤버와 dynamic 멤버를 모두 포함
+type TypedObject struct {
+	ClassName string
+	Sealed    []string // sealed member names, in wire order
+	Dynamic   bool
+	Members   map[string]any
+}
+
+// NewTypedObject creates a dynamic typed object with no sealed members.
+func NewTypedObject(className string) *TypedObject {
+	return &TypedObject{
+		ClassName: className,
+		Dynamic:   true,
+		Members:   make(map[string]any),
+	}
+}
+
+// traits returns the traits of the object.
+func (o *TypedObject) traits() *Traits {
+	return &Traits{ClassName: o.ClassName, Sealed: o.Sealed, Dynamic: o.Dynamic}
+}
+
+// Externalizable is an AMF3 class that serializes its own body (IExternalizable).
+// 본문 형식은 클래스마다 다르므로 디코딩하려면 RegisterExternalizable로 등록해야 함
+type Externalizable interface {
+	// ClassName returns the AMF3 class name written in the traits.
+	ClassName() string
+	// ReadExternal reads the body; values are decoded with ctx.DecodeAMF3.
+	ReadExternal(ctx *AMF3Context, r io.Reader) error
+	// WriteExternal writes the body; values are encoded with ctx.EncodeAMF3.
+	WriteExternal(ctx *AMF3Context, w io.Writer) error
+}
+
+var (
+	externalizableMu sync.RWMutex
+	externalizables  = map[string]func() Externalizable{
+		arrayCollectionClass: func() Externalizable { return new(ArrayCollection) },
+	}
+)
+
+// RegisterExternalizable registers the constructor of an externalizable class for decoding.
+// 같은 이름으로 다시 등록하면 교체
+func RegisterExternalizable(className string, newFunc func() Externalizable) {
+	externalizableMu.Lock()
+	defer externalizableMu.Unlock()
+	externalizables[className] = newFunc
+}
+
+// newExternalizable creates a registered externalizable value.
+func newExternalizable(className string) (Externalizable, error) {
+	externalizableMu.RLock()
+	newFunc, ok := externalizables[className]
+	externalizableMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unregistered externalizable class: %q", className)
+	}
+	return newFunc(), nil
+}
+
+const arrayCollectionClass = "flex.messaging.io.ArrayCollection"
+
+// ArrayCollection is flex.messaging.io.ArrayCollection, an externalizable wrapper of an array.
+type ArrayCollection []any
+
+// ClassName implements Externalizable.
+func (a *ArrayCollection) ClassName() string {
+	return arrayCollectionClass
+}
+
+// ReadExternal implements Externalizable (본문은 AMF3 배열 하나).
+func (a *ArrayCollection) ReadExternal(ctx *AMF3Context, r io.Reader) error {
+	v, err := ctx.DecodeAMF3(r)
+	if err != nil {
+		return err
+	}
+	switch arr := v.(type) {
+	case []any:
+		*a = arr
+	case nil:
+		*a = nil
+	default:
+		return fmt.Errorf("ArrayCollection source is not an array: %T", v)
+	}
+	return nil
+}
+
+// WriteExternal implements Externalizable.
+func (a *ArrayCollection) WriteExternal(ctx *AMF3Context, w io.Writer) error {
+	arr := []any(*a)
+	if arr == nil {
+		arr = []any{}
+	}
+	return ctx.EncodeAMF3(w, arr)
+}
@@ -0,0 +1,189 @@
+package amf
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTypedObject_RoundTrip(t *testing.T) {
+	obj := &TypedObject{
+		ClassName: "com.example.User",
+		Sealed:    []string{"id", "name"},
+		Dynamic:   true,
+		Members:   map[string]any{"id": int32(7), "name": "kim", "extra": true},
+	}
+
+	encoded, err := EncodeAMF3Sequence(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// inline object + inline traits + dynamic, sealed 2개
+	if encoded[1] != 2<<4|0x0B {
+		t.Errorf("traits flags = 0x%02x, want 0x2b", encoded[1])
+	}
+
+	values, err := DecodeAMF3Sequence(bytes.NewReader(encoded))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok := values[0].(*TypedObject)
+	if !ok {
+		t.Fatalf("expected *TypedObject, got %T", values[0])
+	}
+	if !reflect.DeepEqual(got, obj) {
+		t.Errorf("got %+v, want %+v", got, obj)
+	}
+}
+
+func TestTypedObject_SealedOnly(t *testing.T) {
+	obj := &TypedObject{
+		ClassName: "Point",
+		Sealed:    []string{"x", "y"},
+		Members:   map[string]any{"x": int32(1)}, // y 없음 -> null
+	}
+	encoded, err := EncodeAMF3Sequence(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := DecodeAMF3Sequence(bytes.NewReader(encoded))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := values[0].(*TypedObject)
+	if got.Dynamic || got.Members["x"] != int32(1) || got.Members["y"] != nil {
+		t.Errorf("got %+v", got)
+	}
+	if _, ok := got.Members["y"]; !ok {
+		t.Error("sealed member y missing")
+	}
+}
+
+func TestTraitReference(t *testing.T) {
+	a := &TypedObject{ClassName: "Point", Sealed: []string{"x"}, Members: map[string]any{"x": int32(1)}}
+	b := &TypedObject{ClassName: "Point", Sealed: []string{"x"}, Members: map[string]any{"x": int32(2)}}
+
+	encoded, err := EncodeAMF3Sequence([]any{a, b})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 두 번째 객체는 trait 참조 0 (0x01) 뒤에 바로 sealed 값
+	if !bytes.Contains(encoded, []byte{amf3ObjectMarker, 0x01, amf3IntegerMarker, 0x02}) {
+		t.Errorf("expected trait reference in %x", encoded)
+	}
+
+	values, err := DecodeAMF3Sequence(bytes.NewReader(encoded))
+	if err != nil {
+		t.Fatal(err)
+	}
+	arr := values[0].([]any)
+	if got := arr[1].(*TypedObject); got.ClassName != "Point" || got.Members["x"] != int32(2) {
+		t.Errorf("second object = %+v", got)
+	}
+}
+
+func TestAnonymousObject_TraitReference(t *testing.T) {
+	encoded, err := EncodeAMF3Sequence(map[string]any{"a": 1}, map[string]any{"b": 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, err := DecodeAMF3Sequence(bytes.NewReader(encoded))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m, ok := values[1].(map[string]any); !ok || m["b"] != int32(2) {
+		t.Errorf("second object = %v", values[1])
+	}
+}
+
+func TestDecodeObject_CyclicReference(t *testing.T) {
+	// 자기 자신을 가리키는 dynamic 멤버 (object 참조 0)
+	data := []byte{amf3ObjectMarker, 0x0B, 0x01, 0x09, 's', 'e', 'l', 'f', amf3ObjectMarker, 0x00, 0x01}
+	val, err := NewAMF3Context().DecodeAMF3(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj := val.(map[string]any)
+	self, ok := obj["self"].(map[string]any)
+	if !ok || reflect.ValueOf(self).UnsafePointer() != reflect.ValueOf(obj).UnsafePointer() {
+		t.Error("expected self reference")
+	}
+}
+
+func TestArrayCollection_RoundTrip(t *testing.T) {
+	ac := &ArrayCollection{"a", int32(1)}
+	encoded, err := EncodeAMF3Sequence(ac)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// inline object + inline externalizable traits
+	if encoded[1] != 0x07 {
+		t.Errorf("traits flags = 0x%02x, want 0x07", encoded[1])
+	}
+
+	values, err := DecodeAMF3Sequence(bytes.NewReader(encoded))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok := values[0].(*ArrayCollection)
+	if !ok {
+		t.Fatalf("expected *ArrayCollection, got %T", values[0])
+	}
+	if !reflect.DeepEqual(*got, *ac) {
+		t.Errorf("got %v, want %v", *got, *ac)
+	}
+}
+
+func TestDecodeObject_UnregisteredExternalizable(t *testing.T) {
+	ctx := NewAMF3Context()
+	buf := new(bytes.Buffer)
+	buf.WriteByte(amf3ObjectMarker)
+	buf.WriteByte(0x07)
+	ctx.encodeStringValue(buf, "com.example.Unknown")
+
+	_, err := NewAMF3Context().DecodeAMF3(buf)
+	if err == nil || !strings.Contains(err.Error(), "unregistered externalizable class") {
+		t.Errorf("expected unregistered class error, got %v", err)
+	}
+}
+
+// testExternal writes its body as a single AMF3 string
+type testExternal struct {
+	value string
+}
+
+func (e *testExternal) ClassName() string { return "test.External" }
+
+func (e *testExternal) ReadExternal(ctx *AMF3Context, r io.Reader) error {
+	v, err := ctx.DecodeAMF3(r)
+	if err != nil {
+		return err
+	}
+	e.value, _ = v.(string)
+	return nil
+}
+
+func (e *testExternal) WriteExternal(ctx *AMF3Context, w io.Writer) error {
+	return ctx.EncodeAMF3(w, e.value)
+}
+
+func TestRegisterExternalizable(t *testing.T) {
+	RegisterExternalizable("test.External", func() Externalizable { return new(testExternal) })
+
+	encoded, err := EncodeAMF3Sequence(&testExternal{value: "hi"}, &testExternal{value: "hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, err := DecodeAMF3Sequence(bytes.NewReader(encoded))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range values {
+		if got, ok := v.(*testExternal); !ok || got.value != "hi" {
+			t.Errorf("value %d = %#v", i, v)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package amf
+
+import "reflect"
+
+// ByteArray is an AMF3 ByteArray (0x0C).
+// AMF3 인코딩에서는 []byte도 ByteArray로 쓰임
+type ByteArray []byte
+
+// XML is an AMF3 E4X XML value (0x0B).
+// 레거시 XMLDocument(0x07)는 XMLDocument 타입으로 구분
+type XML string
+
+// VectorInt is an AMF3 Vector.<int> (0x0D).
+type VectorInt struct {
+	Fixed bool // 길이 고정 여부
+	Items []int32
+}
+
+// VectorUint is an AMF3 Vector.<uint> (0x0E).
+type VectorUint struct {
+	Fixed bool
+	Items []uint32
+}
+
+// VectorDouble is an AMF3 Vector.<Number> (0x0F).
+type VectorDouble struct {
+	Fixed bool
+	Items []float64
+}
+
+// VectorObject is an AMF3 Vector of objects (0x10).
+// TypeName은 요소의 클래스 이름 (Vector.<*>이면 "*", 비어 있으면 "*"로 인코딩)
+type VectorObject struct {
+	Fixed    bool
+	TypeName string
+	Items    []any
+}
+
+// Dictionary is an AMF3 Dictionary (0x11) whose keys may be any AMF value.
+// 키가 map, 객체 등 비교 불가능한 값일 수 있으므로 Entries 순서대로 보관
+type Dictionary struct {
+	WeakKeys bool
+	Entries  []DictionaryEntry
+}
+
+// DictionaryEntry is a key/value pair of a Dictionary.
+type DictionaryEntry struct {
+	Key   any
+	Value any
+}
+
+// Get returns the value of the last entry whose key equals key.
+// 비교 불가능한 키(map, slice 등)는 일치하지 않는 것으로 취급
+func (d *Dictionary) Get(key any) (any, bool) {
+	for i := len(d.Entries) - 1; i >= 0; i-- {
+		if equalKey(d.Entries[i].Key, key) {
+			return d.Entries[i].Value, true
+		}
+	}
+	return nil, false
+}
+
+// Set replaces the value of key, or appends a new entry.
+func (d *Dictionary) Set(key, value any) {
+	for i := len(d.Entries) - 1; i >= 0; i-- {
+		if equalKey(d.Entries[i].Key, key) {
+			d.Entries[i].Value = value
+			return
+		}
+	}
+	d.Entries = append(d.Entries, DictionaryEntry{Key: key, Value: value})
+}
+
+// equalKey compares two keys without panicking on uncomparable values
+func equalKey(a, b any) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if reflect.TypeOf(a) != reflect.TypeOf(b) || !reflect.ValueOf(a).Comparable() {
+		return false
+	}
+	return a == b
+}
+
+// MixedArray is an AMF3 array with an associative part as well as a dense part.
+// 연관 부분은 내장된 Object에 wire 순서로 보관. 연관 부분이 없는 배열은 []any로 디코딩됨
+type MixedArray struct {
+	Object
+	Dense []any
+}
@@ -0,0 +1,171 @@
+package amf
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestAMF3Types_RoundTrip(t *testing.T) {
+	key := map[string]any{"id": int32(1)}
+	values := []any{
+		ByteArray{0x00, 0x01, 0xFF},
+		XML("<a><b/></a>"),
+		XMLDocument("<doc/>"),
+		&VectorInt{Fixed: true, Items: []int32{-1, 0, 1 << 30}},
+		&VectorUint{Items: []uint32{0, 1 << 31}},
+		&VectorDouble{Fixed: true, Items: []float64{1.5, -2}},
+		&VectorObject{TypeName: "com.example.Item", Items: []any{"a", int32(2), nil}},
+		&VectorInt{Items: []int32{}},
+		&Dictionary{WeakKeys: true, Entries: []DictionaryEntry{
+			{Key: int32(1), Value: "int key"},
+			{Key: key, Value: "object key"},
+			{Key: "s", Value: true},
+		}},
+		&MixedArray{Object: Object{Properties: []Property{{"b", "x"}, {"a", int32(7)}}}, Dense: []any{"first", 2.5}},
+	}
+
+	data, err := EncodeAMF3Sequence(values...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := DecodeAMF3Sequence(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(values) {
+		t.Fatalf("got %d values, want %d", len(got), len(values))
+	}
+	for i := range values {
+		if !reflect.DeepEqual(got[i], values[i]) {
+			t.Errorf("value %d: got %#v, want %#v", i, got[i], values[i])
+		}
+	}
+
+	// 디코딩한 값을 다시 인코딩하면 같은 바이트
+	again, err := EncodeAMF3Sequence(got...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again, data) {
+		t.Errorf("re-encoded bytes differ")
+	}
+}
+
+func TestAMF3Types_VectorObjectDefaultTypeName(t *testing.T) {
+	data, err := EncodeAMF3Sequence(&VectorObject{Items: []any{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{amf3VectorObjectMarker, 0x01, 0x00, 0x03, '*'}
+	if !bytes.Equal(data, want) {
+		t.Errorf("got %v, want %v", data, want)
+	}
+}
+
+func TestAMF3Types_References(t *testing.T) {
+	data := []byte{
+		amf3ArrayMarker, 0x05, 0x01, // dense 2, 연관 부분 없음
+		amf3ByteArrayMarker, 0x03, 0xAB, // object #1
+		amf3ByteArrayMarker, 0x02, // 참조 #1
+		amf3VectorIntMarker, 0x00, // 참조 #0 (배열이므로 타입 불일치)
+	}
+
+	ctx := NewAMF3Context()
+	val, err := ctx.DecodeAMF3(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	arr := val.([]any)
+	if !reflect.DeepEqual(arr[1], ByteArray{0xAB}) {
+		t.Errorf("got %v, want referenced byte array", arr[1])
+	}
+
+	if _, err := ctx.DecodeAMF3(bytes.NewReader(data[len(data)-2:])); err == nil {
+		t.Error("expected error for vector reference to an array")
+	}
+}
+
+func TestAMF3Types_MixedArrayReference(t *testing.T) {
+	data := []byte{
+		amf3ArrayMarker, 0x03, // dense 1, inline
+		0x03, 'k', amf3ArrayMarker, 0x00, // "k" -> 자기 자신 참조
+		0x01,
+		amf3TrueMarker,
+	}
+
+	val, err := NewAMF3Context().DecodeAMF3(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mixed, ok := val.(*MixedArray)
+	if !ok {
+		t.Fatalf("got %T, want *MixedArray", val)
+	}
+	if self, _ := mixed.Get("k"); self != mixed {
+		t.Errorf("k = %v, want self reference", self)
+	}
+	if !reflect.DeepEqual(mixed.Dense, []any{true}) {
+		t.Errorf("dense = %v", mixed.Dense)
+	}
+}
+
+func TestDictionary_GetSet(t *testing.T) {
+	obj := map[string]any{}
+	d := &Dictionary{}
+	d.Set(int32(1), "a")
+	d.Set(obj, "b")
+	d.Set(int32(1), "c")
+
+	if v, ok := d.Get(int32(1)); !ok || v != "c" || len(d.Entries) != 2 {
+		t.Errorf("Get(1) = %v, %v; entries %v", v, ok, d.Entries)
+	}
+	if _, ok := d.Get(1.0); ok {
+		t.Error("keys of different types should not match")
+	}
+	if _, ok := d.Get(map[string]any{}); ok {
+		t.Error("uncomparable keys should not match")
+	}
+}
+
+func TestEncodeAMF0_MixedArray(t *testing.T) {
+	mixed := &MixedArray{Object: Object{Properties: []Property{{"k", "v"}}}, Dense: []any{1.0}}
+	data, err := EncodeAMF0Sequence(mixed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDecoder(bytes.NewReader(data))
+	d.UseOrderedObjects()
+	val, err := d.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := NewECMAArray(Property{"0", 1.0}, Property{"k", "v"})
+	want.Count = 2
+	if !reflect.DeepEqual(val, want) {
+		t.Errorf("got %#v, want %#v", val, want)
+	}
+}
+
+func TestUnmarshalValue_AMF3Types(t *testing.T) {
+	var s struct {
+		Data  []byte    `amf:"data"`
+		Ints  []int     `amf:"ints"`
+		Names []string  `amf:"names"`
+		Rates []float64 `amf:"rates"`
+	}
+	obj := map[string]any{
+		"data":  ByteArray{1, 2},
+		"ints":  &VectorInt{Items: []int32{3, 4}},
+		"names": &MixedArray{Dense: []any{"a"}},
+		"rates": &VectorDouble{Items: []float64{0.5}},
+	}
+	if err := UnmarshalValue(obj, &s); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(s.Data, []byte{1, 2}) || !reflect.DeepEqual(s.Ints, []int{3, 4}) ||
+		!reflect.DeepEqual(s.Names, []string{"a"}) || !reflect.DeepEqual(s.Rates, []float64{0.5}) {
+		t.Errorf("got %+v", s)
+	}
+}
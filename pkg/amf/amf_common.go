@@ -2,6 +2,7 @@ package amf
 
 import (
 	"io"
+	"slices"
 )
 
 // AMF0 Type Markers
@@ -25,21 +26,33 @@ const (
 	avmPlusMarker     = 0x11 // AMF3
 )
 
+// Undefined is the AMF undefined value, distinct from nil (null).
+// Decoder는 undefined를 Undefined{}로 반환하지만 DecodeAMF0 등 함수형 API는 호환을 위해 nil로 반환
+type Undefined struct{}
+
+// XMLDocument is an AMF0 XML document (0x0F) or an AMF3 legacy XMLDocument (0x07).
+type XMLDocument string
+
 // AMF3 Type Markers
 const (
-	amf3UndefinedMarker = 0x00
-	amf3NullMarker      = 0x01
-	amf3FalseMarker     = 0x02
-	amf3TrueMarker      = 0x03
-	amf3IntegerMarker   = 0x04
-	amf3DoubleMarker    = 0x05
-	amf3StringMarker    = 0x06
-	amf3XMLDocMarker    = 0x07
-	amf3DateMarker      = 0x08
-	amf3ArrayMarker     = 0x09
-	amf3ObjectMarker    = 0x0A
-	amf3XMLMarker       = 0x0B
-	amf3ByteArrayMarker = 0x0C
+	amf3UndefinedMarker    = 0x00
+	amf3NullMarker         = 0x01
+	amf3FalseMarker        = 0x02
+	amf3TrueMarker         = 0x03
+	amf3IntegerMarker      = 0x04
+	amf3DoubleMarker       = 0x05
+	amf3StringMarker       = 0x06
+	amf3XMLDocMarker       = 0x07
+	amf3DateMarker         = 0x08
+	amf3ArrayMarker        = 0x09
+	amf3ObjectMarker       = 0x0A
+	amf3XMLMarker          = 0x0B
+	amf3ByteArrayMarker    = 0x0C
+	amf3VectorIntMarker    = 0x0D
+	amf3VectorUintMarker   = 0x0E
+	amf3VectorDoubleMarker = 0x0F
+	amf3VectorObjectMarker = 0x10
+	amf3DictionaryMarker   = 0x11
 )
 
 // AMF3Context holds the state for a single AMF3 encoding or decoding session,
@@ -47,8 +60,15 @@ const (
 type AMF3Context struct {
 	stringTable    []string
 	objectTable    []any
-	traitTable     []any // Traits are not fully supported in this simplified version
+	traitTable     []*Traits
 	stringTableMap map[string]int
+	traitTableMap  map[string]int // 인코딩 시 Traits.key -> traitTable 인덱스
+	objectTableMap map[refKey]int // 인코딩 시 값 식별자 -> 객체 참조 인덱스
+	objectCount    int            // 인코딩 시 지금까지 쓴 complex 값 수 (디코더의 objectTable과 같은 순서)
+
+	limits Limits // 디코딩 한도
+	depth  int    // 현재 중첩 깊이
+	read   int64  // Reset 이후 읽은 바이트 수 (MaxBytes가 있을 때만)
 }
 
 // NewAMF3Context creates and initializes a new AMF3Context.
@@ -56,11 +76,35 @@ func NewAMF3Context() *AMF3Context {
 	return &AMF3Context{
 		stringTable:    make([]string, 0),
 		objectTable:    make([]any, 0),
-		traitTable:     make([]any, 0),
+		traitTable:     make([]*Traits, 0),
 		stringTableMap: make(map[string]int),
+		traitTableMap:  make(map[string]int),
+		objectTableMap: make(map[refKey]int),
+		limits:         DefaultLimits,
 	}
 }
 
+// SetLimits sets the decoding limits of the context.
+func (ctx *AMF3Context) SetLimits(limits Limits) {
+	ctx.limits = limits
+}
+
+// Reset clears the reference tables so the context can be reused.
+// RTMP는 메시지마다 참조 테이블을 새로 시작하므로 메시지 경계에서 호출
+func (ctx *AMF3Context) Reset() {
+	clear(ctx.objectTable)
+	clear(ctx.traitTable)
+	clear(ctx.stringTableMap)
+	clear(ctx.traitTableMap)
+	clear(ctx.objectTableMap)
+	ctx.stringTable = ctx.stringTable[:0]
+	ctx.objectTable = ctx.objectTable[:0]
+	ctx.traitTable = ctx.traitTable[:0]
+	ctx.objectCount = 0
+	ctx.depth = 0
+	ctx.read = 0
+}
+
 // readByte reads a single byte from the reader.
 func readByte(r io.Reader) (byte, error) {
 	buf := make([]byte, 1)
@@ -68,11 +112,32 @@ func readByte(r io.Reader) (byte, error) {
 	return buf[0], err
 }
 
-// readBytes reads n bytes from the reader.
+// readChunkSize is the largest buffer readBytes allocates before the data arrives
+const readChunkSize = 64 << 10
+
+// readBytes reads n bytes.
+// 큰 길이는 선언된 크기를 한 번에 할당하지 않고 실제로 읽은 만큼만 버퍼를 늘림
 func readBytes(r io.Reader, n int) ([]byte, error) {
-	buf := make([]byte, n)
-	_, err := io.ReadFull(r, buf)
-	return buf, err
+	if n <= readChunkSize {
+		buf := make([]byte, n)
+		_, err := io.ReadFull(r, buf)
+		return buf, err
+	}
+
+	buf := make([]byte, 0, readChunkSize)
+	for len(buf) < n {
+		chunk := min(n-len(buf), readChunkSize)
+		buf = slices.Grow(buf, chunk)
+		m, err := io.ReadFull(r, buf[len(buf):len(buf)+chunk])
+		buf = buf[:len(buf)+m]
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF // 일부는 이미 읽음
+			}
+			return buf, err
+		}
+	}
+	return buf, nil
 }
 
 // writeByte writes a single byte to the writer.
@@ -0,0 +1,121 @@
+package amf
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Limits bounds what a decoder accepts from untrusted input.
+// 0인 항목은 제한 없음. 한도를 넘으면 *LimitError를 반환
+type Limits struct {
+	MaxStringLength     int   // string, long string, XML, ByteArray의 최대 바이트 수
+	MaxCollectionLength int   // array, vector, dictionary의 최대 요소 수와 object의 최대 멤버 수
+	MaxDepth            int   // object/array 최대 중첩 깊이 (최상위 object가 1)
+	MaxBytes            int64 // Decoder 또는 AMF3Context가 읽을 수 있는 최대 바이트 수 (Reset마다 초기화)
+}
+
+// DefaultLimits are used by NewDecoder, NewAMF3Context and the package-level decode functions.
+// 문자열과 입력 크기는 RTMP 메시지 최대 크기(16 MiB)까지 허용
+// (하나의 Decoder로 여러 메시지를 읽으면 메시지마다 Reset)
+var DefaultLimits = Limits{
+	MaxStringLength:     16 << 20,
+	MaxCollectionLength: 1 << 20,
+	MaxDepth:            256,
+	MaxBytes:            16 << 20,
+}
+
+// maxPrealloc is the largest element count allocated before the elements are read.
+// 선언된 요소 수는 실제 입력 크기와 무관하므로 더 큰 collection은 읽은 만큼 늘림
+const maxPrealloc = 1024
+
+// newItems allocates the elements of a collection whose count was read from the wire
+func newItems[T any](count int) []T {
+	return make([]T, min(count, maxPrealloc))
+}
+
+// setItem stores v at index i of items allocated by newItems, growing items past the preallocated length
+func setItem[T any](items []T, i int, v T) []T {
+	if i < len(items) {
+		items[i] = v
+		return items
+	}
+	return append(items, v)
+}
+
+// Limit errors (LimitError.Err)
+var (
+	ErrStringTooLong     = errors.New("amf: string too long")
+	ErrCollectionTooLong = errors.New("amf: collection too long")
+	ErrTooDeep           = errors.New("amf: nesting too deep")
+	ErrInputTooLarge     = errors.New("amf: input too large")
+)
+
+// LimitError reports input that exceeds one of the decoder Limits.
+// errors.Is로 ErrStringTooLong 등과 비교 가능
+type LimitError struct {
+	Err   error // ErrStringTooLong, ErrCollectionTooLong, ErrTooDeep 또는 ErrInputTooLarge
+	Value int64 // 입력이 요구한 길이, 요소 수, 깊이 또는 바이트 수
+	Max   int64 // 설정된 한도
+}
+
+// Error implements the error interface.
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%v: %d (max %d)", e.Err, e.Value, e.Max)
+}
+
+// Unwrap returns the sentinel error of the exceeded limit.
+func (e *LimitError) Unwrap() error {
+	return e.Err
+}
+
+// checkString checks a string or byte length read from the wire
+func (l *Limits) checkString(n int) error {
+	if l.MaxStringLength > 0 && n > l.MaxStringLength {
+		return &LimitError{Err: ErrStringTooLong, Value: int64(n), Max: int64(l.MaxStringLength)}
+	}
+	return nil
+}
+
+// checkCollection checks an element or member count read from the wire
+func (l *Limits) checkCollection(n int) error {
+	if l.MaxCollectionLength > 0 && n > l.MaxCollectionLength {
+		return &LimitError{Err: ErrCollectionTooLong, Value: int64(n), Max: int64(l.MaxCollectionLength)}
+	}
+	return nil
+}
+
+// checkDepth checks the nesting depth after entering a complex value
+func (l *Limits) checkDepth(depth int) error {
+	if l.MaxDepth > 0 && depth > l.MaxDepth {
+		return &LimitError{Err: ErrTooDeep, Value: int64(depth), Max: int64(l.MaxDepth)}
+	}
+	return nil
+}
+
+// limitReader counts the bytes read and fails once more than max are requested
+type limitReader struct {
+	r   io.Reader
+	n   *int64 // 읽은 바이트 수 (Decoder/AMF3Context의 카운터)
+	max int64
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if *l.n >= l.max {
+		// 한도에 정확히 맞게 끝난 입력은 정상 종료로 처리
+		var probe [1]byte
+		if n, err := l.r.Read(probe[:]); n == 0 {
+			return 0, err
+		}
+		return 0, &LimitError{Err: ErrInputTooLarge, Value: *l.n + 1, Max: l.max}
+	}
+	if remaining := l.max - *l.n; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	n, err := l.r.Read(p)
+	*l.n += int64(n)
+	return n, err
+}
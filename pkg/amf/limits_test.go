@@ -0,0 +1,225 @@
+package amf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"runtime"
+	"testing"
+)
+
+func TestDecoder_Limits(t *testing.T) {
+	longString := append([]byte{0x0C, 0x00, 0x00, 0x00, 0x05}, "hello"...)
+	strictArray := []byte{0x0A, 0x00, 0x00, 0x00, 0x03, 0x05, 0x05, 0x05}
+	object := []byte{0x03, 0x00, 0x01, 'a', 0x05, 0x00, 0x01, 'b', 0x05, 0x00, 0x00, 0x09}
+	nested := []byte{0x0A, 0x00, 0x00, 0x00, 0x01, 0x0A, 0x00, 0x00, 0x00, 0x01, 0x05}
+	avmPlus := []byte{0x0A, 0x00, 0x00, 0x00, 0x01, 0x11, 0x09, 0x03, 0x01, 0x01} // AMF0 array > AMF3 array
+
+	tests := []struct {
+		name   string
+		data   []byte
+		limits Limits
+		want   error
+	}{
+		{"string", longString, Limits{MaxStringLength: 4}, ErrStringTooLong},
+		{"strict array", strictArray, Limits{MaxCollectionLength: 2}, ErrCollectionTooLong},
+		{"object members", object, Limits{MaxCollectionLength: 1}, ErrCollectionTooLong},
+		{"depth", nested, Limits{MaxDepth: 1}, ErrTooDeep},
+		{"depth across AVM+", avmPlus, Limits{MaxDepth: 1}, ErrTooDeep},
+		{"bytes", object, Limits{MaxBytes: 8}, ErrInputTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDecoder(bytes.NewReader(tt.data))
+			d.SetLimits(tt.limits)
+			_, err := d.Decode()
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+			var limitErr *LimitError
+			if !errors.As(err, &limitErr) || limitErr.Value <= limitErr.Max {
+				t.Errorf("got %#v, want *LimitError over the limit", err)
+			}
+
+			// 한도가 없으면 디코딩 성공
+			d = NewDecoder(bytes.NewReader(tt.data))
+			d.SetLimits(Limits{})
+			if _, err := d.Decode(); err != nil {
+				t.Errorf("unlimited: %v", err)
+			}
+		})
+	}
+}
+
+func TestDecoder_MaxBytesExact(t *testing.T) {
+	data := []byte{0x05, 0x01, 0x01}
+	d := NewDecoder(bytes.NewReader(data))
+	d.SetLimits(Limits{MaxBytes: int64(len(data))})
+	for range 2 {
+		if _, err := d.Decode(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 한도에 정확히 맞게 끝나면 io.EOF
+	if _, err := d.Decode(); err != io.EOF {
+		t.Errorf("got %v, want io.EOF", err)
+	}
+
+	// Reset하면 다시 계산
+	d.Reset(bytes.NewReader(append(data, 0x05)))
+	for range 2 {
+		if _, err := d.Decode(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := d.Decode(); !errors.Is(err, ErrInputTooLarge) {
+		t.Errorf("got %v, want ErrInputTooLarge", err)
+	}
+}
+
+func TestAMF3Context_Limits(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []byte
+		limits Limits
+		want   error
+	}{
+		{"string", []byte{amf3StringMarker, 0x0B, 'h', 'e', 'l', 'l', 'o'}, Limits{MaxStringLength: 4}, ErrStringTooLong},
+		{"byte array", []byte{amf3ByteArrayMarker, 0x05, 1, 2}, Limits{MaxStringLength: 1}, ErrStringTooLong},
+		{"dense array", []byte{amf3ArrayMarker, 0x05, 0x01, 0x01, 0x01}, Limits{MaxCollectionLength: 1}, ErrCollectionTooLong},
+		{"associative array", []byte{amf3ArrayMarker, 0x01, 0x03, 'a', 0x01, 0x03, 'b', 0x01, 0x01}, Limits{MaxCollectionLength: 1}, ErrCollectionTooLong},
+		{"dynamic members", []byte{amf3ObjectMarker, 0x0B, 0x01, 0x03, 'a', 0x01, 0x03, 'b', 0x01, 0x01}, Limits{MaxCollectionLength: 1}, ErrCollectionTooLong},
+		{"vector", []byte{amf3VectorDoubleMarker, 0x05, 0x00}, Limits{MaxCollectionLength: 1}, ErrCollectionTooLong},
+		{"dictionary", []byte{amf3DictionaryMarker, 0x05, 0x00}, Limits{MaxCollectionLength: 1}, ErrCollectionTooLong},
+		{"depth", []byte{amf3ArrayMarker, 0x03, 0x01, amf3ArrayMarker, 0x01, 0x01}, Limits{MaxDepth: 1}, ErrTooDeep},
+		{"bytes", []byte{amf3StringMarker, 0x0B, 'h', 'e', 'l', 'l', 'o'}, Limits{MaxBytes: 4}, ErrInputTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := NewAMF3Context()
+			ctx.SetLimits(tt.limits)
+			if _, err := ctx.DecodeAMF3(bytes.NewReader(tt.data)); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeAMF0_DefaultDepthLimit(t *testing.T) {
+	// 한도가 없으면 스택을 모두 소모할 깊이
+	depth := DefaultLimits.MaxDepth + 1
+	data := bytes.Repeat([]byte{0x0A, 0x00, 0x00, 0x00, 0x01}, depth)
+	data = append(data, 0x05)
+
+	if _, err := DecodeAMF0(bytes.NewReader(data)); !errors.Is(err, ErrTooDeep) {
+		t.Errorf("got %v, want ErrTooDeep", err)
+	}
+	if _, err := DecodeAMF0(bytes.NewReader(data[5:])); err != nil {
+		t.Errorf("at the limit: %v", err)
+	}
+}
+
+func TestDecode_LargeDeclaredLength(t *testing.T) {
+	// 4 GiB를 선언하지만 실제 데이터는 짧음: 할당 없이 EOF로 실패해야 함
+	data := []byte{0x0C, 0xFF, 0xFF, 0xFF, 0xFF, 'x'}
+	d := NewDecoder(bytes.NewReader(data))
+	d.SetLimits(Limits{})
+	if _, err := d.Decode(); err != io.ErrUnexpectedEOF {
+		t.Errorf("got %v, want io.ErrUnexpectedEOF", err)
+	}
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	readBytes(bytes.NewReader([]byte("short")), 1<<30)
+	runtime.ReadMemStats(&after)
+	if n := after.TotalAlloc - before.TotalAlloc; n > 1<<20 {
+		t.Errorf("readBytes allocated %d bytes for a short input", n)
+	}
+}
+
+func TestReadBytes_Large(t *testing.T) {
+	data := bytes.Repeat([]byte{0xAB}, 3*readChunkSize+7)
+	got, err := readBytes(bytes.NewReader(data), len(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Error("data mismatch")
+	}
+}
+
+func TestDecode_PreallocBounded(t *testing.T) {
+	u29 := func(v uint32) []byte {
+		var b bytes.Buffer
+		if err := NewAMF3Context().encodeU29(&b, v); err != nil {
+			t.Fatal(err)
+		}
+		return b.Bytes()
+	}
+	count := uint32(DefaultLimits.MaxCollectionLength) // 한도 안에서 가장 큰 선언
+
+	// 작은 입력이 중첩된 collection마다 최대 요소 수를 선언
+	tests := []struct {
+		name string
+		data []byte
+		amf3 bool
+	}{
+		{"AMF0 strict array", bytes.Repeat([]byte{0x0A, 0x00, 0x10, 0x00, 0x00}, 40), false},
+		{"AMF3 array", bytes.Repeat(append(append([]byte{amf3ArrayMarker}, u29(count<<1|1)...), 0x01), 40), true},
+		{"AMF3 mixed array", bytes.Repeat(append(append([]byte{amf3ArrayMarker}, u29(count<<1|1)...), 0x03, 'a'), 40), true},
+		{"AMF3 object vector", bytes.Repeat(append(append([]byte{amf3VectorObjectMarker}, u29(count<<1|1)...), 0x00, 0x01), 40), true},
+		{"AMF3 dictionary", bytes.Repeat(append(append([]byte{amf3DictionaryMarker}, u29(count<<1|1)...), 0x00), 40), true},
+		{"AMF3 double vector", append(append([]byte{amf3VectorDoubleMarker}, u29(count<<1|1)...), 0x00), true},
+		{"AMF3 sealed traits", append(append([]byte{amf3ObjectMarker}, u29(count<<4|0x03)...), 0x01), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var before, after runtime.MemStats
+			runtime.ReadMemStats(&before)
+			var err error
+			if tt.amf3 {
+				_, err = NewAMF3Context().DecodeAMF3(bytes.NewReader(tt.data))
+			} else {
+				_, err = DecodeAMF0(bytes.NewReader(tt.data))
+			}
+			runtime.ReadMemStats(&after)
+
+			if err == nil {
+				t.Error("expected an error for truncated input")
+			}
+			if n := after.TotalAlloc - before.TotalAlloc; n > 4<<20 {
+				t.Errorf("allocated %d bytes for %d bytes of input", n, len(tt.data))
+			}
+		})
+	}
+}
+
+func TestDecode_LargeCollection(t *testing.T) {
+	n := 3*maxPrealloc + 1
+	data := binary.BigEndian.AppendUint32([]byte{0x0A}, uint32(n))
+	data = append(data, bytes.Repeat([]byte{0x05}, n)...)
+	arr, err := DecodeAMF0(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(arr.([]any)) != n {
+		t.Errorf("strict array: got %d elements, want %d", len(arr.([]any)), n)
+	}
+
+	var b bytes.Buffer
+	items := make([]float64, n)
+	for i := range items {
+		items[i] = float64(i)
+	}
+	if err := NewAMF3Context().EncodeAMF3(&b, &VectorDouble{Items: items}); err != nil {
+		t.Fatal(err)
+	}
+	v, err := NewAMF3Context().DecodeAMF3(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := v.(*VectorDouble).Items; len(got) != n || got[n-1] != float64(n-1) {
+		t.Errorf("double vector: got %d items", len(got))
+	}
+}
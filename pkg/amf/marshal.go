@@ -0,0 +1,638 @@
+package amf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Marshaler is implemented by types that convert themselves to an AMF value
+// (nil, bool, 숫자, string, time.Time, []any, map[string]any 등 인코더가 지원하는 값).
+type Marshaler interface {
+	MarshalAMF() (any, error)
+}
+
+// Unmarshaler is implemented by types that assign themselves from a decoded AMF value.
+type Unmarshaler interface {
+	UnmarshalAMF(value any) error
+}
+
+// UnmarshalTypeError describes a decoded value that cannot be assigned to a Go type.
+type UnmarshalTypeError struct {
+	Value string       // 디코딩된 값의 종류 (예: "string", "number 1.5")
+	Type  reflect.Type // 대상 Go 타입
+	Field string       // 구조체 필드 경로 (예: "Video.Width"), 최상위면 빈 문자열
+}
+
+// Error implements the error interface.
+func (e *UnmarshalTypeError) Error() string {
+	if e.Field != "" {
+		return fmt.Sprintf("amf: cannot unmarshal %s into field %s of type %s", e.Value, e.Field, e.Type)
+	}
+	return fmt.Sprintf("amf: cannot unmarshal %s into Go value of type %s", e.Value, e.Type)
+}
+
+// ErrCycle is returned when a value refers back to itself (디코더는 자기 참조를 허용하므로
+// 상대가 보낸 값에도 나타날 수 있음)
+var ErrCycle = errors.New("amf: value contains a cycle")
+
+var (
+	marshalerType   = reflect.TypeFor[Marshaler]()
+	unmarshalerType = reflect.TypeFor[Unmarshaler]()
+	timeType        = reflect.TypeFor[time.Time]()
+)
+
+// Marshal returns the AMF0 encoding of v.
+// 구조체는 `amf:"name,omitempty"` 태그로 이름을 정하는 익명 객체로, 정수는 number로 인코딩.
+// 태그가 "-"인 필드와 unexported 필드는 제외
+func Marshal(v any) ([]byte, error) {
+	value, err := MarshalValue(v)
+	if err != nil {
+		return nil, err
+	}
+	return EncodeAMF0Sequence(value)
+}
+
+// MarshalAMF3 returns the AMF3 encoding of v (Marshal과 같은 규칙, 29비트에 들어가는 정수는 integer).
+func MarshalAMF3(v any) ([]byte, error) {
+	value, err := MarshalValue(v)
+	if err != nil {
+		return nil, err
+	}
+	return EncodeAMF3Sequence(value)
+}
+
+// Unmarshal decodes the first AMF0 value of data into the value pointed to by v.
+func Unmarshal(data []byte, v any) error {
+	value, err := DecodeAMF0(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	return UnmarshalValue(value, v)
+}
+
+// UnmarshalAMF3 decodes the first AMF3 value of data into the value pointed to by v.
+func UnmarshalAMF3(data []byte, v any) error {
+	value, err := NewAMF3Context().DecodeAMF3(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	return UnmarshalValue(value, v)
+}
+
+// MarshalValue converts v to the generic values accepted by the AMF0/AMF3 encoders.
+// 자기 자신을 참조하는 포인터, map, slice는 ErrCycle
+func MarshalValue(v any) (any, error) {
+	m := &marshaler{}
+	return m.marshalValue(reflect.ValueOf(v))
+}
+
+// UnmarshalValue assigns a decoded AMF value (DecodeAMF0 등의 결과) to the value pointed to by v.
+// 숫자는 대상 정수/실수 타입으로 변환하며 (소수점이나 범위 초과는 에러), 구조체 필드는 태그 이름,
+// 없으면 대소문자 무시로 매칭. 타입이 맞지 않는 값은 건너뛰고 첫 *UnmarshalTypeError를 반환.
+// 자기 참조하는 값은 interface 대상에만 대입 가능하며, 그 밖의 대상은 ErrCycle
+func UnmarshalValue(value any, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("amf: Unmarshal requires a non-nil pointer, got %T", v)
+	}
+	u := &unmarshaler{}
+	u.assign(value, rv.Elem(), "")
+	return u.err
+}
+
+// marshaler converts Go values and tracks the pointers, maps and slices being converted.
+type marshaler struct {
+	visiting map[refKey]struct{}
+}
+
+// enter marks rv as being converted; 이미 변환 중이면 (순환) ErrCycle
+func (m *marshaler) enter(rv reflect.Value) (refKey, error) {
+	key := refKeyOf(rv.Interface())
+	if key == (refKey{}) {
+		return key, nil
+	}
+	if _, ok := m.visiting[key]; ok {
+		return key, fmt.Errorf("%w via %s", ErrCycle, rv.Type())
+	}
+	if m.visiting == nil {
+		m.visiting = make(map[refKey]struct{})
+	}
+	m.visiting[key] = struct{}{}
+	return key, nil
+}
+
+// leave unmarks a value marked by enter
+func (m *marshaler) leave(key refKey) {
+	delete(m.visiting, key)
+}
+
+// marshalValue converts a Go value to an encoder value.
+func (m *marshaler) marshalValue(rv reflect.Value) (any, error) {
+	if !rv.IsValid() {
+		return nil, nil
+	}
+	if !rv.CanInterface() {
+		return nil, fmt.Errorf("amf: cannot marshal unexported value of type %s", rv.Type())
+	}
+	if rv.Type().Implements(marshalerType) {
+		if rv.Kind() == reflect.Pointer && rv.IsNil() {
+			return nil, nil
+		}
+		return rv.Interface().(Marshaler).MarshalAMF()
+	}
+	if rv.Kind() != reflect.Pointer && rv.CanAddr() && rv.Addr().Type().Implements(marshalerType) {
+		return rv.Addr().Interface().(Marshaler).MarshalAMF()
+	}
+
+	// 인코더가 직접 다루는 AMF 타입은 그대로 전달
+	switch v := rv.Interface().(type) {
+	case *TypedObject, TypedObject, Externalizable, time.Time, Undefined, XMLDocument,
+		*Object, Object, *ECMAArray, ECMAArray, *MixedArray, MixedArray, ByteArray, XML,
+		*Dictionary, Dictionary, *VectorInt, VectorInt, *VectorUint, VectorUint,
+		*VectorDouble, VectorDouble, *VectorObject, VectorObject:
+		return v, nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		key, err := m.enter(rv)
+		if err != nil {
+			return nil, err
+		}
+		defer m.leave(key)
+	}
+
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		return m.marshalValue(rv.Elem())
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n := rv.Int(); n >= math.MinInt32 && n <= math.MaxInt32 {
+			return int32(n), nil
+		}
+		return float64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if n := rv.Uint(); n <= math.MaxInt32 {
+			return int32(n), nil
+		}
+		return float64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Slice, reflect.Array:
+		return m.marshalArray(rv)
+	case reflect.Map:
+		return m.marshalMap(rv)
+	case reflect.Struct:
+		return m.marshalStruct(rv)
+	}
+	return nil, fmt.Errorf("amf: unsupported type: %s", rv.Type())
+}
+
+// marshalArray converts a slice or array to []any.
+func (m *marshaler) marshalArray(rv reflect.Value) ([]any, error) {
+	arr := make([]any, rv.Len())
+	for i := range arr {
+		v, err := m.marshalValue(rv.Index(i))
+		if err != nil {
+			return nil, err
+		}
+		arr[i] = v
+	}
+	return arr, nil
+}
+
+// marshalMap converts a map with string keys to map[string]any.
+func (m *marshaler) marshalMap(rv reflect.Value) (map[string]any, error) {
+	if rv.Type().Key().Kind() != reflect.String {
+		return nil, fmt.Errorf("amf: unsupported map key type: %s", rv.Type().Key())
+	}
+	obj := make(map[string]any, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		v, err := m.marshalValue(iter.Value())
+		if err != nil {
+			return nil, err
+		}
+		obj[iter.Key().String()] = v
+	}
+	return obj, nil
+}
+
+// marshalStruct converts a struct to map[string]any using its field tags.
+func (m *marshaler) marshalStruct(rv reflect.Value) (map[string]any, error) {
+	fields := cachedFields(rv.Type())
+	obj := make(map[string]any, len(fields))
+	for _, f := range fields {
+		fv, ok := fieldByIndex(rv, f.index, false)
+		if !ok {
+			continue // nil 포인터 embedded 구조체
+		}
+		if f.omitEmpty && isEmptyValue(fv) {
+			continue
+		}
+		v, err := m.marshalValue(fv)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.name, err)
+		}
+		obj[f.name] = v
+	}
+	return obj, nil
+}
+
+// isEmptyValue reports whether v is empty for omitempty.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	case reflect.Struct:
+		if v.Type() == timeType && v.CanInterface() {
+			return v.Interface().(time.Time).IsZero()
+		}
+	}
+	return false
+}
+
+// unmarshaler assigns decoded values and keeps the first type error.
+type unmarshaler struct {
+	err      error
+	visiting map[refKey]struct{} // 대입 중인 디코딩된 object/array
+}
+
+// typeError records a type mismatch (첫 에러만 유지).
+func (u *unmarshaler) typeError(value any, t reflect.Type, field string) {
+	if u.err != nil {
+		return
+	}
+	u.err = &UnmarshalTypeError{Value: describeValue(value), Type: t, Field: field}
+}
+
+// assign sets dst from a decoded value.
+func (u *unmarshaler) assign(value any, dst reflect.Value, field string) {
+	if dst.Kind() != reflect.Pointer && dst.CanAddr() && dst.Addr().Type().Implements(unmarshalerType) {
+		if err := dst.Addr().Interface().(Unmarshaler).UnmarshalAMF(value); err != nil && u.err == nil {
+			u.err = err
+		}
+		return
+	}
+
+	if value == nil || value == (Undefined{}) {
+		switch dst.Kind() {
+		case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface:
+			dst.SetZero()
+		}
+		return
+	}
+
+	// 자기 참조하는 값을 재귀 타입에 대입하면 끝나지 않으므로 중단
+	// (포인터는 같은 값을 Elem에 넘기기만 하므로 제외)
+	if dst.Kind() != reflect.Pointer && dst.Kind() != reflect.Interface {
+		if key := refKeyOf(value); key != (refKey{}) {
+			if _, ok := u.visiting[key]; ok {
+				if u.err == nil {
+					u.err = fmt.Errorf("%w: %s into %s", ErrCycle, describeValue(value), dst.Type())
+				}
+				return
+			}
+			if u.visiting == nil {
+				u.visiting = make(map[refKey]struct{})
+			}
+			u.visiting[key] = struct{}{}
+			defer delete(u.visiting, key)
+		}
+	}
+
+	switch dst.Kind() {
+	case reflect.Pointer:
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		u.assign(value, dst.Elem(), field)
+		return
+	case reflect.Interface:
+		if rv := reflect.ValueOf(value); rv.Type().AssignableTo(dst.Type()) {
+			dst.Set(rv)
+			return
+		}
+	case reflect.Bool:
+		if b, ok := value.(bool); ok {
+			dst.SetBool(b)
+			return
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, ok := toFloat(value); ok && n == math.Trunc(n) && n >= math.MinInt64 && n < math.MaxInt64 &&
+			!dst.OverflowInt(int64(n)) {
+			dst.SetInt(int64(n))
+			return
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if n, ok := toFloat(value); ok && n == math.Trunc(n) && n >= 0 && n < math.MaxUint64 &&
+			!dst.OverflowUint(uint64(n)) {
+			dst.SetUint(uint64(n))
+			return
+		}
+	case reflect.Float32, reflect.Float64:
+		if n, ok := toFloat(value); ok && !dst.OverflowFloat(n) {
+			dst.SetFloat(n)
+			return
+		}
+	case reflect.String:
+		switch s := value.(type) {
+		case string:
+			dst.SetString(s)
+			return
+		case XMLDocument:
+			dst.SetString(string(s))
+			return
+		}
+	case reflect.Slice:
+		if b, ok := value.(ByteArray); ok && dst.Type().Elem().Kind() == reflect.Uint8 {
+			dst.SetBytes(bytes.Clone(b))
+			return
+		}
+		if arr, ok := toArray(value); ok {
+			slice := reflect.MakeSlice(dst.Type(), len(arr), len(arr))
+			for i, v := range arr {
+				u.assign(v, slice.Index(i), field)
+			}
+			dst.Set(slice)
+			return
+		}
+	case reflect.Array:
+		if arr, ok := toArray(value); ok {
+			dst.SetZero()
+			for i := 0; i < min(len(arr), dst.Len()); i++ {
+				u.assign(arr[i], dst.Index(i), field)
+			}
+			return
+		}
+	case reflect.Map:
+		if obj, ok := toObject(value); ok && dst.Type().Key().Kind() == reflect.String {
+			if dst.IsNil() {
+				dst.Set(reflect.MakeMapWithSize(dst.Type(), len(obj)))
+			}
+			elemType := dst.Type().Elem()
+			for key, v := range obj {
+				elem := reflect.New(elemType).Elem()
+				u.assign(v, elem, joinField(field, key))
+				dst.SetMapIndex(reflect.ValueOf(key).Convert(dst.Type().Key()), elem)
+			}
+			return
+		}
+	case reflect.Struct:
+		if dst.Type() == timeType {
+			if t, ok := value.(time.Time); ok {
+				dst.Set(reflect.ValueOf(t))
+				return
+			}
+			break
+		}
+		if obj, ok := toObject(value); ok {
+			u.assignStruct(obj, dst, field)
+			return
+		}
+	}
+
+	u.typeError(value, dst.Type(), field)
+}
+
+// assignStruct sets struct fields from object members.
+func (u *unmarshaler) assignStruct(obj map[string]any, dst reflect.Value, field string) {
+	fields := cachedFields(dst.Type())
+	for key, v := range obj {
+		f := matchField(fields, key)
+		if f == nil {
+			continue
+		}
+		if fv, ok := fieldByIndex(dst, f.index, true); ok {
+			u.assign(v, fv, joinField(field, f.goName))
+		}
+	}
+}
+
+// toFloat returns a decoded number as float64 (AMF0 number, AMF3 integer/double).
+func toFloat(value any) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case int32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// toArray returns the elements of a decoded array.
+func toArray(value any) ([]any, bool) {
+	switch v := value.(type) {
+	case []any:
+		return v, true
+	case *ArrayCollection:
+		return []any(*v), true
+	case *MixedArray:
+		return v.Dense, true
+	case *VectorObject:
+		return v.Items, true
+	case *VectorInt:
+		return vectorItems(v.Items), true
+	case *VectorUint:
+		return vectorItems(v.Items), true
+	case *VectorDouble:
+		return vectorItems(v.Items), true
+	}
+	return nil, false
+}
+
+// vectorItems converts the items of a numeric vector to []any.
+func vectorItems[T int32 | uint32 | float64](items []T) []any {
+	arr := make([]any, len(items))
+	for i, v := range items {
+		arr[i] = v
+	}
+	return arr
+}
+
+// toObject returns the members of a decoded object.
+func toObject(value any) (map[string]any, bool) {
+	switch v := value.(type) {
+	case map[string]any:
+		return v, true
+	case *TypedObject:
+		return v.Members, true
+	case *Object:
+		return v.Map(), true
+	case *ECMAArray:
+		return v.Map(), true
+	}
+	return nil, false
+}
+
+// describeValue describes a decoded value for UnmarshalTypeError.
+func describeValue(value any) string {
+	switch v := value.(type) {
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64, int32:
+		return fmt.Sprintf("number %v", v)
+	case []any, *ArrayCollection, *MixedArray:
+		return "array"
+	case map[string]any, *Object:
+		return "object"
+	case *ECMAArray:
+		return "ECMA array"
+	case *TypedObject:
+		return "object " + v.ClassName
+	case time.Time:
+		return "date"
+	case XMLDocument:
+		return "xml document"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// joinField appends a name to a field path.
+func joinField(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// structField is an encoded field of a struct type.
+type structField struct {
+	name      string // AMF 멤버 이름
+	goName    string
+	index     []int
+	omitEmpty bool
+}
+
+var fieldCache sync.Map // reflect.Type -> []structField
+
+// cachedFields returns the encoded fields of a struct type.
+func cachedFields(t reflect.Type) []structField {
+	if fields, ok := fieldCache.Load(t); ok {
+		return fields.([]structField)
+	}
+	fields, _ := fieldCache.LoadOrStore(t, typeFields(t, nil))
+	return fields.([]structField)
+}
+
+// typeFields collects the fields of t; 태그 없는 embedded 구조체의 필드는 바깥 구조체로 올림
+// (바깥 필드가 같은 이름이면 바깥이 우선).
+func typeFields(t reflect.Type, index []int) []structField {
+	var fields, embedded []structField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("amf")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		fieldIndex := append(append([]int(nil), index...), i)
+
+		if sf.Anonymous && name == "" {
+			ft := sf.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded = append(embedded, typeFields(ft, fieldIndex)...)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = sf.Name
+		}
+		fields = append(fields, structField{
+			name:      name,
+			goName:    sf.Name,
+			index:     fieldIndex,
+			omitEmpty: opts == "omitempty",
+		})
+	}
+
+	for _, f := range embedded {
+		if matchExact(fields, f.name) == nil {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// matchField finds the field for a member name (정확히 일치, 없으면 대소문자 무시).
+func matchField(fields []structField, name string) *structField {
+	if f := matchExact(fields, name); f != nil {
+		return f
+	}
+	for i := range fields {
+		if strings.EqualFold(fields[i].name, name) {
+			return &fields[i]
+		}
+	}
+	return nil
+}
+
+// matchExact finds the field with the given name.
+func matchExact(fields []structField, name string) *structField {
+	for i := range fields {
+		if fields[i].name == name {
+			return &fields[i]
+		}
+	}
+	return nil
+}
+
+// fieldByIndex returns the field at index; alloc이면 nil embedded 포인터를 할당.
+// nil 포인터를 할당하지 못하면 (alloc이 아니거나 unexported embedded 포인터) false
+func fieldByIndex(v reflect.Value, index []int, alloc bool) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !alloc || !v.CanSet() {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
@@ -0,0 +1,352 @@
+package amf
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testVideo struct {
+	Codec  string  `amf:"codec"`
+	Width  int     `amf:"width"`
+	Height uint16  `amf:"height"`
+	FPS    float32 `amf:"fps,omitempty"`
+}
+
+type testBase struct {
+	ID string `amf:"id"`
+}
+
+type testStream struct {
+	testBase
+	Name     string            `amf:"name"`
+	Video    *testVideo        `amf:"video,omitempty"`
+	Tags     []string          `amf:"tags"`
+	Extra    map[string]int    `amf:"extra,omitempty"`
+	Created  time.Time         `amf:"created"`
+	Labels   map[string]string `amf:"labels,omitempty"`
+	Secret   string            `amf:"-"`
+	internal int
+}
+
+func TestMarshal_RoundTrip(t *testing.T) {
+	in := testStream{
+		testBase: testBase{ID: "s1"},
+		Name:     "live",
+		Video:    &testVideo{Codec: "avc1", Width: 1280, Height: 720, FPS: 30},
+		Tags:     []string{"a", "b"},
+		Extra:    map[string]int{"bitrate": 2500},
+		Created:  time.UnixMilli(1700000000000).UTC(),
+		Secret:   "hidden",
+	}
+
+	for _, enc := range []struct {
+		name      string
+		marshal   func(any) ([]byte, error)
+		unmarshal func([]byte, any) error
+	}{
+		{"AMF0", Marshal, Unmarshal},
+		{"AMF3", MarshalAMF3, UnmarshalAMF3},
+	} {
+		t.Run(enc.name, func(t *testing.T) {
+			data, err := enc.marshal(in)
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+
+			var out testStream
+			if err := enc.unmarshal(data, &out); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			want := in
+			want.Secret = ""
+			out.Created = out.Created.UTC() // AMF3 date는 로컬 시간으로 디코딩됨
+			if !reflect.DeepEqual(out, want) {
+				t.Errorf("got %+v, want %+v", out, want)
+			}
+		})
+	}
+}
+
+func TestMarshalValue_Tags(t *testing.T) {
+	v, err := MarshalValue(testStream{Name: "live"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj := v.(map[string]any)
+
+	// embedded 필드는 바깥으로 올라옴
+	if obj["id"] != "" || obj["name"] != "live" {
+		t.Errorf("got %v", obj)
+	}
+	for _, key := range []string{"video", "extra", "labels", "Secret", "internal", "testBase"} {
+		if _, ok := obj[key]; ok {
+			t.Errorf("unexpected member %q", key)
+		}
+	}
+	// nil slice는 null
+	if tags, ok := obj["tags"]; !ok || tags != nil {
+		t.Errorf("tags = %v, want nil", tags)
+	}
+}
+
+func TestMarshalValue_Numbers(t *testing.T) {
+	tests := []struct {
+		in   any
+		want any
+	}{
+		{int(42), int32(42)},
+		{uint8(7), int32(7)},
+		{int64(1) << 40, float64(1 << 40)},
+		{uint32(1) << 31, float64(1 << 31)},
+		{float32(1.5), float64(1.5)},
+	}
+	for _, tt := range tests {
+		got, err := MarshalValue(tt.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("MarshalValue(%T %v) = %T %v, want %T %v", tt.in, tt.in, got, got, tt.want, tt.want)
+		}
+	}
+}
+
+func TestUnmarshalValue_Coercion(t *testing.T) {
+	var s struct {
+		A int     `amf:"a"`
+		B uint8   `amf:"b"`
+		C float64 `amf:"c"`
+		D int64   `amf:"d"`
+	}
+	obj := map[string]any{"a": 3.0, "b": int32(200), "c": int32(5), "D": 9.0}
+	if err := UnmarshalValue(obj, &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.A != 3 || s.B != 200 || s.C != 5 || s.D != 9 {
+		t.Errorf("got %+v", s)
+	}
+}
+
+func TestUnmarshalValue_TypeError(t *testing.T) {
+	var s struct {
+		Name  string `amf:"name"`
+		Count uint8  `amf:"count"`
+		Ratio int    `amf:"ratio"`
+	}
+
+	tests := []struct {
+		name  string
+		obj   map[string]any
+		field string
+	}{
+		{"string into uint8", map[string]any{"count": "x"}, "Count"},
+		{"overflow", map[string]any{"count": 300.0}, "Count"},
+		{"fraction", map[string]any{"ratio": 1.5}, "Ratio"},
+		{"negative into uint", map[string]any{"count": -1.0}, "Count"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.obj["name"] = "kept"
+			err := UnmarshalValue(tt.obj, &s)
+			var typeErr *UnmarshalTypeError
+			if !errors.As(err, &typeErr) {
+				t.Fatalf("got %v, want *UnmarshalTypeError", err)
+			}
+			if typeErr.Field != tt.field {
+				t.Errorf("field = %q, want %q", typeErr.Field, tt.field)
+			}
+			// 다른 필드는 계속 채워짐
+			if s.Name != "kept" {
+				t.Errorf("name = %q, want kept", s.Name)
+			}
+		})
+	}
+}
+
+func TestUnmarshalValue_NonPointer(t *testing.T) {
+	var s struct{}
+	if err := UnmarshalValue(map[string]any{}, s); err == nil {
+		t.Error("expected error for non-pointer")
+	}
+}
+
+func TestUnmarshalValue_NullAndInterface(t *testing.T) {
+	s := struct {
+		P   *int           `amf:"p"`
+		Any any            `amf:"any"`
+		M   map[string]any `amf:"m"`
+	}{P: new(int), M: map[string]any{"x": 1}}
+
+	obj := map[string]any{"p": nil, "any": []any{"x"}, "m": nil}
+	if err := UnmarshalValue(obj, &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.P != nil || s.M != nil {
+		t.Errorf("null not applied: %+v", s)
+	}
+	if !reflect.DeepEqual(s.Any, []any{"x"}) {
+		t.Errorf("any = %v", s.Any)
+	}
+}
+
+func TestUnmarshalValue_TypedObject(t *testing.T) {
+	obj := &TypedObject{ClassName: "Video", Members: map[string]any{"codec": "avc1", "width": int32(640)}}
+	var v testVideo
+	if err := UnmarshalValue(obj, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Codec != "avc1" || v.Width != 640 {
+		t.Errorf("got %+v", v)
+	}
+
+	var list []string
+	if err := UnmarshalValue(&ArrayCollection{"a", "b"}, &list); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(list, []string{"a", "b"}) {
+		t.Errorf("list = %v", list)
+	}
+}
+
+// testLevel marshals as a string
+type testLevel int
+
+func (l testLevel) MarshalAMF() (any, error) {
+	return [...]string{"status", "error"}[l], nil
+}
+
+func (l *testLevel) UnmarshalAMF(value any) error {
+	switch value {
+	case "status":
+		*l = 0
+	case "error":
+		*l = 1
+	default:
+		return errors.New("unknown level")
+	}
+	return nil
+}
+
+func TestMarshaler(t *testing.T) {
+	in := struct {
+		Level testLevel `amf:"level"`
+	}{Level: 1}
+
+	v, err := MarshalValue(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := v.(map[string]any)["level"]; got != "error" {
+		t.Fatalf("level = %v, want error", got)
+	}
+
+	data, err := Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in.Level = 0
+	if err := Unmarshal(data, &in); err != nil {
+		t.Fatal(err)
+	}
+	if in.Level != 1 {
+		t.Errorf("level = %d, want 1", in.Level)
+	}
+
+	err = UnmarshalValue(map[string]any{"level": "bogus"}, &in)
+	if err == nil || !strings.Contains(err.Error(), "unknown level") {
+		t.Errorf("got %v, want Unmarshaler error", err)
+	}
+}
+
+func TestMarshal_UnsupportedType(t *testing.T) {
+	if _, err := Marshal(map[int]string{1: "a"}); err == nil {
+		t.Error("expected error for non-string map key")
+	}
+	if _, err := Marshal(struct{ C chan int }{}); err == nil {
+		t.Error("expected error for channel field")
+	}
+}
+
+type testNode struct {
+	Name  string
+	Child *testNode
+	Items []*testNode
+}
+
+func TestMarshalValue_Cycle(t *testing.T) {
+	self := &testNode{Name: "a"}
+	self.Child = self
+	list := &testNode{Name: "b"}
+	list.Items = []*testNode{{Name: "c"}, list}
+	obj := map[string]any{"name": "d"}
+	obj["self"] = obj
+	arr := []any{"e", nil}
+	arr[1] = arr
+	var iface any
+	iface = &iface
+
+	tests := []struct {
+		name string
+		v    any
+	}{
+		{"pointer", self},
+		{"slice element", list},
+		{"map", obj},
+		{"slice", arr},
+		{"interface", &iface},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := MarshalValue(tt.v); !errors.Is(err, ErrCycle) {
+				t.Errorf("got %v, want ErrCycle", err)
+			}
+		})
+	}
+
+	// 순환이 아닌 공유 값은 허용
+	shared := &testNode{Name: "shared"}
+	v, err := MarshalValue(&testNode{Child: shared, Items: []*testNode{shared, shared}})
+	if err != nil {
+		t.Fatalf("shared value: %v", err)
+	}
+	if items := v.(map[string]any)["Items"].([]any); len(items) != 2 {
+		t.Errorf("shared value: got %d items, want 2", len(items))
+	}
+}
+
+func TestUnmarshal_Cycle(t *testing.T) {
+	// Child가 자기 자신을 가리키는 object (reference 0)
+	data := []byte{0x03, 0x00, 0x05, 'C', 'h', 'i', 'l', 'd', 0x07, 0x00, 0x00, 0x00, 0x00, 0x09}
+	var n testNode
+	if err := Unmarshal(data, &n); !errors.Is(err, ErrCycle) {
+		t.Errorf("Unmarshal: got %v, want ErrCycle", err)
+	}
+
+	// 디코딩된 순환 값을 다시 변환
+	value, err := DecodeAMF0(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("DecodeAMF0: %v", err)
+	}
+	if _, err := MarshalValue(value); !errors.Is(err, ErrCycle) {
+		t.Errorf("MarshalValue: got %v, want ErrCycle", err)
+	}
+
+	// interface 대상은 값을 그대로 대입하므로 순환도 허용
+	var v any
+	if err := Unmarshal(data, &v); err != nil {
+		t.Errorf("Unmarshal into any: %v", err)
+	}
+
+	// 순환이 아닌 공유 값은 허용
+	shared := map[string]any{"Name": "shared"}
+	if err := UnmarshalValue(map[string]any{"Child": shared, "Items": []any{shared, shared}}, &n); err != nil {
+		t.Fatalf("shared value: %v", err)
+	}
+	if n.Child.Name != "shared" || len(n.Items) != 2 || n.Items[1].Name != "shared" {
+		t.Errorf("shared value: got %+v", n)
+	}
+}
@@ -0,0 +1,103 @@
+package amf
+
+import (
+	"maps"
+	"slices"
+)
+
+// Property is a named value of an Object or ECMAArray.
+type Property struct {
+	Key   string
+	Value any
+}
+
+// Object is an anonymous AMF object that keeps its property order.
+// map[string]any는 인코딩 시 키 정렬 순서로 쓰이므로, 원래 순서가 필요하면 Object를 사용.
+// Decoder.UseOrderedObjects로 디코딩하면 object가 *Object로 반환됨
+type Object struct {
+	Properties []Property
+}
+
+// NewObject creates an object with the given properties.
+func NewObject(props ...Property) *Object {
+	return &Object{Properties: props}
+}
+
+// Len returns the number of properties.
+func (o *Object) Len() int {
+	return len(o.Properties)
+}
+
+// Get returns the value of key.
+func (o *Object) Get(key string) (any, bool) {
+	if i := o.index(key); i >= 0 {
+		return o.Properties[i].Value, true
+	}
+	return nil, false
+}
+
+// Set replaces the value of key in place, or appends it if key is new.
+func (o *Object) Set(key string, value any) {
+	if i := o.index(key); i >= 0 {
+		o.Properties[i].Value = value
+		return
+	}
+	o.Properties = append(o.Properties, Property{Key: key, Value: value})
+}
+
+// Delete removes key and reports whether it was present.
+func (o *Object) Delete(key string) bool {
+	i := o.index(key)
+	if i < 0 {
+		return false
+	}
+	o.Properties = slices.Delete(o.Properties, i, i+1)
+	return true
+}
+
+// Keys returns the property names in order.
+func (o *Object) Keys() []string {
+	keys := make([]string, len(o.Properties))
+	for i, p := range o.Properties {
+		keys[i] = p.Key
+	}
+	return keys
+}
+
+// Map returns the properties as a map; later duplicates win.
+func (o *Object) Map() map[string]any {
+	m := make(map[string]any, len(o.Properties))
+	for _, p := range o.Properties {
+		m[p.Key] = p.Value
+	}
+	return m
+}
+
+// index returns the position of key, or -1.
+// 디코딩한 객체에 중복 키가 있으면 map과 같이 마지막 값을 사용
+func (o *Object) index(key string) int {
+	for i := len(o.Properties) - 1; i >= 0; i-- {
+		if o.Properties[i].Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// ECMAArray is an AMF0 ECMA array (associative array) that keeps its property order.
+// object와 구분되어 다시 인코딩해도 ECMA array(0x08)로 쓰임 (onMetaData 등).
+// associative count는 Count가 0이 아니면 Count, 0이면 Properties 수로 기록
+type ECMAArray struct {
+	Object
+	Count uint32 // 디코딩한 associative count (그대로 다시 인코딩), Properties를 바꾸면 0으로 설정
+}
+
+// NewECMAArray creates an ECMA array with the given properties.
+func NewECMAArray(props ...Property) *ECMAArray {
+	return &ECMAArray{Object: Object{Properties: props}}
+}
+
+// sortedKeys returns the keys of m in sorted order so maps encode deterministically
+func sortedKeys(m map[string]any) []string {
+	return slices.Sorted(maps.Keys(m))
+}
@@ -0,0 +1,189 @@
+package amf
+
+import (
+	"bytes"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestObject_Methods(t *testing.T) {
+	obj := NewObject(Property{"b", 1.0}, Property{"a", 2.0})
+	obj.Set("c", 3.0)
+	obj.Set("b", 4.0) // 기존 위치에서 교체
+
+	if !reflect.DeepEqual(obj.Keys(), []string{"b", "a", "c"}) {
+		t.Errorf("keys = %v", obj.Keys())
+	}
+	if v, ok := obj.Get("b"); !ok || v != 4.0 {
+		t.Errorf("Get(b) = %v, %v", v, ok)
+	}
+	if !obj.Delete("a") || obj.Delete("a") {
+		t.Error("Delete should report presence")
+	}
+	if _, ok := obj.Get("a"); ok || obj.Len() != 2 {
+		t.Errorf("after Delete: %v", obj.Properties)
+	}
+	if !reflect.DeepEqual(obj.Map(), map[string]any{"b": 4.0, "c": 3.0}) {
+		t.Errorf("Map() = %v", obj.Map())
+	}
+}
+
+func TestObject_DuplicateKeys(t *testing.T) {
+	obj := NewObject(Property{"a", 1.0}, Property{"a", 2.0})
+	if v, _ := obj.Get("a"); v != 2.0 {
+		t.Errorf("Get(a) = %v, want last value", v)
+	}
+	if obj.Map()["a"] != 2.0 {
+		t.Errorf("Map()[a] = %v, want last value", obj.Map()["a"])
+	}
+}
+
+func TestDecoder_UseOrderedObjects(t *testing.T) {
+	meta := NewECMAArray(
+		Property{"width", 1280.0},
+		Property{"height", 720.0},
+		Property{"encoder", "obs"},
+		Property{"audio", NewObject(Property{"rate", 44100.0}, Property{"codec", "aac"})},
+	)
+	data, err := EncodeAMF0Sequence("onMetaData", meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDecoder(bytes.NewReader(data))
+	d.UseOrderedObjects()
+	if _, err := d.Decode(); err != nil {
+		t.Fatal(err)
+	}
+	val, err := d.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok := val.(*ECMAArray)
+	if !ok {
+		t.Fatalf("got %T, want *ECMAArray", val)
+	}
+	if !reflect.DeepEqual(got.Properties, meta.Properties) || got.Count != 4 {
+		t.Errorf("got %+v, want %+v with count 4", got, meta)
+	}
+
+	// 다시 인코딩해도 같은 바이트 (ECMA array, 순서, associative count 유지)
+	again, err := EncodeAMF0Sequence("onMetaData", got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again, data) {
+		t.Errorf("re-encoded bytes differ:\n got %v\nwant %v", again, data)
+	}
+	if data[13] != ecmaArrayMarker || data[17] != 4 {
+		t.Errorf("expected ECMA array with count 4, got %v", data[13:18])
+	}
+}
+
+func TestDecoder_ECMAArrayCount(t *testing.T) {
+	tests := []struct {
+		name  string
+		count byte
+	}{
+		{"matching", 1},
+		{"larger", 7},
+		{"zero", 0}, // Count 0은 Properties 수로 다시 인코딩
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := []byte{0x08, 0x00, 0x00, 0x00, tt.count, 0x00, 0x01, 'a', 0x05, 0x00, 0x00, 0x09}
+			d := NewDecoder(bytes.NewReader(data))
+			d.UseOrderedObjects()
+			val, err := d.Decode()
+			if err != nil {
+				t.Fatal(err)
+			}
+			arr := val.(*ECMAArray)
+			if arr.Count != uint32(tt.count) {
+				t.Errorf("expected Count %d, got %d", tt.count, arr.Count)
+			}
+
+			again, err := EncodeAMF0Sequence(arr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := data
+			if tt.count == 0 {
+				want = slices.Clone(data)
+				want[4] = 1
+			}
+			if !bytes.Equal(again, want) {
+				t.Errorf("re-encoded %v, want %v", again, want)
+			}
+		})
+	}
+}
+
+func TestDecoder_OrderedDuplicateKeys(t *testing.T) {
+	data := []byte{0x03,
+		0x00, 0x01, 'a', 0x00, 0x3F, 0xF0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x01, 'a', 0x05,
+		0x00, 0x00, 0x09}
+
+	d := NewDecoder(bytes.NewReader(data))
+	d.UseOrderedObjects()
+	val, err := d.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj := val.(*Object); obj.Len() != 2 {
+		t.Errorf("got %v, want both properties", obj.Properties)
+	}
+}
+
+func TestEncodeMap_SortedKeys(t *testing.T) {
+	obj := map[string]any{"c": nil, "a": nil, "b": nil}
+	want := []byte{0x03,
+		0x00, 0x01, 'a', 0x05,
+		0x00, 0x01, 'b', 0x05,
+		0x00, 0x01, 'c', 0x05,
+		0x00, 0x00, 0x09}
+
+	for i := 0; i < 10; i++ {
+		data, err := EncodeAMF0Sequence(obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, want) {
+			t.Fatalf("got %v, want %v", data, want)
+		}
+	}
+}
+
+func TestEncodeAMF3_OrderedObject(t *testing.T) {
+	obj := NewObject(Property{"b", int32(1)}, Property{"a", int32(2)})
+	data, err := EncodeAMF3Sequence(obj, NewECMAArray(Property{"k", "v"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []byte{
+		0x0A, 0x0B, 0x01, // anonymous dynamic object
+		0x03, 'b', 0x04, 0x01,
+		0x03, 'a', 0x04, 0x02,
+		0x01,
+		0x09, 0x01, // array, dense length 0
+		0x03, 'k', 0x06, 0x03, 'v',
+		0x01,
+	}
+	if !bytes.Equal(data, want) {
+		t.Errorf("got %v, want %v", data, want)
+	}
+}
+
+func TestUnmarshalValue_OrderedObject(t *testing.T) {
+	var v testVideo
+	obj := NewECMAArray(Property{"codec", "avc1"}, Property{"width", 1920.0})
+	if err := UnmarshalValue(obj, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Codec != "avc1" || v.Width != 1920 {
+		t.Errorf("got %+v", v)
+	}
+}
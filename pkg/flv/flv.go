@@ -0,0 +1,123 @@
+// Package flv reads and writes FLV files tag by tag.
+// Tag 본문은 RTMP audio/video/data 메시지 본문과 동일하므로 transport.Message와 무손실 변환 가능
+// (E-RTMP extended header 포함).
+package flv
+
+import (
+	"errors"
+
+	"github.com/ssungk/ertmp/pkg/media"
+	"github.com/ssungk/ertmp/pkg/rtmp/buf"
+	"github.com/ssungk/ertmp/pkg/rtmp/transport"
+)
+
+// FLV layout constants
+const (
+	Version             = 1
+	HeaderSize          = 9  // signature(3) + version(1) + flags(1) + data offset(4)
+	TagHeaderSize       = 11 // type(1) + data size(3) + timestamp(3) + timestamp ext(1) + stream ID(3)
+	PreviousTagSizeSize = 4
+	MaxTagDataSize      = 0xFFFFFF
+)
+
+// Tag types
+const (
+	TagTypeAudio  = 8
+	TagTypeVideo  = 9
+	TagTypeScript = 18
+)
+
+// Header flags
+const (
+	flagVideo = 0x01
+	flagAudio = 0x04
+)
+
+var (
+	// ErrInvalidHeader is returned when the input does not start with an FLV header
+	ErrInvalidHeader = errors.New("flv: invalid header")
+
+	// ErrTagTooLarge is returned when tag data exceeds 24 bits
+	ErrTagTooLarge = errors.New("flv: tag data too large")
+)
+
+// Header is the FLV file header
+type Header struct {
+	HasAudio bool
+	HasVideo bool
+}
+
+// Tag is an FLV tag
+type Tag struct {
+	Type      uint8 // TagTypeAudio, TagTypeVideo, TagTypeScript
+	Filter    bool  // 암호화/전처리 필터 적용 여부 (tag type의 bit 5)
+	Timestamp uint32
+	Data      []byte
+}
+
+// TagFromMessage converts an audio, video or AMF0 data message to a tag.
+// Data는 메시지 버퍼를 참조하므로 메시지 해제 전에만 유효함
+func TagFromMessage(msg transport.Message) (Tag, bool) {
+	tagType, ok := tagTypeOf(msg.Type())
+	if !ok {
+		return Tag{}, false
+	}
+	return Tag{
+		Type:      tagType,
+		Timestamp: msg.Timestamp(),
+		Data:      msg.Data(),
+	}, true
+}
+
+// Message converts the tag to a message on the given message stream.
+// Data를 복사한 새 버퍼를 사용하며, caller가 해제해야 함
+func (t *Tag) Message(streamID uint32) transport.Message {
+	buffer := buf.NewFromPool(len(t.Data))
+	copy(buffer.Data(), t.Data)
+	header := transport.NewMessageHeader(streamID, t.Timestamp, messageTypeOf(t.Type))
+	return transport.NewMessage(header, buffer)
+}
+
+// Video parses the tag data as a (legacy or E-RTMP) video tag
+func (t *Tag) Video() (*media.VideoTag, error) {
+	return media.ParseVideoTag(t.Data)
+}
+
+// Audio parses the tag data as a (legacy or E-RTMP) audio tag
+func (t *Tag) Audio() (*media.AudioTag, error) {
+	return media.ParseAudioTag(t.Data)
+}
+
+// IsKeyFrame reports whether the tag is a video key frame
+func (t *Tag) IsKeyFrame() bool {
+	if t.Type != TagTypeVideo || len(t.Data) == 0 {
+		return false
+	}
+	video, err := t.Video()
+	return err == nil && video.IsKeyFrame()
+}
+
+// tagTypeOf maps an RTMP message type to an FLV tag type
+func tagTypeOf(msgType uint8) (uint8, bool) {
+	switch msgType {
+	case transport.MsgTypeAudio:
+		return TagTypeAudio, true
+	case transport.MsgTypeVideo:
+		return TagTypeVideo, true
+	case transport.MsgTypeAMF0Data:
+		return TagTypeScript, true
+	}
+	return 0, false
+}
+
+// messageTypeOf maps an FLV tag type to an RTMP message type
+func messageTypeOf(tagType uint8) uint8 {
+	switch tagType {
+	case TagTypeAudio:
+		return transport.MsgTypeAudio
+	case TagTypeVideo:
+		return transport.MsgTypeVideo
+	default:
+		return transport.MsgTypeAMF0Data
+	}
+}
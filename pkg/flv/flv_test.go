@@ -0,0 +1,234 @@
+package flv
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/ssungk/ertmp/pkg/amf"
+	"github.com/ssungk/ertmp/pkg/media"
+	"github.com/ssungk/ertmp/pkg/rtmp/buf"
+	"github.com/ssungk/ertmp/pkg/rtmp/transport"
+)
+
+func TestWriter_Header(t *testing.T) {
+	var out bytes.Buffer
+	w := NewWriter(&out)
+	if err := w.WriteHeader(Header{HasAudio: true, HasVideo: true}); err != nil {
+		t.Fatalf("WriteHeader failed: %v", err)
+	}
+
+	want := []byte{'F', 'L', 'V', 0x01, 0x05, 0x00, 0x00, 0x00, 0x09, 0x00, 0x00, 0x00, 0x00}
+	if !bytes.Equal(out.Bytes(), want) {
+		t.Errorf("expected %x, got %x", want, out.Bytes())
+	}
+	if w.Written() != int64(len(want)) {
+		t.Errorf("expected %d bytes written, got %d", len(want), w.Written())
+	}
+}
+
+func TestWriter_Tag(t *testing.T) {
+	var out bytes.Buffer
+	w := NewWriter(&out)
+	// 24비트를 넘는 timestamp는 TimestampExtended에 기록
+	if err := w.WriteTag(Tag{Type: TagTypeVideo, Timestamp: 0x01020304, Data: []byte{0x17, 0x01}}); err != nil {
+		t.Fatalf("WriteTag failed: %v", err)
+	}
+
+	want := []byte{
+		0x09, 0x00, 0x00, 0x02, 0x02, 0x03, 0x04, 0x01, 0x00, 0x00, 0x00, // tag header
+		0x17, 0x01, // data
+		0x00, 0x00, 0x00, 0x0D, // PreviousTagSize = 11 + 2
+	}
+	if !bytes.Equal(out.Bytes(), want) {
+		t.Errorf("expected %x, got %x", want, out.Bytes())
+	}
+
+	if err := w.WriteTag(Tag{Type: TagTypeVideo, Data: make([]byte, MaxTagDataSize+1)}); !errors.Is(err, ErrTagTooLarge) {
+		t.Errorf("expected ErrTagTooLarge, got %v", err)
+	}
+}
+
+func TestReaderWriter_RoundTrip(t *testing.T) {
+	hevcSeq := (&media.VideoTag{
+		IsExHeader: true,
+		FrameType:  media.VideoFrameKey,
+		PacketType: media.VideoPacketSequenceStart,
+		FourCC:     media.FourCCHEVC,
+		Payload:    []byte{0x01, 0x02, 0x03},
+	}).Bytes()
+
+	tags := []Tag{
+		{Type: TagTypeVideo, Timestamp: 0, Data: hevcSeq},
+		{Type: TagTypeAudio, Timestamp: 0, Data: []byte{0x90, 'O', 'p', 'u', 's', 'O', 'H'}},
+		{Type: TagTypeVideo, Timestamp: 0xFF000040, Data: []byte{0x93, 'h', 'v', 'c', '1', 0xAA}},
+		{Type: TagTypeAudio, Timestamp: 23, Filter: true, Data: []byte{}},
+	}
+
+	var out bytes.Buffer
+	w := NewWriter(&out)
+	if err := w.WriteHeader(Header{HasAudio: true, HasVideo: true}); err != nil {
+		t.Fatalf("WriteHeader failed: %v", err)
+	}
+	if err := w.WriteScriptData(0, "onMetaData", map[string]any{"duration": 1.5}); err != nil {
+		t.Fatalf("WriteScriptData failed: %v", err)
+	}
+	for _, tag := range tags {
+		if err := w.WriteTag(tag); err != nil {
+			t.Fatalf("WriteTag failed: %v", err)
+		}
+	}
+	if w.Written() != int64(out.Len()) {
+		t.Errorf("expected %d bytes written, got %d", out.Len(), w.Written())
+	}
+
+	r := NewReader(&out)
+	header, err := r.ReadHeader()
+	if err != nil {
+		t.Fatalf("ReadHeader failed: %v", err)
+	}
+	if !header.HasAudio || !header.HasVideo {
+		t.Errorf("unexpected header: %+v", header)
+	}
+
+	script, err := r.ReadTag()
+	if err != nil {
+		t.Fatalf("ReadTag failed: %v", err)
+	}
+	values, err := DecodeScriptData(script.Data)
+	if err != nil {
+		t.Fatalf("DecodeScriptData failed: %v", err)
+	}
+	if len(values) != 2 || values[0] != "onMetaData" {
+		t.Fatalf("unexpected script data: %v", values)
+	}
+	if meta, ok := values[1].(map[string]any); !ok || meta["duration"] != 1.5 {
+		t.Errorf("unexpected metadata: %v", values[1])
+	}
+
+	for i, want := range tags {
+		got, err := r.ReadTag()
+		if err != nil {
+			t.Fatalf("tag %d: ReadTag failed: %v", i, err)
+		}
+		if got.Type != want.Type || got.Timestamp != want.Timestamp || got.Filter != want.Filter || !bytes.Equal(got.Data, want.Data) {
+			t.Errorf("tag %d: expected %+v, got %+v", i, want, got)
+		}
+	}
+
+	if _, err := r.ReadTag(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestDecodeScriptDataOrdered(t *testing.T) {
+	meta := amf.NewECMAArray(amf.Property{Key: "width", Value: 1280.0}, amf.Property{Key: "duration", Value: 0.0})
+	data, err := amf.EncodeAMF0Sequence("onMetaData", meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := DecodeScriptDataOrdered(data)
+	if err != nil {
+		t.Fatalf("DecodeScriptDataOrdered failed: %v", err)
+	}
+	if len(values) != 2 || values[0] != "onMetaData" {
+		t.Fatalf("unexpected script data: %v", values)
+	}
+	got, ok := values[1].(*amf.ECMAArray)
+	if !ok || len(got.Properties) != 2 || got.Properties[0].Key != "width" || got.Properties[1].Key != "duration" {
+		t.Errorf("unexpected metadata: %#v", values[1])
+	}
+
+	if _, err := DecodeScriptDataOrdered(data[:len(data)-1]); err == nil {
+		t.Error("expected error for truncated script data")
+	}
+}
+
+func TestMessageConversion(t *testing.T) {
+	data := []byte{0x97, 0x01, 'h', 'v', 'c', '1', 0x00, 0x00, 0x00, 0x00, 0xAB}
+	header := transport.NewMessageHeader(1, 0x12345678, transport.MsgTypeVideo)
+	msg := transport.NewMessage(header, buf.New(data))
+
+	var out bytes.Buffer
+	w := NewWriter(&out)
+	if err := w.WriteHeader(Header{HasVideo: true}); err != nil {
+		t.Fatalf("WriteHeader failed: %v", err)
+	}
+	if err := w.WriteMessage(msg); err != nil {
+		t.Fatalf("WriteMessage failed: %v", err)
+	}
+
+	// 미디어/데이터 외 메시지는 무시
+	cmd := transport.NewMessage(transport.NewMessageHeader(0, 0, transport.MsgTypeAMF0Command), buf.New([]byte{0x02}))
+	if err := w.WriteMessage(cmd); err != nil {
+		t.Fatalf("WriteMessage failed: %v", err)
+	}
+
+	r := NewReader(&out)
+	if _, err := r.ReadHeader(); err != nil {
+		t.Fatalf("ReadHeader failed: %v", err)
+	}
+	got, err := r.ReadMessage(5)
+	if err != nil {
+		t.Fatalf("ReadMessage failed: %v", err)
+	}
+	defer got.Buffer().Release()
+
+	if got.Type() != transport.MsgTypeVideo || got.Timestamp() != msg.Timestamp() || got.StreamID() != 5 {
+		t.Errorf("unexpected message header: %+v", got.Header)
+	}
+	if !bytes.Equal(got.Data(), data) {
+		t.Errorf("expected data %x, got %x", data, got.Data())
+	}
+	if _, err := r.ReadMessage(5); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+
+	tag, ok := TagFromMessage(msg)
+	if !ok {
+		t.Fatal("TagFromMessage failed")
+	}
+	back := tag.Message(1)
+	defer back.Buffer().Release()
+	if back.Header != msg.Header || !bytes.Equal(back.Data(), msg.Data()) {
+		t.Errorf("message round trip mismatch: %+v", back.Header)
+	}
+}
+
+func TestTag_IsKeyFrame(t *testing.T) {
+	tests := []struct {
+		tag  Tag
+		want bool
+	}{
+		{Tag{Type: TagTypeVideo, Data: []byte{0x17, 0x01, 0x00, 0x00, 0x00}}, true},
+		{Tag{Type: TagTypeVideo, Data: []byte{0x27, 0x01, 0x00, 0x00, 0x00}}, false},
+		{Tag{Type: TagTypeVideo, Data: []byte{0x93, 'a', 'v', '0', '1'}}, true},
+		{Tag{Type: TagTypeAudio, Data: []byte{0xAF, 0x01}}, false},
+		{Tag{Type: TagTypeVideo}, false},
+	}
+	for i, tt := range tests {
+		if got := tt.tag.IsKeyFrame(); got != tt.want {
+			t.Errorf("%d: expected %v, got %v", i, tt.want, got)
+		}
+	}
+}
+
+func TestReader_Errors(t *testing.T) {
+	r := NewReader(bytes.NewReader([]byte("NOT A FLV FILE")))
+	if _, err := r.ReadHeader(); !errors.Is(err, ErrInvalidHeader) {
+		t.Errorf("expected ErrInvalidHeader, got %v", err)
+	}
+
+	// 잘린 tag
+	truncated := []byte{'F', 'L', 'V', 0x01, 0x01, 0x00, 0x00, 0x00, 0x09, 0x00, 0x00, 0x00, 0x00,
+		0x09, 0x00, 0x00, 0x05, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x17}
+	r = NewReader(bytes.NewReader(truncated))
+	if _, err := r.ReadHeader(); err != nil {
+		t.Fatalf("ReadHeader failed: %v", err)
+	}
+	if _, err := r.ReadTag(); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+}
@@ -0,0 +1,155 @@
+package flv
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ssungk/ertmp/pkg/amf"
+	"github.com/ssungk/ertmp/pkg/rtmp/buf"
+	"github.com/ssungk/ertmp/pkg/rtmp/transport"
+)
+
+// Reader reads an FLV stream
+type Reader struct {
+	r   *bufio.Reader
+	buf [TagHeaderSize]byte
+}
+
+// NewReader creates a reader; call ReadHeader before reading tags
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// ReadHeader reads the FLV header and PreviousTagSize0
+func (r *Reader) ReadHeader() (Header, error) {
+	var header [HeaderSize]byte
+	if _, err := io.ReadFull(r.r, header[:]); err != nil {
+		return Header{}, fmt.Errorf("read header: %w", err)
+	}
+	if string(header[0:3]) != "FLV" {
+		return Header{}, ErrInvalidHeader
+	}
+
+	h := Header{
+		HasAudio: header[4]&flagAudio != 0,
+		HasVideo: header[4]&flagVideo != 0,
+	}
+
+	// DataOffset 이후 확장 영역 건너뜀
+	offset := binary.BigEndian.Uint32(header[5:9])
+	if offset < HeaderSize {
+		return Header{}, fmt.Errorf("data offset %d: %w", offset, ErrInvalidHeader)
+	}
+	if _, err := r.r.Discard(int(offset - HeaderSize)); err != nil {
+		return Header{}, fmt.Errorf("read header: %w", err)
+	}
+
+	// PreviousTagSize0
+	if _, err := r.r.Discard(PreviousTagSizeSize); err != nil {
+		return Header{}, fmt.Errorf("read header: %w", err)
+	}
+	return h, nil
+}
+
+// ReadTag reads the next tag; returns io.EOF at the end of the stream
+func (r *Reader) ReadTag() (Tag, error) {
+	tag, size, err := r.readTagHeader()
+	if err != nil {
+		return Tag{}, err
+	}
+
+	tag.Data = make([]byte, size)
+	if err := r.readTagBody(tag.Data); err != nil {
+		return Tag{}, err
+	}
+	return tag, nil
+}
+
+// ReadMessage reads the next tag as a message on the given message stream.
+// 버퍼는 풀에서 할당되며 caller가 해제해야 함. 스트림 끝에서 io.EOF 반환
+func (r *Reader) ReadMessage(streamID uint32) (transport.Message, error) {
+	tag, size, err := r.readTagHeader()
+	if err != nil {
+		return transport.Message{}, err
+	}
+
+	buffer := buf.NewFromPool(size)
+	if err := r.readTagBody(buffer.Data()); err != nil {
+		buffer.Release()
+		return transport.Message{}, err
+	}
+
+	header := transport.NewMessageHeader(streamID, tag.Timestamp, messageTypeOf(tag.Type))
+	return transport.NewMessage(header, buffer), nil
+}
+
+// readTagHeader reads a tag header and returns the tag (without data) and its data size
+func (r *Reader) readTagHeader() (Tag, int, error) {
+	h := r.buf[:]
+	if _, err := io.ReadFull(r.r, h); err != nil {
+		if err == io.EOF {
+			return Tag{}, 0, io.EOF
+		}
+		return Tag{}, 0, fmt.Errorf("read tag header: %w", err)
+	}
+
+	tag := Tag{
+		Type:      h[0] & 0x1F,
+		Filter:    h[0]&0x20 != 0,
+		Timestamp: uint32(h[7])<<24 | readUint24(h[4:7]),
+	}
+	return tag, int(readUint24(h[1:4])), nil
+}
+
+// readTagBody reads the tag data and the trailing PreviousTagSize
+// PreviousTagSize는 기록기마다 부정확한 경우가 많아 검증하지 않음
+func (r *Reader) readTagBody(data []byte) error {
+	if _, err := io.ReadFull(r.r, data); err != nil {
+		return fmt.Errorf("read tag data: %w", unexpectedEOF(err))
+	}
+	if _, err := r.r.Discard(PreviousTagSizeSize); err != nil && err != io.EOF {
+		return fmt.Errorf("read previous tag size: %w", err)
+	}
+	return nil
+}
+
+// DecodeScriptData decodes the AMF0 values of a script data tag
+func DecodeScriptData(data []byte) ([]any, error) {
+	return amf.DecodeAMF0Sequence(bytes.NewReader(data))
+}
+
+// DecodeScriptDataOrdered decodes script data keeping property order.
+// object는 *amf.Object, ECMA array는 *amf.ECMAArray로 반환되어 다시 인코딩해도 형식과 순서가 유지됨
+func DecodeScriptDataOrdered(data []byte) ([]any, error) {
+	d := amf.NewDecoder(bytes.NewReader(data))
+	d.UseOrderedObjects()
+
+	var values []any
+	for {
+		val, err := d.Decode()
+		if errors.Is(err, io.EOF) {
+			return values, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, val)
+	}
+}
+
+// readUint24 reads a big-endian uint24
+func readUint24(b []byte) uint32 {
+	return uint32(b[0])<<16 | uint32(b[1])<<8 | uint32(b[2])
+}
+
+// unexpectedEOF converts io.EOF inside a tag to io.ErrUnexpectedEOF
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,106 @@
+package flv
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/ssungk/ertmp/pkg/amf"
+	"github.com/ssungk/ertmp/pkg/rtmp/transport"
+)
+
+// Writer writes an FLV stream
+type Writer struct {
+	w       io.Writer
+	written int64
+	buf     [TagHeaderSize]byte
+}
+
+// NewWriter creates a writer.
+// 새 파일은 WriteHeader를 먼저 호출하고, 기존 파일에 이어 쓸 때는 생략
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Written returns the number of bytes written so far
+func (w *Writer) Written() int64 {
+	return w.written
+}
+
+// WriteHeader writes the FLV header and PreviousTagSize0
+func (w *Writer) WriteHeader(h Header) error {
+	var header [HeaderSize + PreviousTagSizeSize]byte
+	copy(header[0:3], "FLV")
+	header[3] = Version
+	if h.HasAudio {
+		header[4] |= flagAudio
+	}
+	if h.HasVideo {
+		header[4] |= flagVideo
+	}
+	binary.BigEndian.PutUint32(header[5:9], HeaderSize)
+	// PreviousTagSize0 = 0
+
+	return w.write(header[:])
+}
+
+// WriteTag writes a tag followed by its PreviousTagSize
+func (w *Writer) WriteTag(tag Tag) error {
+	if len(tag.Data) > MaxTagDataSize {
+		return fmt.Errorf("tag data %d bytes: %w", len(tag.Data), ErrTagTooLarge)
+	}
+
+	h := w.buf[:]
+	h[0] = tag.Type & 0x1F
+	if tag.Filter {
+		h[0] |= 0x20
+	}
+	putUint24(h[1:4], uint32(len(tag.Data)))
+	putUint24(h[4:7], tag.Timestamp&0xFFFFFF)
+	h[7] = byte(tag.Timestamp >> 24) // TimestampExtended (상위 8비트)
+	putUint24(h[8:11], 0)            // StreamID (항상 0)
+
+	if err := w.write(h); err != nil {
+		return err
+	}
+	if err := w.write(tag.Data); err != nil {
+		return err
+	}
+
+	var size [PreviousTagSizeSize]byte
+	binary.BigEndian.PutUint32(size[:], uint32(TagHeaderSize+len(tag.Data)))
+	return w.write(size[:])
+}
+
+// WriteMessage writes an audio, video or AMF0 data message as a tag.
+// 다른 메시지 타입은 무시
+func (w *Writer) WriteMessage(msg transport.Message) error {
+	tag, ok := TagFromMessage(msg)
+	if !ok {
+		return nil
+	}
+	return w.WriteTag(tag)
+}
+
+// WriteScriptData writes a script data tag with AMF0 encoded values (예: "onMetaData", metadata)
+func (w *Writer) WriteScriptData(timestamp uint32, values ...any) error {
+	data, err := amf.EncodeAMF0Sequence(values...)
+	if err != nil {
+		return fmt.Errorf("encode script data: %w", err)
+	}
+	return w.WriteTag(Tag{Type: TagTypeScript, Timestamp: timestamp, Data: data})
+}
+
+// write writes p and counts the bytes
+func (w *Writer) write(p []byte) error {
+	n, err := w.w.Write(p)
+	w.written += int64(n)
+	return err
+}
+
+// putUint24 writes a big-endian uint24
+func putUint24(b []byte, v uint32) {
+	b[0] = byte(v >> 16)
+	b[1] = byte(v >> 8)
+	b[2] = byte(v)
+}
@@ -0,0 +1,342 @@
+package media
+
+import "fmt"
+
+// AudioSoundFormat is the legacy SoundFormat field of an audio tag
+type AudioSoundFormat uint8
+
+const (
+	AudioFormatLinearPCM   AudioSoundFormat = 0
+	AudioFormatADPCM       AudioSoundFormat = 1
+	AudioFormatMP3         AudioSoundFormat = 2
+	AudioFormatLinearPCMLE AudioSoundFormat = 3
+	AudioFormatNellymoser  AudioSoundFormat = 6
+	AudioFormatALaw        AudioSoundFormat = 7
+	AudioFormatMuLaw       AudioSoundFormat = 8
+	AudioFormatExHeader    AudioSoundFormat = 9 // E-RTMP ExAudioTagHeader
+	AudioFormatAAC         AudioSoundFormat = 10
+	AudioFormatSpeex       AudioSoundFormat = 11
+)
+
+// AudioPacketType is the E-RTMP audio packet type.
+// Legacy AAC의 AACPacketType(0: seq header, 1: raw)도 같은 값으로 매핑됨
+type AudioPacketType uint8
+
+const (
+	AudioPacketSequenceStart      AudioPacketType = 0
+	AudioPacketCodedFrames        AudioPacketType = 1
+	AudioPacketSequenceEnd        AudioPacketType = 2
+	AudioPacketMultichannelConfig AudioPacketType = 4
+)
+
+// AudioChannelOrder describes how channels of a multichannel config are mapped
+type AudioChannelOrder uint8
+
+const (
+	AudioChannelOrderUnspecified AudioChannelOrder = 0 // 채널 수만 지정
+	AudioChannelOrderNative      AudioChannelOrder = 1 // AudioChannelFlags 비트마스크 순서
+	AudioChannelOrderCustom      AudioChannelOrder = 2 // 채널별 AudioChannel 매핑
+)
+
+// AudioMultichannelConfig is the body of a MultichannelConfig packet
+type AudioMultichannelConfig struct {
+	ChannelOrder   AudioChannelOrder
+	ChannelCount   uint8
+	ChannelMapping []uint8 // ChannelOrderCustom: 채널별 speaker 위치
+	ChannelFlags   uint32  // ChannelOrderNative: speaker 비트마스크
+}
+
+// AudioTag is a parsed audio message body (FLV AUDIODATA)
+type AudioTag struct {
+	IsExHeader bool
+
+	// Legacy header fields (SoundFormat은 extended header에서 AudioFormatExHeader)
+	SoundFormat AudioSoundFormat
+	SoundRate   uint8 // 0: 5.5kHz, 1: 11kHz, 2: 22kHz, 3: 44kHz
+	SoundSize   uint8 // 0: 8bit, 1: 16bit
+	SoundType   uint8 // 0: mono, 1: stereo
+
+	// FourCC is the codec of an extended header (legacy AAC/MP3도 FourCCAAC/FourCCMP3로 설정)
+	FourCC FourCC
+
+	// PacketType is set for extended headers and legacy AAC
+	PacketType AudioPacketType
+
+	// MultichannelConfig is set for MultichannelConfig packets
+	MultichannelConfig *AudioMultichannelConfig
+
+	// Payload is the data following the header (입력 슬라이스를 참조)
+	Payload []byte
+
+	// Multitrack tags carry their tracks in Tracks instead of Payload.
+	// PacketType은 모든 트랙에 공통인 packet type, FourCC는 공유 코덱 (ManyTracksManyCodecs 제외)
+	Multitrack     bool
+	MultitrackType AvMultitrackType
+	Tracks         []Track
+}
+
+// ParseAudioTag parses an audio message body.
+// Payload는 data를 복사하지 않고 참조함
+func ParseAudioTag(data []byte) (*AudioTag, error) {
+	if len(data) < 1 {
+		return nil, fmt.Errorf("audio tag header: %w", ErrShortTag)
+	}
+
+	format := AudioSoundFormat(data[0] >> 4)
+	if format == AudioFormatExHeader {
+		return parseExAudioTag(data)
+	}
+
+	tag := &AudioTag{
+		SoundFormat: format,
+		SoundRate:   (data[0] >> 2) & 0x03,
+		SoundSize:   (data[0] >> 1) & 0x01,
+		SoundType:   data[0] & 0x01,
+		Payload:     data[1:],
+	}
+
+	switch format {
+	case AudioFormatMP3:
+		tag.FourCC = FourCCMP3
+		tag.PacketType = AudioPacketCodedFrames
+
+	case AudioFormatAAC:
+		// AAC: AACPacketType(1)
+		if len(data) < 2 {
+			return nil, fmt.Errorf("aac audio tag header: %w", ErrShortTag)
+		}
+		tag.FourCC = FourCCAAC
+		tag.PacketType = AudioPacketType(data[1])
+		tag.Payload = data[2:]
+	}
+
+	return tag, nil
+}
+
+// parseExAudioTag parses an E-RTMP extended audio tag header
+func parseExAudioTag(data []byte) (*AudioTag, error) {
+	tag := &AudioTag{
+		IsExHeader:  true,
+		SoundFormat: AudioFormatExHeader,
+		PacketType:  AudioPacketType(data[0] & 0x0F),
+	}
+
+	if tag.PacketType == AudioPacketMultitrack {
+		return parseMultitrackAudioTag(tag, data[1:])
+	}
+	if !validAudioPacketType(tag.PacketType) {
+		return nil, fmt.Errorf("audio packet type %d: %w", tag.PacketType, ErrUnsupportedPacketType)
+	}
+
+	if len(data) < 5 {
+		return nil, fmt.Errorf("extended audio tag header: %w", ErrShortTag)
+	}
+	tag.FourCC = FourCC(readUI32(data[1:5]))
+	tag.Payload = data[5:]
+
+	if tag.PacketType == AudioPacketMultichannelConfig {
+		config, n, err := parseMultichannelConfig(tag.Payload)
+		if err != nil {
+			return nil, err
+		}
+		tag.MultichannelConfig = config
+		tag.Payload = tag.Payload[n:]
+	}
+
+	return tag, nil
+}
+
+// parseMultitrackAudioTag parses the multitrack part of an extended audio tag
+func parseMultitrackAudioTag(tag *AudioTag, data []byte) (*AudioTag, error) {
+	mtType, packetType, fourCC, n, err := parseMultitrackHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	tag.Multitrack = true
+	tag.MultitrackType = mtType
+	tag.PacketType = AudioPacketType(packetType)
+	tag.FourCC = fourCC
+
+	if !validAudioPacketType(tag.PacketType) {
+		return nil, fmt.Errorf("multitrack audio packet type %d: %w", tag.PacketType, ErrUnsupportedPacketType)
+	}
+
+	tag.Tracks, err = parseTracks(data[n:], mtType, fourCC, func(track *Track, body []byte) error {
+		if tag.PacketType == AudioPacketMultichannelConfig {
+			config, n, err := parseMultichannelConfig(body)
+			if err != nil {
+				return err
+			}
+			track.MultichannelConfig = config
+			body = body[n:]
+		}
+		track.Payload = body
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+// validAudioPacketType reports whether an extended audio packet type is supported (multitrack 제외)
+func validAudioPacketType(packetType AudioPacketType) bool {
+	switch packetType {
+	case AudioPacketSequenceStart, AudioPacketCodedFrames, AudioPacketSequenceEnd, AudioPacketMultichannelConfig:
+		return true
+	}
+	return false
+}
+
+// SelectTracks returns a copy of the tag with only the tracks in set.
+// 단일 트랙 태그는 track 0으로 취급하며, 선택된 트랙이 없으면 nil 반환
+func (t *AudioTag) SelectTracks(set TrackSet) *AudioTag {
+	if !t.Multitrack {
+		if !set.Contains(0) {
+			return nil
+		}
+		return t
+	}
+
+	tracks := selectTracks(t.Tracks, set)
+	if len(tracks) == 0 {
+		return nil
+	}
+	selected := *t
+	selected.Tracks = tracks
+	return &selected
+}
+
+// parseMultichannelConfig parses a multichannel config body and returns its length
+func parseMultichannelConfig(data []byte) (*AudioMultichannelConfig, int, error) {
+	if len(data) < 2 {
+		return nil, 0, fmt.Errorf("audio multichannel config: %w", ErrShortTag)
+	}
+
+	config := &AudioMultichannelConfig{
+		ChannelOrder: AudioChannelOrder(data[0]),
+		ChannelCount: data[1],
+	}
+	n := 2
+
+	switch config.ChannelOrder {
+	case AudioChannelOrderCustom:
+		count := int(config.ChannelCount)
+		if len(data) < n+count {
+			return nil, 0, fmt.Errorf("audio channel mapping: %w", ErrShortTag)
+		}
+		config.ChannelMapping = data[n : n+count]
+		n += count
+
+	case AudioChannelOrderNative:
+		if len(data) < n+4 {
+			return nil, 0, fmt.Errorf("audio channel flags: %w", ErrShortTag)
+		}
+		config.ChannelFlags = readUI32(data[n : n+4])
+		n += 4
+	}
+
+	return config, n, nil
+}
+
+// IsSequenceHeader reports whether the tag carries a decoder configuration
+// (AAC AudioSpecificConfig, OpusHead, FLAC STREAMINFO 등)
+func (t *AudioTag) IsSequenceHeader() bool {
+	if !t.IsExHeader && t.SoundFormat != AudioFormatAAC {
+		return false
+	}
+	return t.PacketType == AudioPacketSequenceStart
+}
+
+// IsSequenceEnd reports whether the tag marks the end of a sequence
+func (t *AudioTag) IsSequenceEnd() bool {
+	return t.IsExHeader && t.PacketType == AudioPacketSequenceEnd
+}
+
+// HeaderSize returns the encoded header length (multitrack: 트랙 본문 제외)
+func (t *AudioTag) HeaderSize() int {
+	if t.Multitrack {
+		if t.MultitrackType == MultitrackManyTracksManyCodecs {
+			return 2
+		}
+		return 6
+	}
+	if !t.IsExHeader {
+		if t.SoundFormat == AudioFormatAAC {
+			return 2
+		}
+		return 1
+	}
+	n := 5
+	if t.PacketType == AudioPacketMultichannelConfig && t.MultichannelConfig != nil {
+		n += t.MultichannelConfig.size()
+	}
+	return n
+}
+
+// AppendHeader appends the encoded tag header to dst
+func (t *AudioTag) AppendHeader(dst []byte) []byte {
+	if !t.IsExHeader {
+		dst = append(dst, byte(t.SoundFormat)<<4|(t.SoundRate&0x03)<<2|(t.SoundSize&0x01)<<1|t.SoundType&0x01)
+		if t.SoundFormat == AudioFormatAAC {
+			dst = append(dst, byte(t.PacketType))
+		}
+		return dst
+	}
+
+	if t.Multitrack {
+		dst = append(dst, byte(AudioFormatExHeader)<<4|byte(AudioPacketMultitrack))
+		return appendMultitrackHeader(dst, t.MultitrackType, byte(t.PacketType), t.FourCC)
+	}
+
+	dst = append(dst, byte(AudioFormatExHeader)<<4|byte(t.PacketType)&0x0F)
+	dst = appendUI32(dst, uint32(t.FourCC))
+	if t.PacketType == AudioPacketMultichannelConfig && t.MultichannelConfig != nil {
+		dst = t.MultichannelConfig.append(dst)
+	}
+	return dst
+}
+
+// Bytes returns the encoded header followed by the payload (multitrack: 트랙 본문)
+func (t *AudioTag) Bytes() []byte {
+	if t.Multitrack {
+		dst := t.AppendHeader(nil)
+		return appendTracks(dst, t.MultitrackType, t.Tracks, func(dst []byte, track *Track) []byte {
+			if t.PacketType == AudioPacketMultichannelConfig && track.MultichannelConfig != nil {
+				dst = track.MultichannelConfig.append(dst)
+			}
+			return append(dst, track.Payload...)
+		})
+	}
+
+	dst := make([]byte, 0, t.HeaderSize()+len(t.Payload))
+	dst = t.AppendHeader(dst)
+	return append(dst, t.Payload...)
+}
+
+// size returns the encoded multichannel config length
+func (c *AudioMultichannelConfig) size() int {
+	switch c.ChannelOrder {
+	case AudioChannelOrderCustom:
+		return 2 + int(c.ChannelCount)
+	case AudioChannelOrderNative:
+		return 6
+	default:
+		return 2
+	}
+}
+
+// append appends the encoded multichannel config to dst
+func (c *AudioMultichannelConfig) append(dst []byte) []byte {
+	dst = append(dst, byte(c.ChannelOrder), c.ChannelCount)
+	switch c.ChannelOrder {
+	case AudioChannelOrderCustom:
+		// ChannelCount 길이로 맞춤
+		mapping := make([]byte, c.ChannelCount)
+		copy(mapping, c.ChannelMapping)
+		dst = append(dst, mapping...)
+	case AudioChannelOrderNative:
+		dst = appendUI32(dst, c.ChannelFlags)
+	}
+	return dst
+}
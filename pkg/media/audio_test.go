@@ -0,0 +1,169 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestParseAudioTag_Legacy(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      []byte
+		format    AudioSoundFormat
+		fourCC    FourCC
+		packet    AudioPacketType
+		payload   []byte
+		seqHeader bool
+	}{
+		{"aac sequence header", []byte{0xAF, 0x00, 0x12, 0x10}, AudioFormatAAC, FourCCAAC, AudioPacketSequenceStart, []byte{0x12, 0x10}, true},
+		{"aac raw", []byte{0xAF, 0x01, 0x21}, AudioFormatAAC, FourCCAAC, AudioPacketCodedFrames, []byte{0x21}, false},
+		{"mp3", []byte{0x2F, 0xFF, 0xFB}, AudioFormatMP3, FourCCMP3, AudioPacketCodedFrames, []byte{0xFF, 0xFB}, false},
+		{"speex", []byte{0xB2, 0x01}, AudioFormatSpeex, 0, 0, []byte{0x01}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tag, err := ParseAudioTag(tt.data)
+			if err != nil {
+				t.Fatalf("ParseAudioTag failed: %v", err)
+			}
+			if tag.IsExHeader {
+				t.Error("expected legacy header")
+			}
+			if tag.SoundFormat != tt.format || tag.FourCC != tt.fourCC || tag.PacketType != tt.packet {
+				t.Errorf("unexpected header: %+v", tag)
+			}
+			if !bytes.Equal(tag.Payload, tt.payload) {
+				t.Errorf("expected payload %x, got %x", tt.payload, tag.Payload)
+			}
+			if tag.IsSequenceHeader() != tt.seqHeader {
+				t.Errorf("expected IsSequenceHeader %v", tt.seqHeader)
+			}
+			if !bytes.Equal(tag.Bytes(), tt.data) {
+				t.Errorf("round trip mismatch: %x", tag.Bytes())
+			}
+		})
+	}
+}
+
+func TestParseAudioTag_Extended(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      []byte
+		fourCC    FourCC
+		packet    AudioPacketType
+		payload   []byte
+		seqHeader bool
+		seqEnd    bool
+	}{
+		{"opus sequence start", []byte{0x90, 'O', 'p', 'u', 's', 'O', 'H'}, FourCCOpus, AudioPacketSequenceStart, []byte("OH"), true, false},
+		{"opus coded frames", []byte{0x91, 'O', 'p', 'u', 's', 0xFC}, FourCCOpus, AudioPacketCodedFrames, []byte{0xFC}, false, false},
+		{"flac sequence start", []byte{0x90, 'f', 'L', 'a', 'C', 0x00}, FourCCFLAC, AudioPacketSequenceStart, []byte{0x00}, true, false},
+		{"ac-3 coded frames", []byte{0x91, 'a', 'c', '-', '3', 0x0B, 0x77}, FourCCAC3, AudioPacketCodedFrames, []byte{0x0B, 0x77}, false, false},
+		{"e-ac-3 coded frames", []byte{0x91, 'e', 'c', '-', '3', 0x0B}, FourCCEAC3, AudioPacketCodedFrames, []byte{0x0B}, false, false},
+		{"mp3 coded frames", []byte{0x91, '.', 'm', 'p', '3', 0xFF}, FourCCMP3, AudioPacketCodedFrames, []byte{0xFF}, false, false},
+		{"aac sequence end", []byte{0x92, 'm', 'p', '4', 'a'}, FourCCAAC, AudioPacketSequenceEnd, []byte{}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tag, err := ParseAudioTag(tt.data)
+			if err != nil {
+				t.Fatalf("ParseAudioTag failed: %v", err)
+			}
+			if !tag.IsExHeader || tag.SoundFormat != AudioFormatExHeader {
+				t.Error("expected extended header")
+			}
+			if tag.FourCC != tt.fourCC {
+				t.Errorf("expected FourCC %s, got %s", tt.fourCC, tag.FourCC)
+			}
+			if tag.PacketType != tt.packet {
+				t.Errorf("expected packet type %d, got %d", tt.packet, tag.PacketType)
+			}
+			if !bytes.Equal(tag.Payload, tt.payload) {
+				t.Errorf("expected payload %x, got %x", tt.payload, tag.Payload)
+			}
+			if tag.IsSequenceHeader() != tt.seqHeader || tag.IsSequenceEnd() != tt.seqEnd {
+				t.Errorf("unexpected sequence flags: header=%v end=%v", tag.IsSequenceHeader(), tag.IsSequenceEnd())
+			}
+			if !bytes.Equal(tag.Bytes(), tt.data) {
+				t.Errorf("round trip mismatch: %x", tag.Bytes())
+			}
+		})
+	}
+}
+
+func TestParseAudioTag_MultichannelConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []byte
+		config AudioMultichannelConfig
+	}{
+		{
+			name:   "unspecified",
+			data:   []byte{0x94, 'O', 'p', 'u', 's', 0x00, 0x02},
+			config: AudioMultichannelConfig{ChannelOrder: AudioChannelOrderUnspecified, ChannelCount: 2},
+		},
+		{
+			name:   "native",
+			data:   []byte{0x94, 'O', 'p', 'u', 's', 0x01, 0x06, 0x00, 0x00, 0x00, 0x3F},
+			config: AudioMultichannelConfig{ChannelOrder: AudioChannelOrderNative, ChannelCount: 6, ChannelFlags: 0x3F},
+		},
+		{
+			name:   "custom",
+			data:   []byte{0x94, 'f', 'L', 'a', 'C', 0x02, 0x03, 0x00, 0x01, 0x03},
+			config: AudioMultichannelConfig{ChannelOrder: AudioChannelOrderCustom, ChannelCount: 3, ChannelMapping: []uint8{0, 1, 3}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tag, err := ParseAudioTag(tt.data)
+			if err != nil {
+				t.Fatalf("ParseAudioTag failed: %v", err)
+			}
+			config := tag.MultichannelConfig
+			if config == nil {
+				t.Fatal("expected multichannel config")
+			}
+			if config.ChannelOrder != tt.config.ChannelOrder || config.ChannelCount != tt.config.ChannelCount ||
+				config.ChannelFlags != tt.config.ChannelFlags || !bytes.Equal(config.ChannelMapping, tt.config.ChannelMapping) {
+				t.Errorf("expected %+v, got %+v", tt.config, *config)
+			}
+			if len(tag.Payload) != 0 {
+				t.Errorf("expected empty payload, got %x", tag.Payload)
+			}
+
+			built := &AudioTag{
+				IsExHeader:         true,
+				FourCC:             tag.FourCC,
+				PacketType:         AudioPacketMultichannelConfig,
+				MultichannelConfig: &tt.config,
+			}
+			if !bytes.Equal(built.Bytes(), tt.data) {
+				t.Errorf("construction mismatch: %x", built.Bytes())
+			}
+		})
+	}
+}
+
+func TestParseAudioTag_Errors(t *testing.T) {
+	tests := []struct {
+		data []byte
+		err  error
+	}{
+		{nil, ErrShortTag},
+		{[]byte{0xAF}, ErrShortTag},
+		{[]byte{0x91, 'O', 'p'}, ErrShortTag},
+		{[]byte{0x94, 'O', 'p', 'u', 's', 0x02, 0x04, 0x00}, ErrShortTag},
+		{[]byte{0x94, 'O', 'p', 'u', 's', 0x01, 0x02, 0x00}, ErrShortTag},
+		{[]byte{0x93, 'O', 'p', 'u', 's'}, ErrUnsupportedPacketType},
+	}
+
+	for _, tt := range tests {
+		if _, err := ParseAudioTag(tt.data); !errors.Is(err, tt.err) {
+			t.Errorf("%x: expected %v, got %v", tt.data, tt.err, err)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package media
+
+// readUI32 reads a big-endian uint32
+func readUI32(b []byte) uint32 {
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3])
+}
+
+// readSI24 reads a big-endian signed 24-bit integer
+func readSI24(b []byte) int32 {
+	v := int32(b[0])<<16 | int32(b[1])<<8 | int32(b[2])
+	if v&0x800000 != 0 {
+		v -= 1 << 24
+	}
+	return v
+}
+
+// appendUI32 appends a big-endian uint32
+func appendUI32(dst []byte, v uint32) []byte {
+	return append(dst, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+// appendSI24 appends a big-endian signed 24-bit integer
+func appendSI24(dst []byte, v int32) []byte {
+	u := uint32(v) & 0xFFFFFF
+	return append(dst, byte(u>>16), byte(u>>8), byte(u))
+}
+
+// readUI24 reads a big-endian uint24
+func readUI24(b []byte) uint32 {
+	return uint32(b[0])<<16 | uint32(b[1])<<8 | uint32(b[2])
+}
+
+// putUI24 writes a big-endian uint24
+func putUI24(b []byte, v uint32) {
+	b[0] = byte(v >> 16)
+	b[1] = byte(v >> 8)
+	b[2] = byte(v)
+}
@@ -0,0 +1,11 @@
+package media
+
+import "errors"
+
+var (
+	// ErrShortTag is returned when a tag body is shorter than its header
+	ErrShortTag = errors.New("media: tag too short")
+
+	// ErrUnsupportedPacketType is returned for unknown E-RTMP packet types
+	ErrUnsupportedPacketType = errors.New("media: unsupported packet type")
+)
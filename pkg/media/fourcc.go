@@ -0,0 +1,37 @@
+// Package media parses and writes FLV/RTMP audio and video tag headers,
+// including the Enhanced RTMP (E-RTMP) extended headers.
+package media
+
+// FourCC identifies an Enhanced RTMP codec
+type FourCC uint32
+
+// Video FourCC codes
+const (
+	FourCCAVC  FourCC = 'a'<<24 | 'v'<<16 | 'c'<<8 | '1' // H.264
+	FourCCHEVC FourCC = 'h'<<24 | 'v'<<16 | 'c'<<8 | '1' // H.265
+	FourCCAV1  FourCC = 'a'<<24 | 'v'<<16 | '0'<<8 | '1'
+	FourCCVP9  FourCC = 'v'<<24 | 'p'<<16 | '0'<<8 | '9'
+)
+
+// Audio FourCC codes
+const (
+	FourCCOpus FourCC = 'O'<<24 | 'p'<<16 | 'u'<<8 | 's'
+	FourCCFLAC FourCC = 'f'<<24 | 'L'<<16 | 'a'<<8 | 'C'
+	FourCCAC3  FourCC = 'a'<<24 | 'c'<<16 | '-'<<8 | '3'
+	FourCCEAC3 FourCC = 'e'<<24 | 'c'<<16 | '-'<<8 | '3'
+	FourCCMP3  FourCC = '.'<<24 | 'm'<<16 | 'p'<<8 | '3'
+	FourCCAAC  FourCC = 'm'<<24 | 'p'<<16 | '4'<<8 | 'a'
+)
+
+// ParseFourCC converts a 4-character string (e.g. "hvc1") to a FourCC
+func ParseFourCC(s string) (FourCC, bool) {
+	if len(s) != 4 {
+		return 0, false
+	}
+	return FourCC(s[0])<<24 | FourCC(s[1])<<16 | FourCC(s[2])<<8 | FourCC(s[3]), true
+}
+
+// String returns the 4-character code
+func (f FourCC) String() string {
+	return string([]byte{byte(f >> 24), byte(f >> 16), byte(f >> 8), byte(f)})
+}
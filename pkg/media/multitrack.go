@@ -0,0 +1,195 @@
+package media
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// AvMultitrackType is the track layout of an E-RTMP multitrack packet
+type AvMultitrackType uint8
+
+const (
+	MultitrackOneTrack             AvMultitrackType = 0 // 트랙 1개 (track ID만 지정)
+	MultitrackManyTracks           AvMultitrackType = 1 // 같은 코덱의 여러 트랙
+	MultitrackManyTracksManyCodecs AvMultitrackType = 2 // 트랙별 코덱 (트랙마다 FourCC)
+)
+
+// Multitrack packet types (E-RTMP v2)
+const (
+	VideoPacketMultitrack VideoPacketType = 6
+	AudioPacketMultitrack AudioPacketType = 5
+)
+
+// Track is one track of a multitrack audio or video tag
+type Track struct {
+	ID     uint8
+	FourCC FourCC
+
+	// CompositionTime is set for AVC/HEVC video CodedFrames
+	CompositionTime int32
+
+	// MultichannelConfig is set for audio MultichannelConfig packets
+	MultichannelConfig *AudioMultichannelConfig
+
+	// Payload is the track body (입력 슬라이스를 참조)
+	Payload []byte
+}
+
+// TrackSet is a set of track IDs; a nil TrackSet contains every track
+type TrackSet map[uint8]struct{}
+
+// ParseTrackSet parses a comma separated track ID list (e.g. "0,2").
+// 빈 문자열은 nil (모든 트랙) 반환
+func ParseTrackSet(s string) (TrackSet, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	set := make(TrackSet)
+	for _, field := range strings.Split(s, ",") {
+		id, err := strconv.ParseUint(strings.TrimSpace(field), 10, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid track ID %q: %w", field, err)
+		}
+		set[uint8(id)] = struct{}{}
+	}
+	return set, nil
+}
+
+// Contains reports whether the set contains the track ID
+func (s TrackSet) Contains(id uint8) bool {
+	if s == nil {
+		return true
+	}
+	_, ok := s[id]
+	return ok
+}
+
+// IDs returns the track IDs in ascending order (nil for the all-tracks set)
+func (s TrackSet) IDs() []uint8 {
+	if s == nil {
+		return nil
+	}
+	ids := make([]uint8, 0, len(s))
+	for id := range s {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// parseMultitrackHeader parses the AvMultitrackType/PacketType byte and the shared FourCC.
+// Returns the header length.
+func parseMultitrackHeader(data []byte) (mtType AvMultitrackType, packetType uint8, fourCC FourCC, n int, err error) {
+	if len(data) < 1 {
+		return 0, 0, 0, 0, fmt.Errorf("multitrack header: %w", ErrShortTag)
+	}
+	mtType = AvMultitrackType(data[0] >> 4)
+	packetType = data[0] & 0x0F
+	n = 1
+
+	if mtType > MultitrackManyTracksManyCodecs {
+		return 0, 0, 0, 0, fmt.Errorf("multitrack type %d: %w", mtType, ErrUnsupportedPacketType)
+	}
+
+	// ManyTracksManyCodecs가 아니면 모든 트랙이 같은 FourCC 공유
+	if mtType != MultitrackManyTracksManyCodecs {
+		if len(data) < n+4 {
+			return 0, 0, 0, 0, fmt.Errorf("multitrack fourcc: %w", ErrShortTag)
+		}
+		fourCC = FourCC(readUI32(data[n : n+4]))
+		n += 4
+	}
+	return mtType, packetType, fourCC, n, nil
+}
+
+// parseTracks splits multitrack framing into tracks; parseBody fills codec specific fields
+func parseTracks(data []byte, mtType AvMultitrackType, fourCC FourCC, parseBody func(track *Track, body []byte) error) ([]Track, error) {
+	var tracks []Track
+	for len(data) > 0 {
+		track := Track{FourCC: fourCC}
+
+		if mtType == MultitrackManyTracksManyCodecs {
+			if len(data) < 4 {
+				return nil, fmt.Errorf("track fourcc: %w", ErrShortTag)
+			}
+			track.FourCC = FourCC(readUI32(data[:4]))
+			data = data[4:]
+		}
+
+		if len(data) < 1 {
+			return nil, fmt.Errorf("track id: %w", ErrShortTag)
+		}
+		track.ID = data[0]
+		data = data[1:]
+
+		// OneTrack은 나머지 전체가 트랙 본문
+		body := data
+		data = nil
+		if mtType != MultitrackOneTrack {
+			if len(body) < 3 {
+				return nil, fmt.Errorf("track size: %w", ErrShortTag)
+			}
+			size := int(readUI24(body[:3]))
+			body = body[3:]
+			if len(body) < size {
+				return nil, fmt.Errorf("track %d body: %w", track.ID, ErrShortTag)
+			}
+			body, data = body[:size], body[size:]
+		}
+
+		if err := parseBody(&track, body); err != nil {
+			return nil, err
+		}
+		tracks = append(tracks, track)
+	}
+
+	if len(tracks) == 0 {
+		return nil, fmt.Errorf("multitrack without tracks: %w", ErrShortTag)
+	}
+	return tracks, nil
+}
+
+// appendMultitrackHeader appends the AvMultitrackType/PacketType byte and the shared FourCC
+func appendMultitrackHeader(dst []byte, mtType AvMultitrackType, packetType uint8, fourCC FourCC) []byte {
+	dst = append(dst, byte(mtType)<<4|packetType&0x0F)
+	if mtType != MultitrackManyTracksManyCodecs {
+		dst = appendUI32(dst, uint32(fourCC))
+	}
+	return dst
+}
+
+// appendTracks appends multitrack framing; appendBody writes codec specific fields and payload
+func appendTracks(dst []byte, mtType AvMultitrackType, tracks []Track, appendBody func(dst []byte, track *Track) []byte) []byte {
+	for i := range tracks {
+		track := &tracks[i]
+		if mtType == MultitrackManyTracksManyCodecs {
+			dst = appendUI32(dst, uint32(track.FourCC))
+		}
+		dst = append(dst, track.ID)
+
+		if mtType == MultitrackOneTrack {
+			return appendBody(dst, track)
+		}
+
+		// 트랙 크기는 본문 기록 후 채움
+		sizeAt := len(dst)
+		dst = append(dst, 0, 0, 0)
+		dst = appendBody(dst, track)
+		putUI24(dst[sizeAt:], uint32(len(dst)-sizeAt-3))
+	}
+	return dst
+}
+
+// selectTracks returns the tracks contained in set
+func selectTracks(tracks []Track, set TrackSet) []Track {
+	selected := make([]Track, 0, len(tracks))
+	for _, track := range tracks {
+		if set.Contains(track.ID) {
+			selected = append(selected, track)
+		}
+	}
+	return selected
+}
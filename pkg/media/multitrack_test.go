@@ -0,0 +1,194 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestParseVideoTag_Multitrack(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []byte
+		mtType AvMultitrackType
+		packet VideoPacketType
+		tracks []Track
+	}{
+		{
+			name: "one track",
+			// key frame, multitrack / OneTrack, CodedFrames / hvc1 / track 2 / cts 40
+			data:   []byte{0x96, 0x01, 'h', 'v', 'c', '1', 0x02, 0x00, 0x00, 0x28, 0xAA},
+			mtType: MultitrackOneTrack, packet: VideoPacketCodedFrames,
+			tracks: []Track{{ID: 2, FourCC: FourCCHEVC, CompositionTime: 40, Payload: []byte{0xAA}}},
+		},
+		{
+			name: "many tracks",
+			data: []byte{0x96, 0x10, 'a', 'v', '0', '1',
+				0x00, 0x00, 0x00, 0x02, 0x0A, 0x0B,
+				0x01, 0x00, 0x00, 0x01, 0x0C},
+			mtType: MultitrackManyTracks, packet: VideoPacketSequenceStart,
+			tracks: []Track{
+				{ID: 0, FourCC: FourCCAV1, Payload: []byte{0x0A, 0x0B}},
+				{ID: 1, FourCC: FourCCAV1, Payload: []byte{0x0C}},
+			},
+		},
+		{
+			name: "many tracks many codecs",
+			data: []byte{0x96, 0x21,
+				'a', 'v', 'c', '1', 0x00, 0x00, 0x00, 0x04, 0x00, 0x00, 0x00, 0x01,
+				'v', 'p', '0', '9', 0x01, 0x00, 0x00, 0x01, 0x02},
+			mtType: MultitrackManyTracksManyCodecs, packet: VideoPacketCodedFrames,
+			tracks: []Track{
+				{ID: 0, FourCC: FourCCAVC, Payload: []byte{0x01}},
+				{ID: 1, FourCC: FourCCVP9, Payload: []byte{0x02}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tag, err := ParseVideoTag(tt.data)
+			if err != nil {
+				t.Fatalf("ParseVideoTag failed: %v", err)
+			}
+			if !tag.Multitrack || tag.MultitrackType != tt.mtType {
+				t.Errorf("expected multitrack type %d, got %+v", tt.mtType, tag)
+			}
+			if tag.PacketType != tt.packet {
+				t.Errorf("expected packet type %d, got %d", tt.packet, tag.PacketType)
+			}
+			assertTracks(t, tag.Tracks, tt.tracks)
+			if !bytes.Equal(tag.Bytes(), tt.data) {
+				t.Errorf("round trip mismatch: %x", tag.Bytes())
+			}
+		})
+	}
+}
+
+func TestParseAudioTag_Multitrack(t *testing.T) {
+	data := []byte{0x95, 0x11, 'O', 'p', 'u', 's',
+		0x00, 0x00, 0x00, 0x01, 0xF0,
+		0x01, 0x00, 0x00, 0x02, 0xF1, 0xF2}
+
+	tag, err := ParseAudioTag(data)
+	if err != nil {
+		t.Fatalf("ParseAudioTag failed: %v", err)
+	}
+	if !tag.Multitrack || tag.MultitrackType != MultitrackManyTracks || tag.PacketType != AudioPacketCodedFrames {
+		t.Errorf("unexpected header: %+v", tag)
+	}
+	assertTracks(t, tag.Tracks, []Track{
+		{ID: 0, FourCC: FourCCOpus, Payload: []byte{0xF0}},
+		{ID: 1, FourCC: FourCCOpus, Payload: []byte{0xF1, 0xF2}},
+	})
+	if !bytes.Equal(tag.Bytes(), data) {
+		t.Errorf("round trip mismatch: %x", tag.Bytes())
+	}
+
+	// 트랙별 multichannel config
+	data = []byte{0x95, 0x04, 'O', 'p', 'u', 's', 0x03, 0x00, 0x02}
+	tag, err = ParseAudioTag(data)
+	if err != nil {
+		t.Fatalf("ParseAudioTag failed: %v", err)
+	}
+	if len(tag.Tracks) != 1 || tag.Tracks[0].ID != 3 || tag.Tracks[0].MultichannelConfig == nil ||
+		tag.Tracks[0].MultichannelConfig.ChannelCount != 2 {
+		t.Errorf("unexpected multichannel track: %+v", tag.Tracks)
+	}
+	if !bytes.Equal(tag.Bytes(), data) {
+		t.Errorf("round trip mismatch: %x", tag.Bytes())
+	}
+}
+
+func TestSelectTracks(t *testing.T) {
+	data := []byte{0x95, 0x11, 'O', 'p', 'u', 's',
+		0x00, 0x00, 0x00, 0x01, 0xF0,
+		0x01, 0x00, 0x00, 0x01, 0xF1,
+		0x02, 0x00, 0x00, 0x01, 0xF2}
+	tag, err := ParseAudioTag(data)
+	if err != nil {
+		t.Fatalf("ParseAudioTag failed: %v", err)
+	}
+
+	set, err := ParseTrackSet("2, 0")
+	if err != nil {
+		t.Fatalf("ParseTrackSet failed: %v", err)
+	}
+	if ids := set.IDs(); !bytes.Equal(ids, []uint8{0, 2}) {
+		t.Errorf("expected IDs [0 2], got %v", ids)
+	}
+
+	selected := tag.SelectTracks(set)
+	if selected == nil || len(selected.Tracks) != 2 {
+		t.Fatalf("expected 2 tracks, got %+v", selected)
+	}
+	want := []byte{0x95, 0x11, 'O', 'p', 'u', 's',
+		0x00, 0x00, 0x00, 0x01, 0xF0,
+		0x02, 0x00, 0x00, 0x01, 0xF2}
+	if !bytes.Equal(selected.Bytes(), want) {
+		t.Errorf("expected %x, got %x", want, selected.Bytes())
+	}
+	if len(tag.Tracks) != 3 {
+		t.Error("SelectTracks must not modify the original tag")
+	}
+
+	if tag.SelectTracks(TrackSet{5: {}}) != nil {
+		t.Error("expected nil when no track is selected")
+	}
+	if tag.SelectTracks(nil) == nil {
+		t.Error("nil TrackSet must select all tracks")
+	}
+
+	// 단일 트랙 태그는 track 0
+	video, _ := ParseVideoTag([]byte{0x17, 0x01, 0x00, 0x00, 0x00})
+	if video.SelectTracks(set) != video {
+		t.Error("single-track tag should be selected as track 0")
+	}
+	if video.SelectTracks(TrackSet{1: {}}) != nil {
+		t.Error("single-track tag should not match track 1")
+	}
+
+	if _, err := ParseTrackSet("0,x"); err == nil {
+		t.Error("expected error for invalid track ID")
+	}
+}
+
+func TestParseMultitrack_Errors(t *testing.T) {
+	tests := []struct {
+		data []byte
+		err  error
+	}{
+		{[]byte{0x96}, ErrShortTag},
+		{[]byte{0x96, 0x01, 'h', 'v'}, ErrShortTag},
+		{[]byte{0x96, 0x01, 'h', 'v', 'c', '1'}, ErrShortTag},
+		{[]byte{0x96, 0x10, 'h', 'v', 'c', '1', 0x00, 0x00, 0x00, 0x05, 0x01}, ErrShortTag},
+		{[]byte{0x96, 0x30, 'h', 'v', 'c', '1'}, ErrUnsupportedPacketType},
+		{[]byte{0x96, 0x06, 'h', 'v', 'c', '1', 0x00}, ErrUnsupportedPacketType},
+		{[]byte{0x95, 0x05, 'O', 'p', 'u', 's', 0x00}, ErrUnsupportedPacketType},
+	}
+
+	for _, tt := range tests {
+		var err error
+		if tt.data[0]>>4 == byte(AudioFormatExHeader) && tt.data[0]&0x0F == byte(AudioPacketMultitrack) {
+			_, err = ParseAudioTag(tt.data)
+		} else {
+			_, err = ParseVideoTag(tt.data)
+		}
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%x: expected %v, got %v", tt.data, tt.err, err)
+		}
+	}
+}
+
+func assertTracks(t *testing.T, got, want []Track) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("expected %d tracks, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if got[i].ID != want[i].ID || got[i].FourCC != want[i].FourCC ||
+			got[i].CompositionTime != want[i].CompositionTime || !bytes.Equal(got[i].Payload, want[i].Payload) {
+			t.Errorf("track %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+}
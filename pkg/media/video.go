@@ -0,0 +1,273 @@
+package media
+
+import "fmt"
+
+// VideoFrameType is the frame type of a video tag
+type VideoFrameType uint8
+
+const (
+	VideoFrameKey             VideoFrameType = 1
+	VideoFrameInter           VideoFrameType = 2
+	VideoFrameDisposableInter VideoFrameType = 3 // H.263 전용
+	VideoFrameGenerated       VideoFrameType = 4
+	VideoFrameCommand         VideoFrameType = 5 // video info/command frame
+)
+
+// VideoCodecID is the legacy (non-extended) video codec ID
+type VideoCodecID uint8
+
+const (
+	VideoCodecH263         VideoCodecID = 2
+	VideoCodecScreenVideo  VideoCodecID = 3
+	VideoCodecVP6          VideoCodecID = 4
+	VideoCodecVP6Alpha     VideoCodecID = 5
+	VideoCodecScreenVideo2 VideoCodecID = 6
+	VideoCodecAVC          VideoCodecID = 7
+)
+
+// VideoPacketType is the E-RTMP video packet type.
+// Legacy AVC의 AVCPacketType(0: seq header, 1: NALU, 2: end of seq)도 같은 값으로 매핑됨
+type VideoPacketType uint8
+
+const (
+	VideoPacketSequenceStart        VideoPacketType = 0
+	VideoPacketCodedFrames          VideoPacketType = 1
+	VideoPacketSequenceEnd          VideoPacketType = 2
+	VideoPacketCodedFramesX         VideoPacketType = 3 // composition time 생략 (0)
+	VideoPacketMetadata             VideoPacketType = 4
+	VideoPacketMPEG2TSSequenceStart VideoPacketType = 5
+)
+
+// isExHeaderFlag marks an extended (E-RTMP) video tag header
+const isExHeaderFlag = 0x80
+
+// VideoTag is a parsed video message body (FLV VIDEODATA)
+type VideoTag struct {
+	FrameType  VideoFrameType
+	IsExHeader bool
+
+	// CodecID is the legacy codec ID (0 for extended headers)
+	CodecID VideoCodecID
+
+	// FourCC is the codec of an extended header (legacy AVC도 FourCCAVC로 설정)
+	FourCC FourCC
+
+	// PacketType is set for extended headers and legacy AVC
+	PacketType VideoPacketType
+
+	// CompositionTime is the signed 24-bit composition time offset in milliseconds
+	CompositionTime int32
+
+	// Payload is the data following the header (입력 슬라이스를 참조)
+	Payload []byte
+
+	// Multitrack tags carry their tracks in Tracks instead of Payload.
+	// PacketType은 모든 트랙에 공통인 packet type, FourCC는 공유 코덱 (ManyTracksManyCodecs 제외)
+	Multitrack     bool
+	MultitrackType AvMultitrackType
+	Tracks         []Track
+}
+
+// ParseVideoTag parses a video message body.
+// Payload는 data를 복사하지 않고 참조함
+func ParseVideoTag(data []byte) (*VideoTag, error) {
+	if len(data) < 1 {
+		return nil, fmt.Errorf("video tag header: %w", ErrShortTag)
+	}
+
+	if data[0]&isExHeaderFlag != 0 {
+		return parseExVideoTag(data)
+	}
+
+	tag := &VideoTag{
+		FrameType: VideoFrameType(data[0] >> 4),
+		CodecID:   VideoCodecID(data[0] & 0x0F),
+	}
+
+	if tag.CodecID != VideoCodecAVC {
+		tag.Payload = data[1:]
+		return tag, nil
+	}
+
+	// AVC: AVCPacketType(1) + CompositionTime(3)
+	if len(data) < 5 {
+		return nil, fmt.Errorf("avc video tag header: %w", ErrShortTag)
+	}
+	tag.FourCC = FourCCAVC
+	tag.PacketType = VideoPacketType(data[1])
+	tag.CompositionTime = readSI24(data[2:5])
+	tag.Payload = data[5:]
+	return tag, nil
+}
+
+// parseExVideoTag parses an E-RTMP extended video tag header
+func parseExVideoTag(data []byte) (*VideoTag, error) {
+	tag := &VideoTag{
+		IsExHeader: true,
+		FrameType:  VideoFrameType((data[0] >> 4) & 0x07),
+		PacketType: VideoPacketType(data[0] & 0x0F),
+	}
+
+	if tag.PacketType == VideoPacketMultitrack {
+		return parseMultitrackVideoTag(tag, data[1:])
+	}
+	if tag.PacketType > VideoPacketMPEG2TSSequenceStart {
+		return nil, fmt.Errorf("video packet type %d: %w", tag.PacketType, ErrUnsupportedPacketType)
+	}
+
+	if len(data) < 5 {
+		return nil, fmt.Errorf("extended video tag header: %w", ErrShortTag)
+	}
+	tag.FourCC = FourCC(readUI32(data[1:5]))
+	offset := 5
+
+	// AVC/HEVC CodedFrames에만 composition time 포함
+	if tag.PacketType == VideoPacketCodedFrames && hasCompositionTime(tag.FourCC) {
+		if len(data) < offset+3 {
+			return nil, fmt.Errorf("extended video composition time: %w", ErrShortTag)
+		}
+		tag.CompositionTime = readSI24(data[offset : offset+3])
+		offset += 3
+	}
+
+	tag.Payload = data[offset:]
+	return tag, nil
+}
+
+// parseMultitrackVideoTag parses the multitrack part of an extended video tag
+func parseMultitrackVideoTag(tag *VideoTag, data []byte) (*VideoTag, error) {
+	mtType, packetType, fourCC, n, err := parseMultitrackHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	tag.Multitrack = true
+	tag.MultitrackType = mtType
+	tag.PacketType = VideoPacketType(packetType)
+	tag.FourCC = fourCC
+
+	if tag.PacketType >= VideoPacketMultitrack {
+		return nil, fmt.Errorf("multitrack video packet type %d: %w", tag.PacketType, ErrUnsupportedPacketType)
+	}
+
+	tag.Tracks, err = parseTracks(data[n:], mtType, fourCC, func(track *Track, body []byte) error {
+		if tag.PacketType == VideoPacketCodedFrames && hasCompositionTime(track.FourCC) {
+			if len(body) < 3 {
+				return fmt.Errorf("track %d composition time: %w", track.ID, ErrShortTag)
+			}
+			track.CompositionTime = readSI24(body[:3])
+			body = body[3:]
+		}
+		track.Payload = body
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+// SelectTracks returns a copy of the tag with only the tracks in set.
+// 단일 트랙 태그는 track 0으로 취급하며, 선택된 트랙이 없으면 nil 반환
+func (t *VideoTag) SelectTracks(set TrackSet) *VideoTag {
+	if !t.Multitrack {
+		if !set.Contains(0) {
+			return nil
+		}
+		return t
+	}
+
+	tracks := selectTracks(t.Tracks, set)
+	if len(tracks) == 0 {
+		return nil
+	}
+	selected := *t
+	selected.Tracks = tracks
+	return &selected
+}
+
+// IsSequenceHeader reports whether the tag carries a decoder configuration record
+func (t *VideoTag) IsSequenceHeader() bool {
+	if !t.IsExHeader && t.CodecID != VideoCodecAVC {
+		return false
+	}
+	return t.PacketType == VideoPacketSequenceStart || (t.IsExHeader && t.PacketType == VideoPacketMPEG2TSSequenceStart)
+}
+
+// IsSequenceEnd reports whether the tag marks the end of a sequence
+func (t *VideoTag) IsSequenceEnd() bool {
+	if !t.IsExHeader && t.CodecID != VideoCodecAVC {
+		return false
+	}
+	return t.PacketType == VideoPacketSequenceEnd
+}
+
+// IsKeyFrame reports whether the tag is a key frame
+func (t *VideoTag) IsKeyFrame() bool {
+	return t.FrameType == VideoFrameKey
+}
+
+// HeaderSize returns the encoded header length (multitrack: 트랙 본문 제외)
+func (t *VideoTag) HeaderSize() int {
+	if t.Multitrack {
+		if t.MultitrackType == MultitrackManyTracksManyCodecs {
+			return 2
+		}
+		return 6
+	}
+	if !t.IsExHeader {
+		if t.CodecID == VideoCodecAVC {
+			return 5
+		}
+		return 1
+	}
+	if t.PacketType == VideoPacketCodedFrames && hasCompositionTime(t.FourCC) {
+		return 8
+	}
+	return 5
+}
+
+// AppendHeader appends the encoded tag header to dst
+func (t *VideoTag) AppendHeader(dst []byte) []byte {
+	if !t.IsExHeader {
+		dst = append(dst, byte(t.FrameType)<<4|byte(t.CodecID)&0x0F)
+		if t.CodecID == VideoCodecAVC {
+			dst = append(dst, byte(t.PacketType))
+			dst = appendSI24(dst, t.CompositionTime)
+		}
+		return dst
+	}
+
+	if t.Multitrack {
+		dst = append(dst, isExHeaderFlag|(byte(t.FrameType)&0x07)<<4|byte(VideoPacketMultitrack))
+		return appendMultitrackHeader(dst, t.MultitrackType, byte(t.PacketType), t.FourCC)
+	}
+
+	dst = append(dst, isExHeaderFlag|(byte(t.FrameType)&0x07)<<4|byte(t.PacketType)&0x0F)
+	dst = appendUI32(dst, uint32(t.FourCC))
+	if t.PacketType == VideoPacketCodedFrames && hasCompositionTime(t.FourCC) {
+		dst = appendSI24(dst, t.CompositionTime)
+	}
+	return dst
+}
+
+// Bytes returns the encoded header followed by the payload (multitrack: 트랙 본문)
+func (t *VideoTag) Bytes() []byte {
+	if t.Multitrack {
+		dst := t.AppendHeader(nil)
+		return appendTracks(dst, t.MultitrackType, t.Tracks, func(dst []byte, track *Track) []byte {
+			if t.PacketType == VideoPacketCodedFrames && hasCompositionTime(track.FourCC) {
+				dst = appendSI24(dst, track.CompositionTime)
+			}
+			return append(dst, track.Payload...)
+		})
+	}
+
+	dst := make([]byte, 0, t.HeaderSize()+len(t.Payload))
+	dst = t.AppendHeader(dst)
+	return append(dst, t.Payload...)
+}
+
+// hasCompositionTime reports whether CodedFrames of the codec carry a composition time
+func hasCompositionTime(fourCC FourCC) bool {
+	return fourCC == FourCCAVC || fourCC == FourCCHEVC
+}
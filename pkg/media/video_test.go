@@ -0,0 +1,180 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestParseVideoTag_LegacyAVC(t *testing.T) {
+	// key frame, AVC, NALU, composition time -1
+	data := []byte{0x17, 0x01, 0xFF, 0xFF, 0xFF, 0xAA, 0xBB}
+
+	tag, err := ParseVideoTag(data)
+	if err != nil {
+		t.Fatalf("ParseVideoTag failed: %v", err)
+	}
+	if tag.IsExHeader {
+		t.Error("expected legacy header")
+	}
+	if tag.FrameType != VideoFrameKey || tag.CodecID != VideoCodecAVC || tag.FourCC != FourCCAVC {
+		t.Errorf("unexpected header: %+v", tag)
+	}
+	if tag.PacketType != VideoPacketCodedFrames {
+		t.Errorf("expected CodedFrames, got %d", tag.PacketType)
+	}
+	if tag.CompositionTime != -1 {
+		t.Errorf("expected composition time -1, got %d", tag.CompositionTime)
+	}
+	if !bytes.Equal(tag.Payload, []byte{0xAA, 0xBB}) {
+		t.Errorf("unexpected payload: %x", tag.Payload)
+	}
+	if !bytes.Equal(tag.Bytes(), data) {
+		t.Errorf("round trip mismatch: %x", tag.Bytes())
+	}
+}
+
+func TestParseVideoTag_LegacySequenceHeader(t *testing.T) {
+	tag, err := ParseVideoTag([]byte{0x17, 0x00, 0x00, 0x00, 0x00, 0x01})
+	if err != nil {
+		t.Fatalf("ParseVideoTag failed: %v", err)
+	}
+	if !tag.IsSequenceHeader() {
+		t.Error("expected AVC sequence header")
+	}
+
+	// legacy non-AVC 코덱은 packet type 없음
+	tag, err = ParseVideoTag([]byte{0x22, 0x00, 0x01})
+	if err != nil {
+		t.Fatalf("ParseVideoTag failed: %v", err)
+	}
+	if tag.IsSequenceHeader() || tag.CodecID != VideoCodecH263 || len(tag.Payload) != 2 {
+		t.Errorf("unexpected H.263 tag: %+v", tag)
+	}
+}
+
+func TestParseVideoTag_Extended(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      []byte
+		frameType VideoFrameType
+		packet    VideoPacketType
+		fourCC    FourCC
+		cts       int32
+		payload   []byte
+		seqHeader bool
+	}{
+		{
+			name:      "hevc sequence start",
+			data:      []byte{0x90, 'h', 'v', 'c', '1', 0x01, 0x02},
+			frameType: VideoFrameKey, packet: VideoPacketSequenceStart, fourCC: FourCCHEVC,
+			payload: []byte{0x01, 0x02}, seqHeader: true,
+		},
+		{
+			name:      "hevc coded frames with composition time",
+			data:      []byte{0xA1, 'h', 'v', 'c', '1', 0x00, 0x00, 0x28, 0xCC},
+			frameType: VideoFrameInter, packet: VideoPacketCodedFrames, fourCC: FourCCHEVC,
+			cts: 40, payload: []byte{0xCC},
+		},
+		{
+			name:      "hevc coded frames x",
+			data:      []byte{0x93, 'h', 'v', 'c', '1', 0xDD},
+			frameType: VideoFrameKey, packet: VideoPacketCodedFramesX, fourCC: FourCCHEVC,
+			payload: []byte{0xDD},
+		},
+		{
+			name:      "av1 coded frames without composition time",
+			data:      []byte{0x91, 'a', 'v', '0', '1', 0x12, 0x00},
+			frameType: VideoFrameKey, packet: VideoPacketCodedFrames, fourCC: FourCCAV1,
+			payload: []byte{0x12, 0x00},
+		},
+		{
+			name:      "av1 mpeg2ts sequence start",
+			data:      []byte{0x95, 'a', 'v', '0', '1', 0x80},
+			frameType: VideoFrameKey, packet: VideoPacketMPEG2TSSequenceStart, fourCC: FourCCAV1,
+			payload: []byte{0x80}, seqHeader: true,
+		},
+		{
+			name:      "vp9 sequence end",
+			data:      []byte{0x92, 'v', 'p', '0', '9'},
+			frameType: VideoFrameKey, packet: VideoPacketSequenceEnd, fourCC: FourCCVP9,
+			payload: []byte{},
+		},
+		{
+			name:      "metadata",
+			data:      []byte{0x94, 'h', 'v', 'c', '1', 0x02, 0x00},
+			frameType: VideoFrameKey, packet: VideoPacketMetadata, fourCC: FourCCHEVC,
+			payload: []byte{0x02, 0x00},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tag, err := ParseVideoTag(tt.data)
+			if err != nil {
+				t.Fatalf("ParseVideoTag failed: %v", err)
+			}
+			if !tag.IsExHeader {
+				t.Error("expected extended header")
+			}
+			if tag.FrameType != tt.frameType {
+				t.Errorf("expected frame type %d, got %d", tt.frameType, tag.FrameType)
+			}
+			if tag.PacketType != tt.packet {
+				t.Errorf("expected packet type %d, got %d", tt.packet, tag.PacketType)
+			}
+			if tag.FourCC != tt.fourCC {
+				t.Errorf("expected FourCC %s, got %s", tt.fourCC, tag.FourCC)
+			}
+			if tag.CompositionTime != tt.cts {
+				t.Errorf("expected composition time %d, got %d", tt.cts, tag.CompositionTime)
+			}
+			if !bytes.Equal(tag.Payload, tt.payload) {
+				t.Errorf("expected payload %x, got %x", tt.payload, tag.Payload)
+			}
+			if tag.IsSequenceHeader() != tt.seqHeader {
+				t.Errorf("expected IsSequenceHeader %v", tt.seqHeader)
+			}
+			if !bytes.Equal(tag.Bytes(), tt.data) {
+				t.Errorf("round trip mismatch: %x", tag.Bytes())
+			}
+		})
+	}
+}
+
+func TestParseVideoTag_Errors(t *testing.T) {
+	tests := []struct {
+		data []byte
+		err  error
+	}{
+		{nil, ErrShortTag},
+		{[]byte{0x17, 0x01}, ErrShortTag},
+		{[]byte{0x91, 'h', 'v'}, ErrShortTag},
+		{[]byte{0x91, 'h', 'v', 'c', '1', 0x00}, ErrShortTag},
+		{[]byte{0x9F, 'h', 'v', 'c', '1'}, ErrUnsupportedPacketType},
+	}
+
+	for _, tt := range tests {
+		if _, err := ParseVideoTag(tt.data); !errors.Is(err, tt.err) {
+			t.Errorf("%x: expected %v, got %v", tt.data, tt.err, err)
+		}
+	}
+}
+
+func TestFourCC(t *testing.T) {
+	for _, s := range []string{"avc1", "hvc1", "av01", "vp09"} {
+		f, ok := ParseFourCC(s)
+		if !ok {
+			t.Fatalf("ParseFourCC(%q) failed", s)
+		}
+		if f.String() != s {
+			t.Errorf("expected %q, got %q", s, f.String())
+		}
+	}
+	if f, _ := ParseFourCC("hvc1"); f != FourCCHEVC {
+		t.Errorf("expected FourCCHEVC, got %s", f)
+	}
+	if _, ok := ParseFourCC("hvc"); ok {
+		t.Error("expected failure for short FourCC")
+	}
+}
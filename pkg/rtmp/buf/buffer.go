@@ -28,6 +28,7 @@ import "sync/atomic"
 // Buffer represents a reference-counted buffer with custom finalizer
 type Buffer struct {
 	data      []byte
+	root      []byte // finalizer에 전달할 원본 (Slice로 만든 버퍼도 원본을 해제)
 	refCount  *atomic.Int32
 	finalizer func([]byte)
 }
@@ -50,6 +51,7 @@ func NewWithFinalizer(data []byte, finalizer func([]byte)) *Buffer {
 
 	return &Buffer{
 		data:      data,
+		root:      data,
 		refCount:  refCount,
 		finalizer: finalizer,
 	}
@@ -79,6 +81,19 @@ func (b *Buffer) Retain() {
 func (b *Buffer) Release() {
 	count := b.refCount.Add(-1)
 	if count == 0 && b.finalizer != nil {
-		b.finalizer(b.data)
+		b.finalizer(b.root)
+	}
+}
+
+// Slice returns a zero-copy view of Data()[start:end] sharing the reference count.
+// 참조 카운트를 증가시키므로 반환된 버퍼도 Release해야 하며,
+// 원본과 모든 view가 해제되면 원본 finalizer가 호출됨
+func (b *Buffer) Slice(start, end int) *Buffer {
+	b.Retain()
+	return &Buffer{
+		data:      b.data[start:end],
+		root:      b.root,
+		refCount:  b.refCount,
+		finalizer: b.finalizer,
 	}
 }
@@ -215,3 +215,33 @@ func BenchmarkAtomicInt32(b *testing.B) {
 		}
 	})
 }
+
+func TestBufferSlice(t *testing.T) {
+	var released []byte
+	data := []byte("0123456789")
+	buf := NewWithFinalizer(data, func(b []byte) {
+		released = b
+	})
+
+	view := buf.Slice(2, 5)
+	if string(view.Data()) != "234" {
+		t.Errorf("expected view data 234, got %q", view.Data())
+	}
+
+	// view는 원본 메모리를 공유
+	view.Data()[0] = 'x'
+	if data[2] != 'x' {
+		t.Error("view does not share memory with the original")
+	}
+
+	// 원본을 먼저 해제해도 view가 남아 있으면 finalizer 호출 안 됨
+	buf.Release()
+	if released != nil {
+		t.Fatal("finalizer called while a view is alive")
+	}
+
+	view.Release()
+	if len(released) != len(data) {
+		t.Errorf("finalizer got %d bytes, want the original %d", len(released), len(data))
+	}
+}
@@ -0,0 +1,226 @@
+package rtmp
+
+import (
+	"context"
+
+	"github.com/ssungk/ertmp/pkg/rtmp/transport"
+)
+
+// CallHandler handles a remote call received on the connection.
+// The returned values are sent back in _result; a returned error is sent as _error.
+// Calls with transaction ID 0 are notifications and get no response.
+type CallHandler func(conn *Conn, cmd *Command) ([]interface{}, error)
+
+// callResult is delivered to a waiting Call
+type callResult struct {
+	cmd *Command
+	err error
+}
+
+// HandleCall registers a handler for remote calls with the given command name.
+// Matching commands are consumed by ReadMessage and not returned to the caller.
+// A nil handler removes the registration.
+// handler는 읽기 잠금 없이 실행되므로 handler 안에서 Call을 호출할 수 있음
+func (c *Conn) HandleCall(name string, handler CallHandler) {
+	c.callMu.Lock()
+	defer c.callMu.Unlock()
+
+	if handler == nil {
+		delete(c.handlers, name)
+		return
+	}
+	c.handlers[name] = handler
+}
+
+// Call sends a command with a new transaction ID and waits for its _result or _error.
+// _error 응답은 *StatusError로 반환되며, ctx 취소/만료 시 ctx.Err() 반환.
+// ReadMessage를 호출하는 고루틴이 없으면 Call이 응답을 받을 때까지 직접 메시지를 읽고,
+// 그 사이 수신된 다른 메시지는 다음 ReadMessage에서 순서대로 반환됨.
+func (c *Conn) Call(ctx context.Context, name string, obj map[string]interface{}, args ...interface{}) (*Command, error) {
+	txID, ch := c.registerCall()
+	defer c.unregisterCall(txID)
+
+	if err := SendCommand(c, 0, name, txID, obj, args...); err != nil {
+		return nil, err
+	}
+
+	// 읽는 고루틴이 없으면 응답 수신용 pump 시작
+	if c.readMu.TryLock() {
+		go c.pump()
+	}
+
+	select {
+	case res := <-ch:
+		if res.err != nil {
+			return nil, res.err
+		}
+		if res.cmd.Name == "_error" {
+			return nil, newStatusError(name, statusInfo(res.cmd))
+		}
+		return res.cmd, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Notify sends a command with transaction ID 0 (no response expected)
+func (c *Conn) Notify(name string, obj map[string]interface{}, args ...interface{}) error {
+	return SendCommand(c, 0, name, 0, obj, args...)
+}
+
+// allocTxID returns the next transaction ID
+func (c *Conn) allocTxID() float64 {
+	c.callMu.Lock()
+	defer c.callMu.Unlock()
+
+	txID := c.nextTxID
+	c.nextTxID++
+	return txID
+}
+
+// registerCall allocates a transaction ID and its reply channel
+func (c *Conn) registerCall() (float64, chan callResult) {
+	c.callMu.Lock()
+	defer c.callMu.Unlock()
+
+	txID := c.nextTxID
+	c.nextTxID++
+
+	ch := make(chan callResult, 1)
+	c.calls[txID] = ch
+	return txID, ch
+}
+
+// unregisterCall removes a pending call
+func (c *Conn) unregisterCall(txID float64) {
+	c.callMu.Lock()
+	defer c.callMu.Unlock()
+	delete(c.calls, txID)
+}
+
+// hasPendingCalls reports whether any Call is waiting for a reply
+func (c *Conn) hasPendingCalls() bool {
+	c.callMu.Lock()
+	defer c.callMu.Unlock()
+	return len(c.calls) > 0
+}
+
+// failCalls completes all pending calls with err
+func (c *Conn) failCalls(err error) {
+	c.callMu.Lock()
+	defer c.callMu.Unlock()
+
+	for txID, ch := range c.calls {
+		ch <- callResult{err: err}
+		delete(c.calls, txID)
+	}
+}
+
+// pump reads and dispatches messages while calls are pending (readMu must be held)
+func (c *Conn) pump() {
+	for {
+		serve := c.pumpCalls()
+		c.readMu.Unlock()
+
+		// handler는 readMu 없이 실행 (handler 안의 Call이 새 pump로 응답을 읽음)
+		if serve != nil {
+			serve()
+		}
+
+		// Unlock 직후 등록된 Call이 pump를 시작하지 못했을 수 있으므로 재확인
+		if !c.hasPendingCalls() || !c.readMu.TryLock() {
+			return
+		}
+	}
+}
+
+// pumpCalls reads messages until no call is pending or a registered call must be served;
+// other messages go to the backlog
+func (c *Conn) pumpCalls() func() {
+	for c.hasPendingCalls() {
+		c.callMu.Lock()
+		readErr := c.readErr
+		c.callMu.Unlock()
+		if readErr != nil {
+			c.failCalls(readErr)
+			return nil
+		}
+
+		msg, err := c.transport.ReadMessage()
+		if err != nil {
+			c.callMu.Lock()
+			c.readErr = err
+			c.callMu.Unlock()
+			c.failCalls(err)
+			return nil
+		}
+		consumed, serve := c.dispatch(msg)
+		if serve != nil {
+			return serve
+		}
+		if !consumed {
+			c.callMu.Lock()
+			c.backlog = append(c.backlog, msg)
+			c.callMu.Unlock()
+		}
+	}
+	return nil
+}
+
+// dispatch routes call replies and registered remote calls.
+// Returns true if the message was consumed (buffer released).
+// 등록된 커맨드는 serve로 반환하며, 호출자가 readMu를 놓은 뒤 실행해야 함
+func (c *Conn) dispatch(msg transport.Message) (consumed bool, serve func()) {
+	if !isCommandMessage(msg.Type()) {
+		return false, nil
+	}
+
+	cmd, err := c.decodeCommand(msg)
+	if err != nil {
+		return false, nil
+	}
+
+	c.callMu.Lock()
+	var ch chan callResult
+	var handler CallHandler
+	switch cmd.Name {
+	case "_result", "_error":
+		ch = c.calls[cmd.TransactionID]
+		delete(c.calls, cmd.TransactionID)
+	default:
+		handler = c.handlers[cmd.Name]
+	}
+	c.callMu.Unlock()
+
+	streamID := msg.StreamID()
+	switch {
+	case ch != nil:
+		ch <- callResult{cmd: cmd}
+	case handler != nil:
+		serve = func() { c.serveCall(handler, streamID, cmd) }
+	default:
+		return false, nil
+	}
+
+	msg.Buffer().Release()
+	return true, serve
+}
+
+// serveCall invokes a handler and sends its _result or _error
+func (c *Conn) serveCall(handler CallHandler, streamID uint32, cmd *Command) {
+	values, err := handler(c, cmd)
+	if cmd.TransactionID == 0 {
+		return // notification
+	}
+
+	if err != nil {
+		_ = SendCommand(c, streamID, "_error", cmd.TransactionID, nil, errorInfo(err, "NetConnection.Call.Failed"))
+		return
+	}
+
+	if len(values) == 0 {
+		values = []interface{}{nil}
+	}
+	// 전송 실패는 다음 읽기/쓰기에서 드러나므로 무시
+	_ = SendCommand(c, streamID, "_result", cmd.TransactionID, nil, values...)
+}
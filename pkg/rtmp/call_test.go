@@ -0,0 +1,306 @@
+package rtmp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ssungk/ertmp/pkg/rtmp/transport"
+)
+
+func TestCall_Result(t *testing.T) {
+	client, server := newTestConnPair(t)
+	server.HandleCall("add", func(conn *Conn, cmd *Command) ([]interface{}, error) {
+		a, _ := cmd.Arguments[0].(float64)
+		b, _ := cmd.Arguments[1].(float64)
+		return []interface{}{a + b}, nil
+	})
+	go drainMessages(server)
+
+	// 클라이언트 측에는 읽는 고루틴이 없음 (Call이 직접 읽음)
+	result, err := client.Call(context.Background(), "add", nil, 2.0, 3.0)
+	if err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	if result.Name != "_result" {
+		t.Errorf("expected _result, got %s", result.Name)
+	}
+	if len(result.Arguments) != 1 || result.Arguments[0] != 5.0 {
+		t.Errorf("expected [5], got %v", result.Arguments)
+	}
+}
+
+func TestCall_Error(t *testing.T) {
+	client, server := newTestConnPair(t)
+	server.HandleCall("fail", func(conn *Conn, cmd *Command) ([]interface{}, error) {
+		return nil, errors.New("not allowed")
+	})
+	go drainMessages(server)
+
+	_, err := client.Call(context.Background(), "fail", nil)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("expected StatusError, got %v", err)
+	}
+	if statusErr.Code != "NetConnection.Call.Failed" {
+		t.Errorf("expected NetConnection.Call.Failed, got %q", statusErr.Code)
+	}
+	if statusErr.Description != "not allowed" {
+		t.Errorf("expected description 'not allowed', got %q", statusErr.Description)
+	}
+}
+
+func TestCall_Timeout(t *testing.T) {
+	client, server := newTestConnPair(t)
+	go drainMessages(server) // 응답하지 않음
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := client.Call(ctx, "noReply", nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected DeadlineExceeded, got %v", err)
+	}
+	if client.hasPendingCalls() {
+		t.Error("timed out call is still pending")
+	}
+}
+
+func TestCall_ConcurrentReader(t *testing.T) {
+	client, server := newTestConnPair(t)
+	server.HandleCall("echo", func(conn *Conn, cmd *Command) ([]interface{}, error) {
+		// 응답 전에 미디어 메시지 전송
+		if err := SendVideo(conn, 1, []byte{0x17, 0x01}, 10); err != nil {
+			return nil, err
+		}
+		return cmd.Arguments, nil
+	})
+	go drainMessages(server)
+
+	// 애플리케이션 읽기 루프: 응답은 받지 않고 미디어만 받아야 함
+	received := make(chan uint8, 4)
+	go func() {
+		for {
+			msg, err := client.ReadMessage()
+			if err != nil {
+				close(received)
+				return
+			}
+			received <- msg.Type()
+			msg.Buffer().Release()
+		}
+	}()
+
+	result, err := client.Call(context.Background(), "echo", nil, "hello")
+	if err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	if len(result.Arguments) != 1 || result.Arguments[0] != "hello" {
+		t.Errorf("expected [hello], got %v", result.Arguments)
+	}
+
+	if msgType := <-received; msgType != transport.MsgTypeVideo {
+		t.Errorf("expected video message on reader, got type %d", msgType)
+	}
+}
+
+func TestCall_NestedInHandler(t *testing.T) {
+	client, server := newTestConnPair(t)
+	client.HandleCall("inner", func(conn *Conn, cmd *Command) ([]interface{}, error) {
+		return []interface{}{"inner"}, nil
+	})
+	server.HandleCall("outer", func(conn *Conn, cmd *Command) ([]interface{}, error) {
+		// ReadMessage 고루틴에서 실행되는 handler가 상대에게 다시 Call
+		result, err := conn.Call(context.Background(), "inner", nil)
+		if err != nil {
+			return nil, err
+		}
+		return result.Arguments, nil
+	})
+	go drainMessages(server)
+
+	done := make(chan error, 1)
+	go func() {
+		result, err := client.Call(context.Background(), "outer", nil)
+		if err == nil && (len(result.Arguments) != 1 || result.Arguments[0] != "inner") {
+			err = fmt.Errorf("expected [inner], got %v", result.Arguments)
+		}
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Call failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("nested Call deadlocked")
+	}
+}
+
+func TestCall_Backlog(t *testing.T) {
+	client, server := newTestConnPair(t)
+	server.HandleCall("echo", func(conn *Conn, cmd *Command) ([]interface{}, error) {
+		if err := SendAudio(conn, 1, []byte{0xAF, 0x01}, 20); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	})
+	go drainMessages(server)
+
+	if _, err := client.Call(context.Background(), "echo", nil); err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+
+	// Call 도중 수신된 미디어는 다음 ReadMessage에서 반환
+	msg, err := client.ReadMessage()
+	if err != nil {
+		t.Fatalf("ReadMessage failed: %v", err)
+	}
+	defer msg.Buffer().Release()
+	if msg.Type() != transport.MsgTypeAudio || msg.Timestamp() != 20 {
+		t.Errorf("unexpected message: type=%d timestamp=%d", msg.Type(), msg.Timestamp())
+	}
+}
+
+func TestCall_DuringReadMessage(t *testing.T) {
+	client, server := newTestConnPair(t)
+	server.HandleCall("echo", func(conn *Conn, cmd *Command) ([]interface{}, error) {
+		// 응답 전에 미디어를 보내 client의 ReadMessage가 반환되도록 함
+		if err := SendAudio(conn, 1, []byte{0xAF, 0x01}, 20); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	})
+	go drainMessages(server)
+
+	// ReadMessage가 readMu를 잡고 대기하는 중에 Call 시작 (Call은 pump를 시작하지 못함)
+	read := make(chan transport.Message, 1)
+	go func() {
+		msg, err := client.ReadMessage()
+		if err == nil {
+			read <- msg
+		}
+	}()
+	for client.readMu.TryLock() {
+		client.readMu.Unlock()
+		time.Sleep(time.Millisecond)
+	}
+
+	// 미디어를 받은 뒤 ReadMessage를 다시 호출하지 않아도 Call은 응답을 받아야 함
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := client.Call(ctx, "echo", nil); err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+
+	msg := <-read
+	defer msg.Buffer().Release()
+	if msg.Type() != transport.MsgTypeAudio || msg.Timestamp() != 20 {
+		t.Errorf("unexpected message: type=%d timestamp=%d", msg.Type(), msg.Timestamp())
+	}
+}
+
+func TestCall_Notify(t *testing.T) {
+	client, server := newTestConnPair(t)
+
+	called := make(chan float64, 1)
+	client.HandleCall("onBWDone", func(conn *Conn, cmd *Command) ([]interface{}, error) {
+		called <- cmd.TransactionID
+		return nil, nil
+	})
+	go drainMessages(client)
+
+	if err := server.Notify("onBWDone", nil); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+
+	select {
+	case txID := <-called:
+		if txID != 0 {
+			t.Errorf("expected transaction ID 0, got %v", txID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("handler not called")
+	}
+}
+
+func TestCall_ConnClosed(t *testing.T) {
+	client, server := newTestConnPair(t)
+	go drainMessages(server)
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := client.Call(context.Background(), "noReply", nil)
+		errCh <- err
+	}()
+
+	// Call 등록 대기 후 종료
+	for !client.hasPendingCalls() {
+		time.Sleep(time.Millisecond)
+	}
+	client.Close()
+
+	// ErrConnClosed 또는 종료된 연결의 읽기 에러
+	if err := <-errCh; err == nil {
+		t.Fatal("expected error after close")
+	}
+}
+
+// newTestConnPair creates a connected client/server Conn pair over loopback TCP
+func newTestConnPair(t *testing.T) (client, server *Conn) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer listener.Close()
+
+	serverCh := make(chan *Conn, 1)
+	go func() {
+		netConn, err := listener.Accept()
+		if err != nil {
+			serverCh <- nil
+			return
+		}
+		conn, err := AcceptConn(netConn)
+		if err != nil {
+			netConn.Close()
+		}
+		serverCh <- conn
+	}()
+
+	netConn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	client, err = DialConn(netConn)
+	if err != nil {
+		t.Fatalf("DialConn failed: %v", err)
+	}
+	server = <-serverCh
+	if server == nil {
+		t.Fatal("server handshake failed")
+	}
+
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+	return client, server
+}
+
+// drainMessages reads and releases messages until the connection fails
+func drainMessages(conn *Conn) {
+	for {
+		msg, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		msg.Buffer().Release()
+	}
+}
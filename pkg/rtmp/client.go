@@ -0,0 +1,351 @@
+package rtmp
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+
+	"github.com/ssungk/ertmp/pkg/rtmp/transport"
+)
+
+// ClientConfig holds RTMP client options
+type ClientConfig struct {
+	TLSConfig  *tls.Config // rtmps:// 연결에 사용 (nil이면 기본값)
+	FlashVer   string
+	FourCcList []string // Enhanced RTMP 코덱 협상
+	ChunkSize  uint32   // connect 후 설정할 송신 청크 크기
+}
+
+// DefaultClientConfig returns default RTMP client configuration
+func DefaultClientConfig() ClientConfig {
+	return ClientConfig{
+		FlashVer:   "FMLE/3.0 (compatible; ertmp)",
+		FourCcList: []string{"av01", "vp09", "hvc1", "avc1"},
+		ChunkSize:  4096,
+	}
+}
+
+// Client represents a client-side RTMP session
+type Client struct {
+	conn   *Conn
+	url    *URL
+	config ClientConfig
+
+	// 응답 대기 중 수신된 스트림 메시지 (ReadMessage에서 먼저 반환)
+	pending []transport.Message
+}
+
+// Dial connects to rtmp[s]://host[:port]/app[/instance][/streamKey]
+// Performs the handshake and the connect command
+func Dial(rawURL string, config ClientConfig) (*Client, error) {
+	return DialContext(context.Background(), rawURL, config)
+}
+
+// DialContext connects like Dial with the dial and handshake bounded by ctx
+func DialContext(ctx context.Context, rawURL string, config ClientConfig) (*Client, error) {
+	u, err := ParseURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	netConn, err := dialNet(ctx, u, config.TLSConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := DialConnContext(ctx, netConn)
+	if err != nil {
+		netConn.Close()
+		return nil, err
+	}
+
+	c := &Client{
+		conn:   conn,
+		url:    u,
+		config: config,
+	}
+
+	if err := c.connect(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Conn returns the underlying RTMP connection
+func (c *Client) Conn() *Conn {
+	return c.conn
+}
+
+// URL returns the parsed URL the client connected to
+func (c *Client) URL() *URL {
+	return c.url
+}
+
+// Close closes the client connection
+func (c *Client) Close() error {
+	for _, msg := range c.pending {
+		msg.Buffer().Release()
+	}
+	c.pending = nil
+	return c.conn.Close()
+}
+
+// Publish creates a stream and starts publishing to it
+// key가 비어 있으면 URL의 stream key 사용
+func (c *Client) Publish(key string) (*ClientStream, error) {
+	if key == "" {
+		key = c.url.StreamKey
+	}
+	if key == "" {
+		return nil, fmt.Errorf("publish: missing stream key")
+	}
+
+	// FMLE 호환: releaseStream, FCPublish (응답은 무시)
+	if err := SendCommand(c.conn, 0, "releaseStream", c.conn.allocTxID(), nil, key); err != nil {
+		return nil, err
+	}
+	if err := SendCommand(c.conn, 0, "FCPublish", c.conn.allocTxID(), nil, key); err != nil {
+		return nil, err
+	}
+
+	streamID, err := c.createStream()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := SendCommand(c.conn, streamID, "publish", c.conn.allocTxID(), nil, key, "live"); err != nil {
+		return nil, err
+	}
+	if err := c.waitStatus("publish", streamID, "NetStream.Publish.Start"); err != nil {
+		return nil, err
+	}
+
+	return &ClientStream{client: c, id: streamID, key: key, mode: StreamModePublish}, nil
+}
+
+// Play creates a stream and starts playing it
+// key가 비어 있으면 URL의 stream key 사용
+func (c *Client) Play(key string) (*ClientStream, error) {
+	if key == "" {
+		key = c.url.StreamKey
+	}
+	if key == "" {
+		return nil, fmt.Errorf("play: missing stream key")
+	}
+
+	streamID, err := c.createStream()
+	if err != nil {
+		return nil, err
+	}
+
+	// start=-2: live 우선, 없으면 녹화 파일
+	if err := SendCommand(c.conn, streamID, "play", c.conn.allocTxID(), nil, key, -2.0); err != nil {
+		return nil, err
+	}
+	if err := c.waitStatus("play", streamID, "NetStream.Play.Start"); err != nil {
+		return nil, err
+	}
+
+	return &ClientStream{client: c, id: streamID, key: key, mode: StreamModePlay}, nil
+}
+
+// connect sends the connect command and waits for the result
+func (c *Client) connect() error {
+	obj := map[string]interface{}{
+		"app":            c.url.App,
+		"type":           "nonprivate",
+		"flashVer":       c.config.FlashVer,
+		"tcUrl":          c.url.TcURL(),
+		"fpad":           false,
+		"capabilities":   15.0,
+		"audioCodecs":    4071.0,
+		"videoCodecs":    252.0,
+		"videoFunction":  1.0,
+		"objectEncoding": 0.0,
+	}
+
+	// Enhanced RTMP 코덱 목록
+	if len(c.config.FourCcList) > 0 {
+		fourCcList := make([]interface{}, len(c.config.FourCcList))
+		for i, fcc := range c.config.FourCcList {
+			fourCcList[i] = fcc
+		}
+		obj["fourCcList"] = fourCcList
+	}
+
+	if _, err := c.conn.Call(context.Background(), "connect", obj); err != nil {
+		return err
+	}
+
+	// 송신 청크 크기 확대
+	if c.config.ChunkSize > transport.DefaultChunkSize {
+		if err := c.conn.SetChunkSize(c.config.ChunkSize); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// createStream sends createStream and returns the new message stream ID
+func (c *Client) createStream() (uint32, error) {
+	result, err := c.conn.Call(context.Background(), "createStream", nil)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, arg := range result.Arguments {
+		if id, ok := arg.(float64); ok {
+			return uint32(id), nil
+		}
+	}
+	return 0, fmt.Errorf("createStream: missing stream ID in result")
+}
+
+// waitStatus reads messages until an onStatus with the wanted code arrives on streamID
+// error 레벨의 onStatus는 StatusError로 반환
+func (c *Client) waitStatus(command string, streamID uint32, code string) error {
+	for {
+		msg, err := c.conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+
+		streamOfMsg := msg.StreamID()
+		cmd, ok := c.commandOrQueue(msg)
+		if !ok || streamOfMsg != streamID {
+			continue
+		}
+
+		switch cmd.Name {
+		case "onStatus":
+			info := statusInfo(cmd)
+			statusErr := newStatusError(command, info)
+			if statusErr.Level == "error" {
+				return statusErr
+			}
+			if statusErr.Code == code {
+				return nil
+			}
+		case "_error":
+			return newStatusError(command, statusInfo(cmd))
+		}
+	}
+}
+
+// commandOrQueue decodes AMF0/AMF3 command messages and releases them;
+// other messages are queued for ClientStream.ReadMessage
+func (c *Client) commandOrQueue(msg transport.Message) (*Command, bool) {
+	if !isCommandMessage(msg.Type()) {
+		c.pending = append(c.pending, msg)
+		return nil, false
+	}
+	defer msg.Buffer().Release()
+
+	cmd, err := c.conn.decodeCommand(msg)
+	if err != nil {
+		return nil, false
+	}
+	return cmd, true
+}
+
+// readMessage returns a queued message first, then reads from the connection
+func (c *Client) readMessage() (transport.Message, error) {
+	if len(c.pending) > 0 {
+		msg := c.pending[0]
+		c.pending = c.pending[1:]
+		return msg, nil
+	}
+	return c.conn.ReadMessage()
+}
+
+// ClientStream represents a publishing or playing stream of a Client
+type ClientStream struct {
+	client *Client
+	id     uint32
+	key    string
+	mode   StreamMode
+}
+
+// ID returns the message stream ID
+func (s *ClientStream) ID() uint32 {
+	return s.id
+}
+
+// Key returns the stream key
+func (s *ClientStream) Key() string {
+	return s.key
+}
+
+// Mode returns the stream mode
+func (s *ClientStream) Mode() StreamMode {
+	return s.mode
+}
+
+// WriteVideo sends a video tag body
+func (s *ClientStream) WriteVideo(data []byte, timestamp uint32) error {
+	return SendVideo(s.client.conn, s.id, data, timestamp)
+}
+
+// WriteAudio sends an audio tag body
+func (s *ClientStream) WriteAudio(data []byte, timestamp uint32) error {
+	return SendAudio(s.client.conn, s.id, data, timestamp)
+}
+
+// WriteMetadata sends @setDataFrame onMetaData
+func (s *ClientStream) WriteMetadata(metadata map[string]interface{}) error {
+	return SendMetadata(s.client.conn, s.id, metadata)
+}
+
+// ReadMessage reads the next audio, video or data message of a playing stream
+// 스트림 종료(NetStream.Play.Stop/Complete, UnpublishNotify) 시 io.EOF 반환
+// Caller must release the returned message buffer
+func (s *ClientStream) ReadMessage() (transport.Message, error) {
+	for {
+		msg, err := s.client.readMessage()
+		if err != nil {
+			return transport.Message{}, err
+		}
+
+		switch msg.Type() {
+		case transport.MsgTypeAudio, transport.MsgTypeVideo, transport.MsgTypeAMF0Data, transport.MsgTypeAMF3Data:
+			if msg.StreamID() == s.id {
+				return msg, nil
+			}
+			msg.Buffer().Release()
+
+		case transport.MsgTypeAMF0Command, transport.MsgTypeAMF3Command:
+			cmd, err := s.client.conn.decodeCommand(msg)
+			streamID := msg.StreamID()
+			msg.Buffer().Release()
+			if err != nil || streamID != s.id || cmd.Name != "onStatus" {
+				continue
+			}
+
+			status := newStatusError("play", statusInfo(cmd))
+			if status.Level == "error" {
+				return transport.Message{}, status
+			}
+			switch status.Code {
+			case "NetStream.Play.Stop", "NetStream.Play.Complete", "NetStream.Play.UnpublishNotify":
+				return transport.Message{}, io.EOF
+			}
+
+		default:
+			msg.Buffer().Release()
+		}
+	}
+}
+
+// Close stops publishing or playing and deletes the stream
+func (s *ClientStream) Close() error {
+	if s.mode == StreamModePublish {
+		if err := SendCommand(s.client.conn, 0, "FCUnpublish", s.client.conn.allocTxID(), nil, s.key); err != nil {
+			return err
+		}
+	}
+	return SendCommand(s.client.conn, 0, "deleteStream", s.client.conn.allocTxID(), nil, float64(s.id))
+}
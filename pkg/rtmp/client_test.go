@@ -0,0 +1,232 @@
+package rtmp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/ssungk/ertmp/pkg/rtmp/transport"
+)
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		raw       string
+		app       string
+		key       string
+		address   string
+		tcURL     string
+		wantError bool
+	}{
+		{"rtmp://localhost/live/stream", "live", "stream", "localhost:1935", "rtmp://localhost/live", false},
+		{"rtmp://localhost:1936/live/stream", "live", "stream", "localhost:1936", "rtmp://localhost:1936/live", false},
+		{"rtmps://ingest.example.com/app/inst/key", "app/inst", "key", "ingest.example.com:443", "rtmps://ingest.example.com/app/inst", false},
+		{"rtmp://localhost/live", "live", "", "localhost:1935", "rtmp://localhost/live", false},
+		{"rtmp://localhost/live/stream?token=abc", "live", "stream?token=abc", "localhost:1935", "rtmp://localhost/live", false},
+		{"rtmp://[::1]:1935/live/stream", "live", "stream", "[::1]:1935", "rtmp://[::1]/live", false},
+		{"http://localhost/live/stream", "", "", "", "", true},
+		{"rtmp:///live/stream", "", "", "", "", true},
+		{"rtmp://localhost/", "", "", "", "", true},
+	}
+
+	for _, tt := range tests {
+		u, err := ParseURL(tt.raw)
+		if tt.wantError {
+			if err == nil {
+				t.Errorf("%s: expected error", tt.raw)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.raw, err)
+			continue
+		}
+		if u.App != tt.app {
+			t.Errorf("%s: expected app %q, got %q", tt.raw, tt.app, u.App)
+		}
+		if u.StreamKey != tt.key {
+			t.Errorf("%s: expected key %q, got %q", tt.raw, tt.key, u.StreamKey)
+		}
+		if u.Address() != tt.address {
+			t.Errorf("%s: expected address %q, got %q", tt.raw, tt.address, u.Address())
+		}
+		if u.TcURL() != tt.tcURL {
+			t.Errorf("%s: expected tcUrl %q, got %q", tt.raw, tt.tcURL, u.TcURL())
+		}
+	}
+}
+
+func TestClient_Publish(t *testing.T) {
+	received := make(chan []byte, 1)
+	addr := startTestServer(t, func(conn *Conn, msg transport.Message, cmd *Command) error {
+		switch cmd.Name {
+		case "publish":
+			if err := HandlePublish(conn, msg); err != nil {
+				return err
+			}
+			// 다음 미디어 메시지 수신
+			for {
+				media, err := conn.ReadMessage()
+				if err != nil {
+					return err
+				}
+				if media.Type() == transport.MsgTypeVideo {
+					received <- bytes.Clone(media.Data())
+					media.Buffer().Release()
+					return nil
+				}
+				media.Buffer().Release()
+			}
+		}
+		return nil
+	})
+
+	client, err := Dial(fmt.Sprintf("rtmp://%s/live/stream", addr), DefaultClientConfig())
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer client.Close()
+
+	stream, err := client.Publish("")
+	if err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	if stream.Key() != "stream" {
+		t.Errorf("expected key 'stream', got %q", stream.Key())
+	}
+
+	payload := bytes.Repeat([]byte{0x27}, 5000) // 4096 청크 크기보다 큰 메시지
+	if err := stream.WriteVideo(payload, 0); err != nil {
+		t.Fatalf("WriteVideo failed: %v", err)
+	}
+
+	if got := <-received; !bytes.Equal(got, payload) {
+		t.Error("video payload mismatch")
+	}
+}
+
+func TestClient_Play(t *testing.T) {
+	payload := []byte{0xAF, 0x01, 0x21, 0x00}
+	addr := startTestServer(t, func(conn *Conn, msg transport.Message, cmd *Command) error {
+		if cmd.Name != "play" {
+			return nil
+		}
+		if err := HandlePlay(conn, msg); err != nil {
+			return err
+		}
+		if err := SendAudio(conn, msg.StreamID(), payload, 100); err != nil {
+			return err
+		}
+		return SendOnStatus(conn, msg.StreamID(), "status", "NetStream.Play.Stop", "Stopped")
+	})
+
+	client, err := Dial(fmt.Sprintf("rtmp://%s/live", addr), DefaultClientConfig())
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer client.Close()
+
+	stream, err := client.Play("stream")
+	if err != nil {
+		t.Fatalf("Play failed: %v", err)
+	}
+
+	msg, err := stream.ReadMessage()
+	if err != nil {
+		t.Fatalf("ReadMessage failed: %v", err)
+	}
+	if msg.Type() != transport.MsgTypeAudio || msg.Timestamp() != 100 {
+		t.Errorf("unexpected message: type=%d timestamp=%d", msg.Type(), msg.Timestamp())
+	}
+	if !bytes.Equal(msg.Data(), payload) {
+		t.Error("audio payload mismatch")
+	}
+	msg.Buffer().Release()
+
+	if _, err := stream.ReadMessage(); !errors.Is(err, io.EOF) {
+		t.Errorf("expected io.EOF after Play.Stop, got %v", err)
+	}
+}
+
+func TestClient_PublishRejected(t *testing.T) {
+	addr := startTestServer(t, func(conn *Conn, msg transport.Message, cmd *Command) error {
+		if cmd.Name == "publish" {
+			return SendOnStatus(conn, msg.StreamID(), "error", "NetStream.Publish.BadName", "Stream already publishing")
+		}
+		return nil
+	})
+
+	client, err := Dial(fmt.Sprintf("rtmp://%s/live/stream", addr), DefaultClientConfig())
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer client.Close()
+
+	_, err = client.Publish("")
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("expected StatusError, got %v", err)
+	}
+	if statusErr.Code != "NetStream.Publish.BadName" {
+		t.Errorf("expected BadName, got %q", statusErr.Code)
+	}
+}
+
+// startTestServer starts a loopback RTMP server that handles connect and createStream,
+// and passes every other command to handle
+func startTestServer(t *testing.T, handle func(conn *Conn, msg transport.Message, cmd *Command) error) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		netConn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		conn, err := AcceptConn(netConn)
+		if err != nil {
+			netConn.Close()
+			return
+		}
+		defer conn.Close()
+
+		for {
+			msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if msg.Type() != transport.MsgTypeAMF0Command {
+				msg.Buffer().Release()
+				continue
+			}
+
+			cmd, err := DecodeCommand(msg.Data())
+			if err != nil {
+				msg.Buffer().Release()
+				return
+			}
+
+			switch cmd.Name {
+			case "connect":
+				err = HandleConnect(conn, msg)
+			case "createStream":
+				_, err = HandleCreateStream(conn, msg)
+			default:
+				err = handle(conn, msg, cmd)
+			}
+			msg.Buffer().Release()
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	return listener.Addr().String()
+}
@@ -2,6 +2,7 @@ package rtmp
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 
 	"github.com/ssungk/ertmp/pkg/amf"
@@ -19,14 +20,24 @@ type Command struct {
 
 // ConnectCommand represents a connect command
 type ConnectCommand struct {
-	App            string
-	TcUrl          string
-	FlashVer       string
-	ObjectEncoding float64
-	FourCcList     []string
-	CapsEx         map[string]interface{}
+	App            string                 `amf:"app"`
+	TcUrl          string                 `amf:"tcUrl"`
+	FlashVer       string                 `amf:"flashVer"`
+	ObjectEncoding float64                `amf:"objectEncoding"`
+	FourCcList     []string               `amf:"fourCcList"`
+	CapsEx         map[string]interface{} `amf:"capsEx"`
 }
 
+// objectEncoding values of the connect command
+const (
+	ObjectEncodingAMF0 = 0.0
+	ObjectEncodingAMF3 = 3.0
+)
+
+// amf3FormatAMF0 is the leading format byte of AMF3 command/data messages (types 0x11, 0x0F):
+// 이후 본문은 AMF0 값들이며 개별 값이 AVM+ 마커(0x11)로 AMF3 전환
+const amf3FormatAMF0 = 0x00
+
 // PublishCommand represents a publish command
 type PublishCommand struct {
 	StreamKey   string
@@ -42,6 +53,7 @@ type PlayCommand struct {
 }
 
 // DecodeCommand decodes AMF0 command from message data
+// (AVM+ 마커로 전환된 AMF3 값 포함)
 func DecodeCommand(data []byte) (*Command, error) {
 	if len(data) == 0 {
 		return nil, fmt.Errorf("empty command data")
@@ -52,7 +64,42 @@ func DecodeCommand(data []byte) (*Command, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode AMF0: %w", err)
 	}
+	return commandFromValues(values)
+}
+
+// DecodeCommandAMF3 decodes the body of an AMF3 command message (type 0x11).
+// AVM+ 값은 ctx의 참조 테이블로 디코딩하며, ctx는 메시지 시작 시 Reset됨 (nil이면 새 context)
+func DecodeCommandAMF3(data []byte, ctx *amf.AMF3Context) (*Command, error) {
+	values, err := DecodeDataAMF3(data, ctx)
+	if err != nil {
+		return nil, err
+	}
+	return commandFromValues(values)
+}
+
+// DecodeDataAMF3 decodes the values of an AMF3 command or data message body (types 0x11, 0x0F)
+func DecodeDataAMF3(data []byte, ctx *amf.AMF3Context) ([]interface{}, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty message data")
+	}
+	if data[0] != amf3FormatAMF0 {
+		return nil, fmt.Errorf("unsupported AMF3 message format: 0x%02x", data[0])
+	}
+
+	if ctx == nil {
+		ctx = amf.NewAMF3Context()
+	} else {
+		ctx.Reset()
+	}
+	values, err := amf.DecodeAMF0SequenceContext(bytes.NewReader(data[1:]), ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode AMF3: %w", err)
+	}
+	return values, nil
+}
 
+// commandFromValues builds a command from decoded AMF values
+func commandFromValues(values []interface{}) (*Command, error) {
 	if len(values) < 2 {
 		return nil, fmt.Errorf("invalid command: need at least 2 values")
 	}
@@ -106,6 +153,61 @@ func EncodeCommand(name string, txID float64, obj map[string]interface{}, args .
 	return data, nil
 }
 
+// EncodeCommandAMF3 encodes a command as the body of an AMF3 command message (type 0x11).
+// 이름과 트랜잭션 ID는 AMF0, 커맨드 객체와 인자는 AVM+ 마커로 전환한 AMF3 값으로 인코딩
+// (ctx는 Reset 후 사용, nil이면 새 context)
+func EncodeCommandAMF3(ctx *amf.AMF3Context, name string, txID float64, obj map[string]interface{}, args ...interface{}) ([]byte, error) {
+	values := []interface{}{name, txID}
+	if obj != nil {
+		values = append(values, obj)
+	} else {
+		values = append(values, nil)
+	}
+	values = append(values, args...)
+
+	data, err := encodeAMF3Body(ctx, 2, values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode command: %w", err)
+	}
+	return data, nil
+}
+
+// EncodeDataAMF3 encodes values as the body of an AMF3 data message (type 0x0F).
+// 첫 값(핸들러 이름)은 AMF0, 나머지는 AVM+ 값
+func EncodeDataAMF3(ctx *amf.AMF3Context, values ...interface{}) ([]byte, error) {
+	return encodeAMF3Body(ctx, min(1, len(values)), values)
+}
+
+// encodeAMF3Body writes the format byte, the first plain values as AMF0 and the rest as AVM+ values.
+// null은 AMF0 그대로 인코딩
+func encodeAMF3Body(ctx *amf.AMF3Context, plain int, values []interface{}) ([]byte, error) {
+	if ctx == nil {
+		ctx = amf.NewAMF3Context()
+	} else {
+		ctx.Reset()
+	}
+
+	head, err := amf.EncodeAMF0Sequence(values[:plain]...)
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	b.WriteByte(amf3FormatAMF0)
+	b.Write(head)
+
+	for _, v := range values[plain:] {
+		if v == nil {
+			null, _ := amf.EncodeAMF0Sequence(nil)
+			b.Write(null)
+			continue
+		}
+		if err := ctx.EncodeAVMPlus(&b, v); err != nil {
+			return nil, err
+		}
+	}
+	return b.Bytes(), nil
+}
+
 // ParseConnect parses a connect command
 func ParseConnect(cmd *Command) (*ConnectCommand, error) {
 	if cmd.Name != "connect" {
@@ -114,31 +216,10 @@ func ParseConnect(cmd *Command) (*ConnectCommand, error) {
 
 	cc := &ConnectCommand{}
 
-	if cmd.Object != nil {
-		if v, ok := cmd.Object["app"].(string); ok {
-			cc.App = v
-		}
-		if v, ok := cmd.Object["tcUrl"].(string); ok {
-			cc.TcUrl = v
-		}
-		if v, ok := cmd.Object["flashVer"].(string); ok {
-			cc.FlashVer = v
-		}
-		if v, ok := cmd.Object["objectEncoding"].(float64); ok {
-			cc.ObjectEncoding = v
-		}
-
-		// Enhanced RTMP 기능
-		if fourCcList, ok := cmd.Object["fourCcList"].([]interface{}); ok {
-			for _, fcc := range fourCcList {
-				if str, ok := fcc.(string); ok {
-					cc.FourCcList = append(cc.FourCcList, str)
-				}
-			}
-		}
-		if capsEx, ok := cmd.Object["capsEx"].(map[string]interface{}); ok {
-			cc.CapsEx = capsEx
-		}
+	// 타입이 맞지 않는 속성은 무시하고 나머지만 사용
+	var typeErr *amf.UnmarshalTypeError
+	if err := amf.UnmarshalValue(cmd.Object, cc); err != nil && !errors.As(err, &typeErr) {
+		return nil, fmt.Errorf("invalid connect object: %w", err)
 	}
 
 	return cc, nil
@@ -203,24 +284,54 @@ func ParsePlay(cmd *Command) (*PlayCommand, error) {
 	return pc, nil
 }
 
+// NewCommandMessage creates a command message on the given message stream
+func NewCommandMessage(streamID uint32, name string, txID float64, obj map[string]interface{}, args ...interface{}) (transport.Message, error) {
+	cmdData, err := EncodeCommand(name, txID, obj, args...)
+	if err != nil {
+		return transport.Message{}, err
+	}
+	buffer := buf.New(cmdData)
+	header := transport.NewMessageHeader(streamID, 0, transport.MsgTypeAMF0Command)
+	return transport.NewMessage(header, buffer), nil
+}
+
+// isCommandMessage reports whether msgType is an AMF0 or AMF3 command message
+func isCommandMessage(msgType uint8) bool {
+	return msgType == transport.MsgTypeAMF0Command || msgType == transport.MsgTypeAMF3Command
+}
+
+// statusInfo returns the info object of an onStatus/_result/_error command
+func statusInfo(cmd *Command) map[string]interface{} {
+	for _, arg := range cmd.Arguments {
+		if info, ok := arg.(map[string]interface{}); ok {
+			return info
+		}
+	}
+	return nil
+}
+
 // NewConnectResponseMessage creates a connect response message
 func NewConnectResponseMessage(txID float64, props map[string]interface{}) transport.Message {
 	if props == nil {
 		props = make(map[string]interface{})
 	}
 
-	info := map[string]interface{}{
-		"level":       "status",
-		"code":        "NetConnection.Connect.Success",
-		"description": "Connection succeeded",
-	}
-
-	cmdData, _ := EncodeCommand("_result", txID, props, info)
+	cmdData, _ := EncodeCommand("_result", txID, props, connectResultInfo(ObjectEncodingAMF0))
 	buffer := buf.New(cmdData)
 	header := transport.NewMessageHeader(0, 0, transport.MsgTypeAMF0Command)
 	return transport.NewMessage(header, buffer)
 }
 
+// connectResultInfo returns the info object of a successful connect _result
+func connectResultInfo(objectEncoding float64) map[string]interface{} {
+	return map[string]interface{}{
+		"level":          "status",
+		"code":           "NetConnection.Connect.Success",
+		"description":    "Connection succeeded",
+		"objectEncoding": objectEncoding,
+	}
+}
+
 // NewCreateStreamResponseMessage creates a createStream response message
 func NewCreateStreamResponseMessage(txID float64, streamID float64) transport.Message {
 	cmdData, _ := EncodeCommand("_result", txID, nil, streamID)
@@ -231,14 +342,17 @@ func NewCreateStreamResponseMessage(txID float64, streamID float64) transport.Me
 
 // NewOnStatusMessage creates an onStatus command message
 func NewOnStatusMessage(streamID uint32, level, code, description string) transport.Message {
-	info := map[string]interface{}{
+	cmdData, _ := EncodeCommand("onStatus", 0, nil, onStatusInfo(level, code, description))
+	buffer := buf.New(cmdData)
+	header := transport.NewMessageHeader(streamID, 0, transport.MsgTypeAMF0Command)
+	return transport.NewMessage(header, buffer)
+}
+
+// onStatusInfo returns the info object of an onStatus command
+func onStatusInfo(level, code, description string) map[string]interface{} {
+	return map[string]interface{}{
 		"level":       level,
 		"code":        code,
 		"description": description,
 	}
-
-	cmdData, _ := EncodeCommand("onStatus", 0, nil, info)
-	buffer := buf.New(cmdData)
-	header := transport.NewMessageHeader(streamID, 0, transport.MsgTypeAMF0Command)
-	return transport.NewMessage(header, buffer)
 }
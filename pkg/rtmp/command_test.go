@@ -0,0 +1,127 @@
+package rtmp
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/ssungk/ertmp/pkg/amf"
+)
+
+func TestCommandAMF3_RoundTrip(t *testing.T) {
+	ctx := amf.NewAMF3Context()
+	obj := map[string]interface{}{"app": "live", "objectEncoding": 3.0}
+	data, err := EncodeCommandAMF3(ctx, "connect", 1, obj, "live", "live")
+	if err != nil {
+		t.Fatalf("EncodeCommandAMF3: %v", err)
+	}
+	if data[0] != amf3FormatAMF0 {
+		t.Fatalf("format byte = 0x%02x, want 0x00", data[0])
+	}
+
+	// 같은 context를 재사용해도 메시지마다 Reset됨
+	for i := 0; i < 2; i++ {
+		cmd, err := DecodeCommandAMF3(data, ctx)
+		if err != nil {
+			t.Fatalf("DecodeCommandAMF3: %v", err)
+		}
+		if cmd.Name != "connect" || cmd.TransactionID != 1 {
+			t.Errorf("got %s %v, want connect 1", cmd.Name, cmd.TransactionID)
+		}
+		if cmd.Object["app"] != "live" || cmd.Object["objectEncoding"] != 3.0 {
+			t.Errorf("object = %v", cmd.Object)
+		}
+		// 두 번째 "live"는 첫 값의 문자열 참조
+		if len(cmd.Arguments) != 2 || cmd.Arguments[0] != "live" || cmd.Arguments[1] != "live" {
+			t.Errorf("arguments = %v", cmd.Arguments)
+		}
+	}
+}
+
+func TestDecodeCommandAMF3_Invalid(t *testing.T) {
+	if _, err := DecodeCommandAMF3(nil, nil); err == nil {
+		t.Error("expected error for empty data")
+	}
+
+	body, _ := EncodeCommand("connect", 1, nil)
+	if _, err := DecodeCommandAMF3(append([]byte{0x01}, body...), nil); err == nil {
+		t.Error("expected error for unknown format byte")
+	}
+}
+
+func TestDecodeCommand_Truncated(t *testing.T) {
+	body, err := EncodeCommand("connect", 1, map[string]interface{}{"app": "live"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// object 중간에서 끝난 명령은 일부 값으로 디코딩하지 않음
+	for _, n := range []int{len(body) - 1, len(body) - 3, len(body) - 6} {
+		if cmd, err := DecodeCommand(body[:n]); !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("%d of %d bytes: expected io.ErrUnexpectedEOF, got %v (%+v)", n, len(body), err, cmd)
+		}
+	}
+}
+
+func TestEncodeDataAMF3(t *testing.T) {
+	data, err := EncodeDataAMF3(nil, "onMetaData", map[string]interface{}{"width": 1280.0})
+	if err != nil {
+		t.Fatalf("EncodeDataAMF3: %v", err)
+	}
+	// 핸들러 이름은 AMF0 문자열
+	if !bytes.HasPrefix(data, []byte{0x00, 0x02, 0x00, 0x0A}) {
+		t.Errorf("unexpected prefix %x", data[:4])
+	}
+
+	values, err := DecodeDataAMF3(data, nil)
+	if err != nil {
+		t.Fatalf("DecodeDataAMF3: %v", err)
+	}
+	meta, ok := values[1].(map[string]interface{})
+	if values[0] != "onMetaData" || !ok || meta["width"] != 1280.0 {
+		t.Errorf("values = %v", values)
+	}
+}
+
+func TestParseConnect(t *testing.T) {
+	cmd := &Command{Name: "connect", TransactionID: 1, Object: map[string]interface{}{
+		"app":            "live",
+		"tcUrl":          "rtmp://localhost/live",
+		"flashVer":       42.0,     // 타입이 맞지 않으면 무시
+		"objectEncoding": int32(3), // AMF3 integer
+		"fourCcList":     []interface{}{"av01", "hvc1"},
+		"capsEx":         map[string]interface{}{"reconnect": true},
+	}}
+
+	cc, err := ParseConnect(cmd)
+	if err != nil {
+		t.Fatalf("ParseConnect: %v", err)
+	}
+	if cc.App != "live" || cc.TcUrl != "rtmp://localhost/live" || cc.FlashVer != "" {
+		t.Errorf("got %+v", cc)
+	}
+	if cc.ObjectEncoding != ObjectEncodingAMF3 {
+		t.Errorf("ObjectEncoding = %v, want 3", cc.ObjectEncoding)
+	}
+	if len(cc.FourCcList) != 2 || cc.FourCcList[1] != "hvc1" || cc.CapsEx["reconnect"] != true {
+		t.Errorf("got %+v", cc)
+	}
+}
+
+func FuzzDecodeCommand(f *testing.F) {
+	connect, _ := EncodeCommand("connect", 1, map[string]interface{}{"app": "live", "tcUrl": "rtmp://localhost/live"})
+	publish, _ := EncodeCommand("publish", 0, nil, "key", "live")
+	f.Add(connect)
+	f.Add(publish)
+	f.Add([]byte{0x02, 0x00, 0x07, 'c', 'o', 'n', 'n', 'e', 'c', 't', 0x0A, 0xFF, 0xFF, 0xFF, 0xFF})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		cmd, err := DecodeCommand(data)
+		if err != nil {
+			return
+		}
+		if cmd.Name == "connect" {
+			_, _ = ParseConnect(cmd)
+		}
+	})
+}
@@ -1,52 +1,198 @@
 package rtmp
 
 import (
+	"context"
 	"fmt"
 	"net"
+	"sync"
+	"time"
 
+	"github.com/ssungk/ertmp/pkg/amf"
+	"github.com/ssungk/ertmp/pkg/rtmp/buf"
 	"github.com/ssungk/ertmp/pkg/rtmp/transport"
 )
 
-// Conn represents an RTMP connection
+// Conn represents an RTMP connection.
+// WriteMessage와 Send* 함수, SetChunkSize 등 송신 메서드는 여러 고루틴에서 동시에 사용 가능
+// (Transport에서 메시지 단위로 직렬화, 외부 잠금 불필요).
+// ReadMessage는 한 고루틴에서 호출하고, 스트림 관리는 읽는 고루틴에서 수행
 type Conn struct {
-	transport *transport.Transport
-	config    Config
+	netConn       net.Conn
+	transport     *transport.Transport
+	config        Config
+	handshakeMode transport.HandshakeMode
+	connectInfo   *ConnectCommand
 
 	// 스트림 관리
 	streams      map[uint32]*Stream
 	nextStreamID uint32
+
+	// 트랜잭션 (Call) 관리
+	readMu   sync.Mutex // ReadMessage/pump 중 하나만 transport에서 읽음
+	callMu   sync.Mutex // 아래 필드 보호
+	calls    map[float64]chan callResult
+	handlers map[string]CallHandler
+	nextTxID float64
+	backlog  []transport.Message // pump가 읽은 응답 외 메시지
+	readErr  error               // pump에서 발생한 읽기 에러
+
+	// AMF3 커맨드 인코딩 (objectEncoding 3)
+	amfMu          sync.Mutex       // 아래 필드 보호 (송신은 여러 고루틴에서 가능)
+	amf3           *amf.AMF3Context // 메시지마다 Reset하여 재사용
+	objectEncoding float64          // 커맨드 송신 인코딩 (connect에서 협상)
 }
 
 // AcceptConn accepts a server-side RTMP connection with handshake
 func AcceptConn(netConn net.Conn) (*Conn, error) {
-	// 서버 핸드셰이크 수행
-	if err := transport.ServerHandshake(netConn); err != nil {
+	return AcceptConnContext(context.Background(), netConn)
+}
+
+// AcceptConnContext accepts a server-side RTMP connection with the handshake bounded by ctx.
+// 기한 초과 시 transport.ErrHandshakeTimeout 반환
+func AcceptConnContext(ctx context.Context, netConn net.Conn) (*Conn, error) {
+	// 서버 핸드셰이크 수행 (simple/digest 자동 감지)
+	mode, err := transport.ServerHandshakeContext(ctx, netConn)
+	if err != nil {
 		return nil, err
 	}
-	return newConn(netConn), nil
+	conn := newConn(netConn)
+	conn.handshakeMode = mode
+	return conn, nil
 }
 
 // DialConn creates a client-side RTMP connection with handshake
+// Digest 핸드셰이크를 시도하고, 서버가 지원하지 않으면 simple로 fallback
 func DialConn(netConn net.Conn) (*Conn, error) {
+	return DialConnContext(context.Background(), netConn)
+}
+
+// DialConnContext creates a client-side RTMP connection with the handshake bounded by ctx
+func DialConnContext(ctx context.Context, netConn net.Conn) (*Conn, error) {
 	// 클라이언트 핸드셰이크 수행
-	if err := transport.ClientHandshake(netConn); err != nil {
+	mode, err := transport.ClientHandshakeContext(ctx, netConn, transport.HandshakeDigest)
+	if err != nil {
 		return nil, err
 	}
-	return newConn(netConn), nil
+	conn := newConn(netConn)
+	conn.handshakeMode = mode
+	return conn, nil
 }
 
 // newConn creates a new RTMP connection without handshake (internal use)
 func newConn(netConn net.Conn) *Conn {
 	return &Conn{
+		netConn:      netConn,
 		transport:    transport.NewTransport(netConn),
 		config:       DefaultConfig(),
 		streams:      make(map[uint32]*Stream),
 		nextStreamID: 1,
+		calls:        make(map[float64]chan callResult),
+		handlers:     make(map[string]CallHandler),
+		nextTxID:     1,
+		amf3:         amf.NewAMF3Context(),
 	}
 }
 
+// HandshakeMode returns the handshake mode negotiated with the peer
+func (c *Conn) HandshakeMode() transport.HandshakeMode {
+	return c.handshakeMode
+}
+
+// ConnectInfo returns the parsed connect command (nil before connect is handled)
+func (c *Conn) ConnectInfo() *ConnectCommand {
+	return c.connectInfo
+}
+
+// ObjectEncoding returns the encoding of outgoing commands
+// (connect에서 협상된 ObjectEncodingAMF0 또는 ObjectEncodingAMF3)
+func (c *Conn) ObjectEncoding() float64 {
+	c.amfMu.Lock()
+	defer c.amfMu.Unlock()
+	return c.objectEncoding
+}
+
+// setObjectEncoding sets the encoding of outgoing commands
+func (c *Conn) setObjectEncoding(objectEncoding float64) {
+	c.amfMu.Lock()
+	defer c.amfMu.Unlock()
+	c.objectEncoding = objectEncoding
+}
+
+// decodeCommand decodes an AMF0 or AMF3 command message
+func (c *Conn) decodeCommand(msg transport.Message) (*Command, error) {
+	if msg.Type() != transport.MsgTypeAMF3Command {
+		return DecodeCommand(msg.Data())
+	}
+	c.amfMu.Lock()
+	defer c.amfMu.Unlock()
+	return DecodeCommandAMF3(msg.Data(), c.amf3)
+}
+
+// amf0Data converts an AMF3 data message to an AMF0 data message (호출자가 해제)
+func (c *Conn) amf0Data(msg transport.Message) (transport.Message, error) {
+	c.amfMu.Lock()
+	values, err := DecodeDataAMF3(msg.Data(), c.amf3)
+	c.amfMu.Unlock()
+	if err != nil {
+		return transport.Message{}, err
+	}
+
+	data, err := amf.EncodeAMF0Sequence(values...)
+	if err != nil {
+		return transport.Message{}, err
+	}
+	header := transport.NewMessageHeader(msg.StreamID(), msg.Timestamp(), transport.MsgTypeAMF0Data)
+	return transport.NewMessage(header, buf.New(data)), nil
+}
+
+// newCommandMessage creates a command message in the negotiated encoding
+func (c *Conn) newCommandMessage(streamID uint32, name string, txID float64, obj map[string]interface{}, args ...interface{}) (transport.Message, error) {
+	c.amfMu.Lock()
+	defer c.amfMu.Unlock()
+
+	if c.objectEncoding != ObjectEncodingAMF3 {
+		return NewCommandMessage(streamID, name, txID, obj, args...)
+	}
+	data, err := EncodeCommandAMF3(c.amf3, name, txID, obj, args...)
+	if err != nil {
+		return transport.Message{}, err
+	}
+	header := transport.NewMessageHeader(streamID, 0, transport.MsgTypeAMF3Command)
+	return transport.NewMessage(header, buf.New(data)), nil
+}
+
+// newDataMessage creates a data message (onMetaData, onPlayStatus 등) in the negotiated encoding
+func (c *Conn) newDataMessage(streamID uint32, values ...interface{}) (transport.Message, error) {
+	c.amfMu.Lock()
+	defer c.amfMu.Unlock()
+
+	msgType := uint8(transport.MsgTypeAMF0Data)
+	var (
+		data []byte
+		err  error
+	)
+	if c.objectEncoding == ObjectEncodingAMF3 {
+		msgType = transport.MsgTypeAMF3Data
+		data, err = EncodeDataAMF3(c.amf3, values...)
+	} else {
+		data, err = amf.EncodeAMF0Sequence(values...)
+	}
+	if err != nil {
+		return transport.Message{}, err
+	}
+	header := transport.NewMessageHeader(streamID, 0, msgType)
+	return transport.NewMessage(header, buf.New(data)), nil
+}
+
+// RemoteAddr returns the remote network address
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.netConn.RemoteAddr()
+}
+
 // Close closes the connection
+// 응답을 기다리는 Call은 ErrConnClosed로 종료됨
 func (c *Conn) Close() error {
+	c.failCalls(ErrConnClosed)
 	return c.transport.Close()
 }
 
@@ -56,6 +202,7 @@ func (c *Conn) createStream() *Stream {
 	c.nextStreamID++
 
 	stream := NewStream(streamID)
+	stream.conn = c
 	c.streams[streamID] = stream
 
 	return stream
@@ -78,13 +225,80 @@ func (c *Conn) Streams() map[uint32]*Stream {
 
 // ReadMessage reads a message from the connection
 // 프로토콜 제어 메시지 (SetChunkSize 등)는 자동으로 내부 처리됨
+// Call 응답(_result/_error)과 HandleCall로 등록된 커맨드도 내부 처리되어 반환되지 않음
 func (c *Conn) ReadMessage() (transport.Message, error) {
-	return c.transport.ReadMessage()
+	return c.ReadMessageContext(context.Background())
+}
+
+// ReadMessageContext reads a message like ReadMessage, bounded by ctx.
+// 기한 초과 시 transport.ErrReadTimeout, 취소 시 context.Cause(ctx) 반환 (이후 연결은 닫아야 함)
+func (c *Conn) ReadMessageContext(ctx context.Context) (transport.Message, error) {
+	for {
+		msg, serve, err := c.readMessage(ctx)
+		if serve != nil {
+			// readMu를 놓은 상태에서 handler 실행: handler 안의 Call이 응답을 읽을 수 있음
+			serve()
+			continue
+		}
+		return msg, err
+	}
+}
+
+// readMessage reads the next message for the caller, or returns a registered call to serve
+func (c *Conn) readMessage(ctx context.Context) (transport.Message, func(), error) {
+	c.readMu.Lock()
+	defer c.unlockRead()
+
+	for {
+		// pump가 먼저 읽어 둔 메시지/에러 우선 반환
+		c.callMu.Lock()
+		if len(c.backlog) > 0 {
+			msg := c.backlog[0]
+			c.backlog = c.backlog[1:]
+			c.callMu.Unlock()
+			return msg, nil, nil
+		}
+		if c.readErr != nil {
+			err := c.readErr
+			c.callMu.Unlock()
+			return transport.Message{}, nil, err
+		}
+		c.callMu.Unlock()
+
+		msg, err := c.transport.ReadMessageContext(ctx)
+		if err != nil {
+			c.failCalls(err)
+			return transport.Message{}, nil, err
+		}
+		consumed, serve := c.dispatch(msg)
+		if serve != nil {
+			return transport.Message{}, serve, nil
+		}
+		if consumed {
+			continue
+		}
+		return msg, nil, nil
+	}
+}
+
+// unlockRead releases readMu and starts a pump for calls registered while it was held.
+// readMu를 잡은 동안 시작된 Call은 pump를 시작하지 못했으므로, 다음 ReadMessage가 없어도 응답을 읽도록 넘김
+func (c *Conn) unlockRead() {
+	c.readMu.Unlock()
+	if c.hasPendingCalls() && c.readMu.TryLock() {
+		go c.pump()
+	}
 }
 
-// WriteMessage writes a message to the connection
+// WriteMessage writes a message to the connection; safe for concurrent use
 // Protocol control messages that require state synchronization cannot be sent directly
 func (c *Conn) WriteMessage(msg transport.Message) error {
+	return c.WriteMessageContext(context.Background(), msg)
+}
+
+// WriteMessageContext writes a message like WriteMessage, bounded by ctx.
+// 기한 초과 시 transport.ErrWriteTimeout, 취소 시 context.Cause(ctx) 반환
+func (c *Conn) WriteMessageContext(ctx context.Context, msg transport.Message) error {
 	// Prevent direct sending of protocol control messages that have dedicated methods
 	switch msg.Type() {
 	case transport.MsgTypeSetChunkSize:
@@ -97,7 +311,7 @@ func (c *Conn) WriteMessage(msg transport.Message) error {
 		return fmt.Errorf("Acknowledgement messages are sent automatically by the Transport layer")
 	}
 
-	return c.transport.WriteMessage(msg)
+	return c.transport.WriteMessageContext(ctx, msg)
 }
 
 // SetChunkSize sets the outgoing chunk size
@@ -117,3 +331,32 @@ func (c *Conn) SetWindowAckSize(size uint32) error {
 func (c *Conn) SetPeerBandwidth(size uint32, limitType uint8) error {
 	return c.transport.SetPeerBandwidth(size, limitType)
 }
+
+// SetReadTimeout sets the read idle timeout (0이면 없음)
+// 이 시간 동안 데이터를 받지 못하면 ReadMessage가 transport.ErrReadTimeout으로 실패
+func (c *Conn) SetReadTimeout(timeout time.Duration) {
+	c.transport.SetReadTimeout(timeout)
+}
+
+// SetWriteTimeout sets the write timeout (0이면 없음)
+// 이 시간 동안 전송이 진행되지 않으면 쓰기가 transport.ErrWriteTimeout으로 실패
+func (c *Conn) SetWriteTimeout(timeout time.Duration) {
+	c.transport.SetWriteTimeout(timeout)
+}
+
+// SetFlowMode sets how media writes behave when the peer's SetPeerBandwidth window is exhausted
+// (기본값 transport.FlowBlock)
+func (c *Conn) SetFlowMode(mode transport.FlowMode) {
+	c.transport.SetFlowMode(mode)
+}
+
+// BytesInFlight returns the bytes sent but not yet acknowledged by the peer
+// 느린 수신자 감지에 사용 (상대가 ACK를 보내지 않으면 전송한 전체 바이트)
+func (c *Conn) BytesInFlight() uint64 {
+	return c.transport.BytesInFlight()
+}
+
+// SendWindow returns the send window set by the peer (0이면 제한 없음)
+func (c *Conn) SendWindow() uint32 {
+	return c.transport.SendWindow()
+}
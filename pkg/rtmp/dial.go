@@ -0,0 +1,71 @@
+package rtmp
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"time"
+)
+
+// URL schemes and default ports
+const (
+	SchemeRTMP  = "rtmp"
+	SchemeRTMPS = "rtmps"
+
+	DefaultPort    = "1935"
+	DefaultTLSPort = "443"
+
+	DefaultDialTimeout = 10 * time.Second
+)
+
+// DialURL dials an rtmp:// or rtmps:// URL and performs the client handshake
+// rtmps의 경우 config가 nil이면 기본 TLS 설정을 사용하며, ServerName(SNI)은 URL 호스트로 채워짐
+func DialURL(rawURL string, config *tls.Config) (*Conn, error) {
+	return DialURLContext(context.Background(), rawURL, config)
+}
+
+// DialURLContext dials like DialURL with the dial and handshake bounded by ctx
+func DialURLContext(ctx context.Context, rawURL string, config *tls.Config) (*Conn, error) {
+	u, err := ParseURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	netConn, err := dialNet(ctx, u, config)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := DialConnContext(ctx, netConn)
+	if err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// dialNet opens the underlying TCP or TLS connection for a parsed URL
+func dialNet(ctx context.Context, u *URL, config *tls.Config) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: DefaultDialTimeout}
+
+	if u.Scheme != SchemeRTMPS {
+		return dialer.DialContext(ctx, "tcp", u.Address())
+	}
+
+	// SNI: ServerName이 비어 있으면 URL 호스트 사용
+	tlsConfig := &tls.Config{}
+	if config != nil {
+		tlsConfig = config.Clone()
+	}
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = u.Host
+	}
+
+	tlsDialer := &tls.Dialer{NetDialer: dialer, Config: tlsConfig}
+	tlsConn, err := tlsDialer.DialContext(ctx, "tcp", u.Address())
+	if err != nil {
+		return nil, fmt.Errorf("tls dial: %w", err)
+	}
+	return tlsConn, nil
+}
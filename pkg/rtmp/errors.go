@@ -0,0 +1,64 @@
+package rtmp
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrConnClosed is returned to pending calls when the connection is closed
+	ErrConnClosed = errors.New("rtmp connection closed")
+
+	// ErrServerClosed is returned by Server.Serve and ServeConn after Close
+	ErrServerClosed = errors.New("rtmp server closed")
+)
+
+// StatusError represents an _error response or an error-level onStatus from the peer
+type StatusError struct {
+	Command     string // 실패한 커맨드 이름 (connect, publish, play 등)
+	Level       string
+	Code        string
+	Description string
+}
+
+// Error implements the error interface
+func (e *StatusError) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("%s failed: %s (%s)", e.Command, e.Code, e.Description)
+	}
+	return fmt.Sprintf("%s failed: %s", e.Command, e.Code)
+}
+
+// errorInfo builds an error-level info object for _error and onStatus responses.
+// *StatusError의 code/description이 있으면 그대로 사용
+func errorInfo(err error, defaultCode string) map[string]interface{} {
+	info := map[string]interface{}{
+		"level":       "error",
+		"code":        defaultCode,
+		"description": err.Error(),
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.Code != "" {
+		info["code"] = statusErr.Code
+		info["description"] = statusErr.Description
+	}
+	return info
+}
+
+// newStatusError creates a StatusError from an info object
+func newStatusError(command string, info map[string]interface{}) *StatusError {
+	e := &StatusError{Command: command}
+	if info == nil {
+		return e
+	}
+	if v, ok := info["level"].(string); ok {
+		e.Level = v
+	}
+	if v, ok := info["code"].(string); ok {
+		e.Code = v
+	}
+	if v, ok := info["description"].(string); ok {
+		e.Description = v
+	}
+	return e
+}
@@ -0,0 +1,81 @@
+package rtmp
+
+import "github.com/ssungk/ertmp/pkg/rtmp/transport"
+
+// Handler receives server-side connection events from a Server.
+//
+// On* 메서드 중 error를 반환하는 것은 해당 단계를 거부할 수 있음:
+//   - OnConnect: _error (NetConnection.Connect.Rejected) 전송 후 연결 종료
+//   - OnCreateStream: _error (NetConnection.Call.Failed) 전송
+//   - OnPublish: onStatus NetStream.Publish.BadName 전송
+//   - OnPlay: onStatus NetStream.Play.StreamNotFound 전송
+//
+// 반환한 error가 *StatusError이면 그 code/description이 응답에 사용됨.
+// 한 연결의 이벤트는 모두 같은 고루틴에서 순서대로 호출됨.
+type Handler interface {
+	// OnConnect is called for the connect command before the _result is sent
+	OnConnect(conn *Conn, cmd *ConnectCommand) error
+
+	// OnCreateStream is called for a new message stream before its ID is returned
+	OnCreateStream(conn *Conn, stream *Stream) error
+
+	// OnPublish is called before NetStream.Publish.Start is sent.
+	// cmd.StreamKey를 수정하면 (예: 쿼리 제거) 수정된 값이 stream key로 설정됨
+	OnPublish(conn *Conn, stream *Stream, cmd *PublishCommand) error
+
+	// OnPlay is called before StreamBegin and NetStream.Play.Start are sent.
+	// cmd.StreamKey를 수정하면 수정된 값이 stream key로 설정됨
+	OnPlay(conn *Conn, stream *Stream, cmd *PlayCommand) error
+
+	// OnPlayStart is called after NetStream.Play.Start is sent.
+	// 초기 데이터(메타데이터, sequence header) 전송이나 VOD 재생 시작은 여기서 수행
+	OnPlayStart(conn *Conn, stream *Stream)
+
+	// OnMedia is called for audio/video messages of a publishing stream.
+	// msg는 반환 후 해제되므로 보관하려면 Retain 필요
+	OnMedia(conn *Conn, stream *Stream, msg transport.Message)
+
+	// OnMetadata is called for data messages (@setDataFrame 등) of a publishing stream.
+	// AMF3 data 메시지(0x0F)는 AMF0 data로 변환되어 전달됨.
+	// msg는 반환 후 해제되므로 보관하려면 Retain 필요
+	OnMetadata(conn *Conn, stream *Stream, msg transport.Message)
+
+	// OnDeleteStream is called when a stream is deleted or closed,
+	// and for every remaining stream when the connection ends
+	OnDeleteStream(conn *Conn, stream *Stream)
+
+	// OnClose is called once when the connection ends
+	// err은 정상 종료(EOF, Server.Close) 시 nil
+	OnClose(conn *Conn, err error)
+}
+
+// BaseHandler accepts every request and ignores all events.
+// Embed it to implement only the methods you need.
+type BaseHandler struct{}
+
+// OnConnect accepts the connection
+func (BaseHandler) OnConnect(conn *Conn, cmd *ConnectCommand) error { return nil }
+
+// OnCreateStream accepts the stream
+func (BaseHandler) OnCreateStream(conn *Conn, stream *Stream) error { return nil }
+
+// OnPublish accepts the publish request
+func (BaseHandler) OnPublish(conn *Conn, stream *Stream, cmd *PublishCommand) error { return nil }
+
+// OnPlay accepts the play request
+func (BaseHandler) OnPlay(conn *Conn, stream *Stream, cmd *PlayCommand) error { return nil }
+
+// OnPlayStart does nothing
+func (BaseHandler) OnPlayStart(conn *Conn, stream *Stream) {}
+
+// OnMedia ignores the message
+func (BaseHandler) OnMedia(conn *Conn, stream *Stream, msg transport.Message) {}
+
+// OnMetadata ignores the message
+func (BaseHandler) OnMetadata(conn *Conn, stream *Stream, msg transport.Message) {}
+
+// OnDeleteStream does nothing
+func (BaseHandler) OnDeleteStream(conn *Conn, stream *Stream) {}
+
+// OnClose does nothing
+func (BaseHandler) OnClose(conn *Conn, err error) {}
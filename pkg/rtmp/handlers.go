@@ -8,7 +8,7 @@ import (
 
 // HandleConnect handles a connect command (server side)
 func HandleConnect(conn *Conn, msg transport.Message) error {
-	cmd, err := DecodeCommand(msg.Data())
+	cmd, err := conn.decodeCommand(msg)
 	if err != nil {
 		return fmt.Errorf("failed to decode connect command: %w", err)
 	}
@@ -18,38 +18,12 @@ func HandleConnect(conn *Conn, msg transport.Message) error {
 		return fmt.Errorf("failed to parse connect: %w", err)
 	}
 
-	// 프로토콜 제어 메시지 전송
-	if err := conn.SetWindowAckSize(conn.config.WindowAckSize); err != nil {
-		return err
-	}
-	if err := conn.SetPeerBandwidth(conn.config.PeerBandwidth, 2); err != nil {
-		return err
-	}
-	if err := conn.SetChunkSize(conn.config.ChunkSize); err != nil {
-		return err
-	}
-
-	// 응답 속성 구성
-	props := map[string]interface{}{
-		"fmsVer":       "FMS/3,0,1,123",
-		"capabilities": 31.0,
-	}
-
-	// Enhanced RTMP 지원
-	if len(connectCmd.FourCcList) > 0 {
-		props["fourCcList"] = connectCmd.FourCcList
-	}
-	if connectCmd.CapsEx != nil {
-		props["capsEx"] = connectCmd.CapsEx
-	}
-
-	// connect 응답 전송
-	return SendConnectResponse(conn, cmd.TransactionID, props)
+	return acceptConnect(conn, cmd.TransactionID, connectCmd)
 }
 
 // HandleCreateStream handles a createStream command (server side)
 func HandleCreateStream(conn *Conn, msg transport.Message) (*Stream, error) {
-	cmd, err := DecodeCommand(msg.Data())
+	cmd, err := conn.decodeCommand(msg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode createStream command: %w", err)
 	}
@@ -67,7 +41,7 @@ func HandleCreateStream(conn *Conn, msg transport.Message) (*Stream, error) {
 
 // HandlePublish handles a publish command (server side)
 func HandlePublish(conn *Conn, msg transport.Message) error {
-	cmd, err := DecodeCommand(msg.Data())
+	cmd, err := conn.decodeCommand(msg)
 	if err != nil {
 		return fmt.Errorf("failed to decode publish command: %w", err)
 	}
@@ -84,16 +58,12 @@ func HandlePublish(conn *Conn, msg transport.Message) error {
 		return fmt.Errorf("stream not found: %d", streamID)
 	}
 
-	// 스트림 정보 설정
-	stream.SetKey(publishCmd.StreamKey)
-	stream.SetMode(StreamModePublish)
-
-	return SendOnStatus(conn, streamID, "status", "NetStream.Publish.Start", "Publishing")
+	return acceptPublish(conn, stream, publishCmd)
 }
 
 // HandlePlay handles a play command (server side)
 func HandlePlay(conn *Conn, msg transport.Message) error {
-	cmd, err := DecodeCommand(msg.Data())
+	cmd, err := conn.decodeCommand(msg)
 	if err != nil {
 		return fmt.Errorf("failed to decode play command: %w", err)
 	}
@@ -110,9 +80,81 @@ func HandlePlay(conn *Conn, msg transport.Message) error {
 		return fmt.Errorf("stream not found: %d", streamID)
 	}
 
+	return acceptPlay(conn, stream, playCmd)
+}
+
+// acceptConnect sends protocol control messages and the connect _result
+func acceptConnect(conn *Conn, txID float64, connectCmd *ConnectCommand) error {
+	conn.connectInfo = connectCmd
+
+	// 프로토콜 제어 메시지 전송
+	if err := conn.SetWindowAckSize(conn.config.WindowAckSize); err != nil {
+		return err
+	}
+	if err := conn.SetPeerBandwidth(conn.config.PeerBandwidth, transport.LimitTypeDynamic); err != nil {
+		return err
+	}
+	if err := conn.SetChunkSize(conn.config.ChunkSize); err != nil {
+		return err
+	}
+
+	// 응답 속성 구성
+	props := map[string]interface{}{
+		"fmsVer":       "FMS/3,0,1,123",
+		"capabilities": 31.0,
+	}
+
+	// Enhanced RTMP 지원
+	if len(connectCmd.FourCcList) > 0 {
+		fourCcList := make([]interface{}, len(connectCmd.FourCcList))
+		for i, fcc := range connectCmd.FourCcList {
+			fourCcList[i] = fcc
+		}
+		props["fourCcList"] = fourCcList
+	}
+	if connectCmd.CapsEx != nil {
+		props["capsEx"] = connectCmd.CapsEx
+	}
+
+	// connect 응답 전송 (AMF0), 이후 커맨드는 협상된 인코딩으로 전송
+	if err := SendConnectResponse(conn, txID, props); err != nil {
+		return err
+	}
+	if connectCmd.ObjectEncoding == ObjectEncodingAMF3 {
+		conn.setObjectEncoding(ObjectEncodingAMF3)
+	}
+	return nil
+}
+
+// acceptPublish marks the stream as publishing and sends NetStream.Publish.Start
+func acceptPublish(conn *Conn, stream *Stream, publishCmd *PublishCommand) error {
+	// 스트림 정보 설정
+	stream.SetKey(publishCmd.StreamKey)
+	stream.SetMode(StreamModePublish)
+	stream.SetPublishType(publishCmd.PublishType)
+
+	return SendOnStatus(conn, stream.ID(), "status", "NetStream.Publish.Start", "Publishing")
+}
+
+// acceptPlay marks the stream as playing and sends StreamBegin and NetStream.Play.Start
+// 녹화 파일 재생이면 StreamIsRecorded, reset 요청이면 NetStream.Play.Reset도 전송
+func acceptPlay(conn *Conn, stream *Stream, playCmd *PlayCommand) error {
 	// 스트림 정보 설정
 	stream.SetKey(playCmd.StreamKey)
 	stream.SetMode(StreamModePlay)
 
-	return SendOnStatus(conn, streamID, "status", "NetStream.Play.Start", "Playing")
+	if stream.Recorded() {
+		if err := SendStreamIsRecorded(conn, stream.ID()); err != nil {
+			return err
+		}
+	}
+	if err := SendStreamBegin(conn, stream.ID()); err != nil {
+		return err
+	}
+	if playCmd.Reset {
+		if err := SendOnStatus(conn, stream.ID(), "status", "NetStream.Play.Reset", "Playing and resetting"); err != nil {
+			return err
+		}
+	}
+	return SendOnStatus(conn, stream.ID(), "status", "NetStream.Play.Start", "Playing")
 }
@@ -0,0 +1,206 @@
+package rtmpt
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Dial opens an RTMPT session to baseURL (e.g. "http://host:80") using http.DefaultClient
+func Dial(baseURL string) (net.Conn, error) {
+	return DialWithClient(baseURL, http.DefaultClient)
+}
+
+// DialWithClient opens an RTMPT session using the given HTTP client
+func DialWithClient(baseURL string, client *http.Client) (net.Conn, error) {
+	c := &clientConn{
+		client:   client,
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		in:       newDataQueue(queueSize),
+		out:      newDataQueue(queueSize),
+		done:     make(chan struct{}),
+		interval: MinPollInterval,
+	}
+
+	// 일부 서버는 ident2 요청을 기대함 (응답은 무시)
+	_, _ = c.post("/"+cmdIdent+"/ident2", nil)
+
+	resp, err := c.post("/"+cmdOpen+"/1", nil)
+	if err != nil {
+		return nil, fmt.Errorf("rtmpt open: %w", err)
+	}
+	c.sessionID = strings.TrimSpace(string(resp))
+	if c.sessionID == "" {
+		return nil, fmt.Errorf("rtmpt open: empty session ID")
+	}
+
+	go c.poll()
+	return c, nil
+}
+
+// clientConn is the client side of an RTMPT session
+type clientConn struct {
+	client    *http.Client
+	baseURL   string
+	sessionID string
+	seq       uint64
+	in        *dataQueue // server -> client
+	out       *dataQueue // client -> server
+	interval  byte
+	done      chan struct{}
+	closeOnce sync.Once
+	mu        sync.Mutex // seq 보호 (poll 고루틴과 Close)
+}
+
+// poll exchanges data with the server until the session is closed
+func (c *clientConn) poll() {
+	for {
+		select {
+		case <-c.done:
+			return
+		default:
+		}
+
+		data := c.out.Drain()
+		cmd := cmdIdle
+		if len(data) > 0 {
+			cmd = cmdSend
+		}
+
+		resp, err := c.exchange(cmd, data)
+		if err != nil {
+			c.in.CloseWithError(err)
+			c.out.Close()
+			return
+		}
+		if len(resp) == 0 {
+			c.in.CloseWithError(fmt.Errorf("rtmpt %s: empty response", cmd))
+			c.out.Close()
+			return
+		}
+
+		c.interval = resp[0]
+		if payload := resp[1:]; len(payload) > 0 {
+			// 수신 큐가 가득 차면 애플리케이션이 읽을 때까지 polling 중단
+			if _, err := c.in.Write(payload); err != nil {
+				c.out.Close()
+				return
+			}
+		}
+
+		// 데이터가 오갔으면 즉시 다음 요청
+		if len(data) > 0 || len(resp) > 1 {
+			continue
+		}
+
+		// 송신 데이터가 생기거나 polling 간격이 지날 때까지 대기
+		changed := c.out.Changed()
+		if c.out.Len() > 0 {
+			continue
+		}
+		timer := time.NewTimer(time.Duration(c.interval) * PollUnit)
+		select {
+		case <-c.done:
+			timer.Stop()
+			return
+		case <-changed:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// exchange sends a send/idle/close request with the next sequence number
+func (c *clientConn) exchange(cmd string, data []byte) ([]byte, error) {
+	c.mu.Lock()
+	c.seq++
+	path := fmt.Sprintf("/%s/%s/%d", cmd, c.sessionID, c.seq)
+	c.mu.Unlock()
+
+	return c.post(path, data)
+}
+
+// post performs an RTMPT HTTP request and returns the response body
+func (c *clientConn) post(path string, body []byte) ([]byte, error) {
+	if body == nil {
+		body = []byte{0x00} // 빈 본문 대신 0x00 한 바이트 (Flash Player 동작)
+	}
+	req, err := http.NewRequest(http.MethodPost, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", ContentType)
+	req.Header.Set("Cache-Control", "no-cache")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("rtmpt %s: HTTP %d", path, resp.StatusCode)
+	}
+	return data, nil
+}
+
+// Read reads data received from the server
+func (c *clientConn) Read(p []byte) (int, error) {
+	return c.in.Read(p)
+}
+
+// Write queues data for the next send request
+// 큐가 가득 차면 전송될 때까지 대기 (SetWriteDeadline 적용)
+func (c *clientConn) Write(p []byte) (int, error) {
+	return c.out.Write(p)
+}
+
+// Close closes the session
+func (c *clientConn) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.done)
+		_, err = c.exchange(cmdClose, nil)
+		c.in.Close()
+		c.out.Close()
+	})
+	return err
+}
+
+// LocalAddr returns the local address
+func (c *clientConn) LocalAddr() net.Addr {
+	return Addr{addr: "rtmpt"}
+}
+
+// RemoteAddr returns the server base URL
+func (c *clientConn) RemoteAddr() net.Addr {
+	return Addr{addr: c.baseURL}
+}
+
+// SetDeadline sets the read and write deadlines
+func (c *clientConn) SetDeadline(t time.Time) error {
+	c.in.SetReadDeadline(t)
+	c.out.SetWriteDeadline(t)
+	return nil
+}
+
+// SetReadDeadline sets the read deadline
+func (c *clientConn) SetReadDeadline(t time.Time) error {
+	c.in.SetReadDeadline(t)
+	return nil
+}
+
+// SetWriteDeadline sets the deadline of writes blocked on a full queue
+func (c *clientConn) SetWriteDeadline(t time.Time) error {
+	c.out.SetWriteDeadline(t)
+	return nil
+}
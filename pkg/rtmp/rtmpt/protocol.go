@@ -0,0 +1,79 @@
+// Package rtmpt implements RTMPT, RTMP tunneled over HTTP.
+//
+// The client opens a session with POST /open/1 and then exchanges data with
+// POST /send/<session>/<seq> (client data in the body) and polls with
+// POST /idle/<session>/<seq>. Every send/idle response starts with a one byte
+// polling interval followed by any data queued by the server. POST /close
+// terminates the session.
+//
+// Both sides expose the tunnel as a net.Conn, so the regular RTMP handshake,
+// transport and rtmp.Conn run unchanged on top of it:
+//
+//	server := rtmpt.NewServer()
+//	go http.ListenAndServe(":80", server)
+//	netConn, _ := server.Accept()
+//	conn, _ := rtmp.AcceptConn(netConn)
+//
+//	netConn, _ := rtmpt.Dial("http://host:80")
+//	conn, _ := rtmp.DialConn(netConn)
+package rtmpt
+
+import "time"
+
+// HTTP protocol constants
+const (
+	ContentType = "application/x-fcs"
+
+	cmdIdent = "fcs"
+	cmdOpen  = "open"
+	cmdSend  = "send"
+	cmdIdle  = "idle"
+	cmdClose = "close"
+)
+
+// Polling interval constants
+// 응답 첫 바이트(interval)는 클라이언트가 다음 idle 요청까지 대기할 시간 단위
+const (
+	MinPollInterval = 0x01
+	MaxPollInterval = 0x21
+	PollUnit        = 10 * time.Millisecond
+)
+
+// Session constants
+// 한 세션이 보관하는 데이터는 방향마다 queueSize, 요청 본문은 MaxRequestBodySize로 제한.
+// 애플리케이션이 sendTimeout 동안 수신 큐를 비우지 않으면 세션을 닫음
+const (
+	DefaultSessionTimeout = 30 * time.Second
+	MaxRequestBodySize    = 1 << 20
+	acceptBacklog         = 16
+	queueSize             = 1 << 20
+	sendTimeout           = 10 * time.Second
+)
+
+// Addr is the net.Addr of an RTMPT tunnel endpoint
+type Addr struct {
+	addr string
+}
+
+// Network returns the network name
+func (a Addr) Network() string {
+	return "rtmpt"
+}
+
+// String returns the address string
+func (a Addr) String() string {
+	return a.addr
+}
+
+// nextInterval returns the polling interval after an exchange
+// 데이터가 오가면 최소값으로 되돌리고, 유휴 상태면 두 배씩 늘림
+func nextInterval(interval byte, active bool) byte {
+	if active {
+		return MinPollInterval
+	}
+	next := int(interval) * 2
+	if next > MaxPollInterval {
+		next = MaxPollInterval
+	}
+	return byte(next)
+}
@@ -0,0 +1,193 @@
+package rtmpt
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// dataQueue is a bounded byte queue shared between HTTP handlers and a net.Conn.
+// Read blocks until data arrives, the queue is closed or the read deadline passes.
+// Write blocks while the queue holds limit bytes (backpressure), until the write deadline passes
+type dataQueue struct {
+	mu            sync.Mutex
+	buf           bytes.Buffer
+	limit         int           // 최대 보관 바이트 수
+	err           error         // 닫힌 후 Read가 반환할 에러
+	changed       chan struct{} // 상태 변경 시 close되고 교체됨 (broadcast)
+	readDeadline  time.Time
+	writeDeadline time.Time
+}
+
+// newDataQueue creates a new data queue holding at most limit bytes
+func newDataQueue(limit int) *dataQueue {
+	return &dataQueue{
+		limit:   limit,
+		changed: make(chan struct{}),
+	}
+}
+
+// signal wakes up all waiters (must hold mu)
+func (q *dataQueue) signal() {
+	close(q.changed)
+	q.changed = make(chan struct{})
+}
+
+// Read reads queued data, blocking until data is available
+func (q *dataQueue) Read(p []byte) (int, error) {
+	for {
+		q.mu.Lock()
+		if q.buf.Len() > 0 {
+			n, _ := q.buf.Read(p)
+			q.signal() // 대기 중인 Write에 공간이 생겼음을 알림
+			q.mu.Unlock()
+			return n, nil
+		}
+		if q.err != nil {
+			err := q.err
+			q.mu.Unlock()
+			return 0, err
+		}
+		deadline := q.readDeadline
+		changed := q.changed
+		q.mu.Unlock()
+
+		if err := waitChange(context.Background(), changed, deadline); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// Write appends data to the queue, blocking while it is full
+// 공간이 생기는 만큼 나누어 쓰며, 기한 초과 시 쓴 바이트 수와 os.ErrDeadlineExceeded 반환
+func (q *dataQueue) Write(p []byte) (int, error) {
+	return q.WriteContext(context.Background(), p)
+}
+
+// WriteContext writes like Write and also stops waiting when ctx is done (쓴 바이트 수와 ctx.Err() 반환)
+func (q *dataQueue) WriteContext(ctx context.Context, p []byte) (int, error) {
+	written := 0
+	for {
+		q.mu.Lock()
+		if q.err != nil {
+			q.mu.Unlock()
+			return written, io.ErrClosedPipe
+		}
+		if space := q.limit - q.buf.Len(); space > 0 {
+			n := min(space, len(p)-written)
+			q.buf.Write(p[written : written+n])
+			written += n
+			q.signal()
+			if written == len(p) {
+				q.mu.Unlock()
+				return written, nil
+			}
+		}
+		deadline := q.writeDeadline
+		changed := q.changed
+		q.mu.Unlock()
+
+		if err := waitChange(ctx, changed, deadline); err != nil {
+			return written, err
+		}
+	}
+}
+
+// Drain removes and returns all queued data
+func (q *dataQueue) Drain() []byte {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.buf.Len() == 0 {
+		return nil
+	}
+	data := bytes.Clone(q.buf.Bytes())
+	q.buf.Reset()
+	q.signal()
+	return data
+}
+
+// Len returns the number of queued bytes
+func (q *dataQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.buf.Len()
+}
+
+// Changed returns a channel closed on the next state change
+func (q *dataQueue) Changed() <-chan struct{} {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.changed
+}
+
+// Closed reports whether the queue has been closed
+func (q *dataQueue) Closed() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.err != nil
+}
+
+// Close closes the queue; pending data can still be read before io.EOF
+func (q *dataQueue) Close() {
+	q.CloseWithError(io.EOF)
+}
+
+// CloseWithError closes the queue with the error returned after pending data
+func (q *dataQueue) CloseWithError(err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.err != nil {
+		return
+	}
+	q.err = err
+	q.signal()
+}
+
+// SetReadDeadline sets the read deadline (zero value disables it)
+func (q *dataQueue) SetReadDeadline(t time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.readDeadline = t
+	q.signal()
+}
+
+// SetWriteDeadline sets the deadline of a blocked Write (zero value disables it)
+func (q *dataQueue) SetWriteDeadline(t time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.writeDeadline = t
+	q.signal()
+}
+
+// waitChange waits for a state change, the deadline or ctx
+func waitChange(ctx context.Context, changed <-chan struct{}, deadline time.Time) error {
+	if deadline.IsZero() {
+		select {
+		case <-changed:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	d := time.Until(deadline)
+	if d <= 0 {
+		return os.ErrDeadlineExceeded
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-changed:
+		return nil
+	case <-timer.C:
+		return os.ErrDeadlineExceeded
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
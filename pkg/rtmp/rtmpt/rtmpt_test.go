@@ -0,0 +1,336 @@
+package rtmpt
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ssungk/ertmp/pkg/rtmp"
+	"github.com/ssungk/ertmp/pkg/rtmp/transport"
+)
+
+func TestRTMPT_RawExchange(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	client, err := Dial(ts.URL)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer client.Close()
+
+	conn, err := server.Accept()
+	if err != nil {
+		t.Fatalf("Accept failed: %v", err)
+	}
+	defer conn.Close()
+
+	// client -> server
+	if _, err := client.Write([]byte("hello")); err != nil {
+		t.Fatalf("client write failed: %v", err)
+	}
+	got := make([]byte, 5)
+	if _, err := io.ReadFull(conn, got); err != nil {
+		t.Fatalf("server read failed: %v", err)
+	}
+	if string(got) != "hello" {
+		t.Errorf("expected 'hello', got %q", got)
+	}
+
+	// server -> client (delivered by idle polling)
+	if _, err := conn.Write([]byte("world")); err != nil {
+		t.Fatalf("server write failed: %v", err)
+	}
+	if _, err := io.ReadFull(client, got); err != nil {
+		t.Fatalf("client read failed: %v", err)
+	}
+	if string(got) != "world" {
+		t.Errorf("expected 'world', got %q", got)
+	}
+}
+
+func TestRTMPT_RTMPConn(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	type result struct {
+		msg transport.Message
+		err error
+	}
+	received := make(chan result, 1)
+	go func() {
+		netConn, err := server.Accept()
+		if err != nil {
+			received <- result{err: err}
+			return
+		}
+		conn, err := rtmp.AcceptConn(netConn)
+		if err != nil {
+			received <- result{err: err}
+			return
+		}
+		defer conn.Close()
+
+		msg, err := conn.ReadMessage()
+		received <- result{msg: msg, err: err}
+	}()
+
+	netConn, err := Dial(ts.URL)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	conn, err := rtmp.DialConn(netConn)
+	if err != nil {
+		t.Fatalf("DialConn failed: %v", err)
+	}
+	defer conn.Close()
+
+	payload := bytes.Repeat([]byte{0xAF}, 300) // 여러 청크로 분할됨
+	if err := rtmp.SendAudio(conn, 1, payload, 20); err != nil {
+		t.Fatalf("SendAudio failed: %v", err)
+	}
+
+	select {
+	case res := <-received:
+		if res.err != nil {
+			t.Fatalf("server read failed: %v", res.err)
+		}
+		defer res.msg.Buffer().Release()
+		if res.msg.Type() != transport.MsgTypeAudio {
+			t.Errorf("expected audio message, got type %d", res.msg.Type())
+		}
+		if !bytes.Equal(res.msg.Data(), payload) {
+			t.Error("payload mismatch")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for message")
+	}
+}
+
+func TestRTMPT_ClientClose(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	client, err := Dial(ts.URL)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	conn, err := server.Accept()
+	if err != nil {
+		t.Fatalf("Accept failed: %v", err)
+	}
+
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// 서버 측 Read는 EOF를 반환해야 함
+	if _, err := conn.Read(make([]byte, 1)); !errors.Is(err, io.EOF) {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestRTMPT_ReadDeadline(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	client, err := Dial(ts.URL)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer client.Close()
+	conn, err := server.Accept()
+	if err != nil {
+		t.Fatalf("Accept failed: %v", err)
+	}
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(20 * time.Millisecond))
+	if _, err := conn.Read(make([]byte, 1)); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestRTMPT_WriteDeadline(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	// polling하지 않는 클라이언트: 서버 송신 큐가 가득 차면 Write가 대기해야 함
+	openSession(t, ts.URL)
+	conn, err := server.Accept()
+	if err != nil {
+		t.Fatalf("Accept failed: %v", err)
+	}
+	defer conn.Close()
+
+	conn.SetWriteDeadline(time.Now().Add(50 * time.Millisecond))
+	n, err := conn.Write(make([]byte, 2*queueSize))
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if n != queueSize {
+		t.Errorf("expected %d bytes queued, got %d", queueSize, n)
+	}
+}
+
+func TestRTMPT_RequestLimits(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	id := openSession(t, ts.URL)
+
+	tests := []struct {
+		name string
+		path string
+		body string
+		want int
+	}{
+		{"first", "/idle/" + id + "/1", "\x00", http.StatusOK},
+		{"next", "/send/" + id + "/2", "data", http.StatusOK},
+		{"replayed", "/send/" + id + "/2", "data", http.StatusBadRequest},
+		{"out of order", "/idle/" + id + "/5", "\x00", http.StatusBadRequest},
+		{"missing sequence", "/idle/" + id, "\x00", http.StatusBadRequest},
+		{"body too large", "/send/" + id + "/3", strings.Repeat("x", MaxRequestBodySize+1), http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Post(ts.URL+tt.path, ContentType, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("POST failed: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestRTMPT_SendTimeout(t *testing.T) {
+	server := NewServer()
+	server.sendTimeout = 50 * time.Millisecond
+	defer server.Close()
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	// 애플리케이션이 읽지 않는 세션: 수신 큐가 가득 찬 뒤의 send는 기한 내에 실패하고 세션이 닫힘
+	id := openSession(t, ts.URL)
+	conn, err := server.Accept()
+	if err != nil {
+		t.Fatalf("Accept failed: %v", err)
+	}
+	defer conn.Close()
+
+	tests := []struct {
+		name string
+		path string
+		body string
+		want int
+	}{
+		{"fills queue", "/send/" + id + "/1", strings.Repeat("x", queueSize), http.StatusOK},
+		{"queue full", "/send/" + id + "/2", "x", http.StatusServiceUnavailable},
+		{"session closed", "/idle/" + id + "/3", "\x00", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Post(ts.URL+tt.path, ContentType, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("POST failed: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, resp.StatusCode)
+			}
+		})
+	}
+
+	// 이미 받은 데이터를 읽은 뒤 EOF
+	n, err := io.Copy(io.Discard, conn)
+	if err != nil || n != queueSize {
+		t.Errorf("expected %d bytes then EOF, got %d, %v", queueSize, n, err)
+	}
+}
+
+func TestRTMPT_CloseBacklog(t *testing.T) {
+	server := NewServer()
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	// Accept되지 않은 세션도 Close로 닫혀야 함
+	ids := []string{openSession(t, ts.URL), openSession(t, ts.URL)}
+	server.Close()
+
+	if conn, err := server.Accept(); !errors.Is(err, ErrServerClosed) {
+		t.Fatalf("expected ErrServerClosed, got %v, %v", conn, err)
+	}
+	if n := len(server.accept); n != 0 {
+		t.Errorf("expected empty accept backlog, got %d", n)
+	}
+	for _, id := range ids {
+		if conn := server.session(id); conn != nil {
+			t.Errorf("session %s still open", id)
+		}
+	}
+}
+
+func TestRTMPT_UnknownSession(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	resp, err := http.Post(ts.URL+"/idle/unknown/1", ContentType, strings.NewReader("\x00"))
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", resp.StatusCode)
+	}
+}
+
+func TestNextInterval(t *testing.T) {
+	interval := byte(MinPollInterval)
+	for i := 0; i < 10; i++ {
+		interval = nextInterval(interval, false)
+	}
+	if interval != MaxPollInterval {
+		t.Errorf("expected max interval 0x%x, got 0x%x", MaxPollInterval, interval)
+	}
+	if got := nextInterval(interval, true); got != MinPollInterval {
+		t.Errorf("expected reset to 0x%x, got 0x%x", MinPollInterval, got)
+	}
+}
+
+// openSession opens an RTMPT session without polling and returns its ID
+func openSession(t *testing.T, baseURL string) string {
+	t.Helper()
+	resp, err := http.Post(baseURL+"/open/1", ContentType, strings.NewReader("\x00"))
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	defer resp.Body.Close()
+	id, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read session ID failed: %v", err)
+	}
+	return strings.TrimSpace(string(id))
+}
@@ -0,0 +1,404 @@
+package rtmpt
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrServerClosed is returned by Accept after the server is closed
+var ErrServerClosed = errors.New("rtmpt: server closed")
+
+// Server is an RTMPT endpoint: an http.Handler that also implements net.Listener.
+// Each opened session is returned from Accept as a net.Conn.
+type Server struct {
+	sessions       map[string]*serverConn
+	accept         chan *serverConn
+	done           chan struct{}
+	sessionTimeout time.Duration
+	sendTimeout    time.Duration // send 본문을 수신 큐에 넣을 때까지 기다리는 최대 시간
+	closeOnce      sync.Once
+	mu             sync.Mutex
+}
+
+// NewServer creates a new RTMPT server
+func NewServer() *Server {
+	s := &Server{
+		sessions:       make(map[string]*serverConn),
+		accept:         make(chan *serverConn, acceptBacklog),
+		done:           make(chan struct{}),
+		sessionTimeout: DefaultSessionTimeout,
+		sendTimeout:    sendTimeout,
+	}
+	go s.expireSessions()
+	return s
+}
+
+// Accept waits for and returns the next RTMPT session
+func (s *Server) Accept() (net.Conn, error) {
+	select {
+	case <-s.done:
+		return nil, ErrServerClosed
+	default:
+	}
+
+	select {
+	case conn := <-s.accept:
+		return conn, nil
+	case <-s.done:
+		return nil, ErrServerClosed
+	}
+}
+
+// Close closes the server and all open sessions
+func (s *Server) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.done)
+
+		s.mu.Lock()
+		sessions := make([]*serverConn, 0, len(s.sessions))
+		for _, conn := range s.sessions {
+			sessions = append(sessions, conn)
+		}
+		// Accept되지 않은 세션 (handleOpen은 s.mu를 잡고 넣으므로 이후 추가되지 않음)
+		for len(s.accept) > 0 {
+			sessions = append(sessions, <-s.accept)
+		}
+		s.mu.Unlock()
+
+		for _, conn := range sessions {
+			conn.Close()
+		}
+	})
+	return nil
+}
+
+// Addr returns the listener address
+func (s *Server) Addr() net.Addr {
+	return Addr{addr: "rtmpt"}
+}
+
+// ServeHTTP handles RTMPT requests
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// 경로: /<cmd>/<session>/<seq> (open은 /open/1)
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	switch parts[0] {
+	case cmdOpen:
+		s.handleOpen(w, r)
+	case cmdSend, cmdIdle, cmdClose:
+		if len(parts) < 2 {
+			http.Error(w, "missing session", http.StatusBadRequest)
+			return
+		}
+		conn := s.session(parts[1])
+		if conn == nil {
+			http.NotFound(w, r)
+			return
+		}
+		if parts[0] == cmdClose {
+			s.handleClose(w, conn)
+			return
+		}
+
+		// send/idle은 한 번에 하나씩, 순서 번호가 1씩 증가해야 함 (재전송, 순서 뒤바뀜 거부)
+		if len(parts) < 3 {
+			http.Error(w, "missing sequence", http.StatusBadRequest)
+			return
+		}
+		seq, err := strconv.ParseUint(parts[2], 10, 64)
+		if err != nil {
+			http.Error(w, "invalid sequence", http.StatusBadRequest)
+			return
+		}
+		if !conn.reqMu.TryLock() {
+			http.Error(w, "request in progress", http.StatusConflict)
+			return
+		}
+		defer conn.reqMu.Unlock()
+		if !conn.nextSeq(seq) {
+			http.Error(w, "unexpected sequence", http.StatusBadRequest)
+			return
+		}
+
+		if parts[0] == cmdSend {
+			s.handleSend(w, r, conn)
+		} else {
+			s.handlePoll(w, conn, false)
+		}
+	default:
+		// /fcs/ident2 등: Flash Player와 동일하게 404 응답
+		http.NotFound(w, r)
+	}
+}
+
+// handleOpen creates a new session
+func (s *Server) handleOpen(w http.ResponseWriter, r *http.Request) {
+	io.Copy(io.Discard, r.Body)
+
+	id, err := newSessionID()
+	if err != nil {
+		http.Error(w, "session id", http.StatusInternalServerError)
+		return
+	}
+
+	local := Addr{addr: "rtmpt"}
+	if addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr); ok {
+		local = Addr{addr: addr.String()}
+	}
+	conn := newServerConn(s, id, local, Addr{addr: r.RemoteAddr})
+
+	s.mu.Lock()
+	select {
+	case <-s.done:
+		s.mu.Unlock()
+		http.Error(w, "server closed", http.StatusServiceUnavailable)
+		return
+	default:
+	}
+	select {
+	case s.accept <- conn:
+		s.sessions[id] = conn
+		s.mu.Unlock()
+	default:
+		// Accept backlog 초과
+		s.mu.Unlock()
+		http.Error(w, "too many pending sessions", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	io.WriteString(w, id+"\n")
+}
+
+// handleSend queues the request body for the session and returns pending data
+// 수신 큐가 가득 차면 애플리케이션이 읽을 때까지 응답을 지연 (backpressure, 최대 sendTimeout)
+func (s *Server) handleSend(w http.ResponseWriter, r *http.Request, conn *serverConn) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxRequestBodySize))
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "read body", http.StatusBadRequest)
+		return
+	}
+	if len(data) > 0 {
+		// 대기하는 동안 reqMu를 잡고 있어 다른 send/idle이 거부되므로 sendTimeout으로 제한.
+		// 일부만 넣고 실패하면 스트림이 어긋나므로 세션을 닫음
+		ctx, cancel := context.WithTimeout(r.Context(), s.sendTimeout)
+		_, err := conn.in.WriteContext(ctx, data)
+		cancel()
+		if errors.Is(err, io.ErrClosedPipe) {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			conn.in.Close()
+			conn.out.Close()
+			s.remove(conn.id)
+			http.Error(w, "session receive queue full", http.StatusServiceUnavailable)
+			return
+		}
+	}
+	s.handlePoll(w, conn, len(data) > 0)
+}
+
+// handlePoll returns the polling interval followed by pending server data
+func (s *Server) handlePoll(w http.ResponseWriter, conn *serverConn, received bool) {
+	data := conn.out.Drain()
+	interval := conn.touch(received || len(data) > 0)
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Write([]byte{interval})
+	w.Write(data)
+
+	// 애플리케이션이 닫은 세션은 남은 데이터를 모두 전달한 뒤 제거
+	if conn.out.Closed() && conn.out.Len() == 0 {
+		s.remove(conn.id)
+	}
+}
+
+// handleClose closes the session at the client's request
+func (s *Server) handleClose(w http.ResponseWriter, conn *serverConn) {
+	conn.in.Close()
+	conn.out.Close()
+	s.remove(conn.id)
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Write([]byte{0x00})
+}
+
+// session returns a session by ID
+func (s *Server) session(id string) *serverConn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sessions[id]
+}
+
+// remove removes a session
+func (s *Server) remove(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, id)
+}
+
+// expireSessions closes sessions the client stopped polling
+func (s *Server) expireSessions() {
+	ticker := time.NewTicker(s.sessionTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case now := <-ticker.C:
+			s.mu.Lock()
+			var expired []*serverConn
+			for _, conn := range s.sessions {
+				if now.Sub(conn.lastSeen()) > s.sessionTimeout {
+					expired = append(expired, conn)
+				}
+			}
+			s.mu.Unlock()
+
+			for _, conn := range expired {
+				conn.in.Close()
+				conn.out.Close()
+				s.remove(conn.id)
+			}
+		}
+	}
+}
+
+// newSessionID generates a random session ID
+func newSessionID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// serverConn is the server side of an RTMPT session
+type serverConn struct {
+	server   *Server
+	id       string
+	in       *dataQueue // client -> server
+	out      *dataQueue // server -> client
+	local    net.Addr
+	remote   net.Addr
+	interval byte
+	seen     time.Time
+	seq      uint64 // 마지막으로 받은 send/idle 순서 번호
+	seqSet   bool
+	mu       sync.Mutex
+	reqMu    sync.Mutex // 진행 중인 send/idle 요청
+}
+
+// newServerConn creates a new server session
+func newServerConn(server *Server, id string, local, remote net.Addr) *serverConn {
+	return &serverConn{
+		server:   server,
+		id:       id,
+		in:       newDataQueue(queueSize),
+		out:      newDataQueue(queueSize),
+		local:    local,
+		remote:   remote,
+		interval: MinPollInterval,
+		seen:     time.Now(),
+	}
+}
+
+// touch records client activity and returns the next polling interval
+func (c *serverConn) touch(active bool) byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.seen = time.Now()
+	c.interval = nextInterval(c.interval, active)
+	return c.interval
+}
+
+// nextSeq accepts seq if it directly follows the previous request
+// 첫 요청은 클라이언트마다 시작 번호가 달라(0 또는 1) 그대로 받아들임
+func (c *serverConn) nextSeq(seq uint64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.seqSet && seq != c.seq+1 {
+		return false
+	}
+	c.seq = seq
+	c.seqSet = true
+	return true
+}
+
+// lastSeen returns the time of the last client request
+func (c *serverConn) lastSeen() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.seen
+}
+
+// Read reads data sent by the client
+func (c *serverConn) Read(p []byte) (int, error) {
+	return c.in.Read(p)
+}
+
+// Write queues data for the client's next send/idle response
+// 큐가 가득 차면 클라이언트가 가져갈 때까지 대기 (SetWriteDeadline 적용)
+func (c *serverConn) Write(p []byte) (int, error) {
+	return c.out.Write(p)
+}
+
+// Close closes the session; queued data is still delivered on the next poll
+func (c *serverConn) Close() error {
+	c.in.Close()
+	c.out.Close()
+	if c.out.Len() == 0 {
+		c.server.remove(c.id)
+	}
+	return nil
+}
+
+// LocalAddr returns the local address
+func (c *serverConn) LocalAddr() net.Addr {
+	return c.local
+}
+
+// RemoteAddr returns the client address of the open request
+func (c *serverConn) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+// SetDeadline sets the read and write deadlines
+func (c *serverConn) SetDeadline(t time.Time) error {
+	c.in.SetReadDeadline(t)
+	c.out.SetWriteDeadline(t)
+	return nil
+}
+
+// SetReadDeadline sets the read deadline
+func (c *serverConn) SetReadDeadline(t time.Time) error {
+	c.in.SetReadDeadline(t)
+	return nil
+}
+
+// SetWriteDeadline sets the deadline of writes blocked on a full queue
+func (c *serverConn) SetWriteDeadline(t time.Time) error {
+	c.out.SetWriteDeadline(t)
+	return nil
+}
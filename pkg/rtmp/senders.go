@@ -1,31 +1,45 @@
 package rtmp
 
 import (
+	"encoding/binary"
 	"fmt"
 
 	"github.com/ssungk/ertmp/pkg/rtmp/buf"
 	"github.com/ssungk/ertmp/pkg/rtmp/transport"
 )
 
-// SendConnectResponse sends a connect response
-func SendConnectResponse(conn *Conn, txID float64, props map[string]interface{}) error {
-	msg := NewConnectResponseMessage(txID, props)
+// SendCommand sends a command message on the given message stream
+// (connect에서 AMF3가 협상되었으면 AMF3 커맨드 메시지로 전송)
+func SendCommand(conn *Conn, streamID uint32, name string, txID float64, obj map[string]interface{}, args ...interface{}) error {
+	msg, err := conn.newCommandMessage(streamID, name, txID, obj, args...)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", name, err)
+	}
 	defer msg.Buffer().Release()
 	return conn.WriteMessage(msg)
 }
 
+// SendConnectResponse sends a connect response
+// info의 objectEncoding은 클라이언트가 요청한 인코딩 (AMF3가 아니면 AMF0)
+func SendConnectResponse(conn *Conn, txID float64, props map[string]interface{}) error {
+	if props == nil {
+		props = make(map[string]interface{})
+	}
+	objectEncoding := ObjectEncodingAMF0
+	if conn.connectInfo != nil && conn.connectInfo.ObjectEncoding == ObjectEncodingAMF3 {
+		objectEncoding = ObjectEncodingAMF3
+	}
+	return SendCommand(conn, 0, "_result", txID, props, connectResultInfo(objectEncoding))
+}
+
 // SendCreateStreamResponse sends a createStream response
 func SendCreateStreamResponse(conn *Conn, txID, streamID float64) error {
-	msg := NewCreateStreamResponseMessage(txID, streamID)
-	defer msg.Buffer().Release()
-	return conn.WriteMessage(msg)
+	return SendCommand(conn, 0, "_result", txID, nil, streamID)
 }
 
 // SendOnStatus sends an onStatus message
 func SendOnStatus(conn *Conn, streamID uint32, level, code, description string) error {
-	msg := NewOnStatusMessage(streamID, level, code, description)
-	defer msg.Buffer().Release()
-	return conn.WriteMessage(msg)
+	return SendCommand(conn, streamID, "onStatus", 0, nil, onStatusInfo(level, code, description))
 }
 
 // SendVideo sends video data
@@ -49,13 +63,47 @@ func SendAudio(conn *Conn, streamID uint32, data []byte, timestamp uint32) error
 // SendMetadata sends metadata
 func SendMetadata(conn *Conn, streamID uint32, metadata map[string]interface{}) error {
 	// 메타데이터 인코딩
-	cmdData, err := EncodeCommand("@setDataFrame", 0, nil, "onMetaData", metadata)
+	msg, err := conn.newDataMessage(streamID, "@setDataFrame", 0.0, nil, "onMetaData", metadata)
 	if err != nil {
 		return fmt.Errorf("failed to encode metadata: %w", err)
 	}
-	buffer := buf.New(cmdData)
-	header := transport.NewMessageHeader(streamID, 0, transport.MsgTypeAMF0Data)
-	msg := transport.NewMessage(header, buffer)
+	defer msg.Buffer().Release()
+	return conn.WriteMessage(msg)
+}
+
+// SendUserControl sends a user control event with a 4-byte stream ID payload
+func SendUserControl(conn *Conn, eventType uint16, streamID uint32) error {
+	data := make([]byte, 4)
+	binary.BigEndian.PutUint32(data, streamID)
+	msg := transport.NewUserControlMessage(eventType, data)
+	defer msg.Buffer().Release()
+	return conn.WriteMessage(msg)
+}
+
+// SendStreamBegin sends a StreamBegin user control event
+func SendStreamBegin(conn *Conn, streamID uint32) error {
+	return SendUserControl(conn, transport.UserControlStreamBegin, streamID)
+}
+
+// SendStreamEOF sends a StreamEOF user control event (재생할 데이터가 끝남)
+func SendStreamEOF(conn *Conn, streamID uint32) error {
+	return SendUserControl(conn, transport.UserControlStreamEOF, streamID)
+}
+
+// SendStreamIsRecorded sends a StreamIsRecorded user control event
+func SendStreamIsRecorded(conn *Conn, streamID uint32) error {
+	return SendUserControl(conn, transport.UserControlStreamIsRecorded, streamID)
+}
+
+// SendPlayStatus sends an onPlayStatus data message (예: NetStream.Play.Complete)
+func SendPlayStatus(conn *Conn, streamID uint32, level, code string) error {
+	msg, err := conn.newDataMessage(streamID, "onPlayStatus", map[string]interface{}{
+		"level": level,
+		"code":  code,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode onPlayStatus: %w", err)
+	}
 	defer msg.Buffer().Release()
 	return conn.WriteMessage(msg)
 }
@@ -0,0 +1,397 @@
+package rtmp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ssungk/ertmp/pkg/rtmp/transport"
+)
+
+// DefaultHandshakeTimeout is the default handshake timeout of a Server
+const DefaultHandshakeTimeout = 10 * time.Second
+
+// Server accepts RTMP connections and drives a Handler
+type Server struct {
+	// 타임아웃 (0이면 없음, Serve 전에 설정)
+	HandshakeTimeout time.Duration // 핸드셰이크 완료까지 (기본값 DefaultHandshakeTimeout)
+	ReadTimeout      time.Duration // 데이터 수신 없이 기다리는 최대 시간 (플레이어는 장시간 보내지 않을 수 있음)
+	WriteTimeout     time.Duration // 전송이 진행되지 않는 최대 시간
+
+	handler Handler
+
+	mu        sync.Mutex
+	listeners map[net.Listener]struct{}
+	conns     map[*Conn]struct{}
+	closed    bool
+}
+
+// NewServer creates a server that dispatches connection events to handler
+func NewServer(handler Handler) *Server {
+	if handler == nil {
+		handler = BaseHandler{}
+	}
+	return &Server{
+		HandshakeTimeout: DefaultHandshakeTimeout,
+		handler:          handler,
+		listeners:        make(map[net.Listener]struct{}),
+		conns:            make(map[*Conn]struct{}),
+	}
+}
+
+// ListenAndServe listens on the TCP address and serves RTMP connections
+func (s *Server) ListenAndServe(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(listener)
+}
+
+// ListenAndServeTLS listens on the TCP address and serves RTMPS connections
+func (s *Server) ListenAndServeTLS(addr, certFile, keyFile string) error {
+	config, err := LoadTLSConfig(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	listener, err := ListenTLS(addr, config)
+	if err != nil {
+		return err
+	}
+	return s.Serve(listener)
+}
+
+// Serve accepts connections on listener and serves each in its own goroutine.
+// Always returns a non-nil error; ErrServerClosed after Close.
+func (s *Server) Serve(listener net.Listener) error {
+	if !s.trackListener(listener, true) {
+		listener.Close()
+		return ErrServerClosed
+	}
+	defer s.trackListener(listener, false)
+
+	var delay time.Duration
+	for {
+		netConn, err := listener.Accept()
+		if err != nil {
+			if s.isClosed() {
+				return ErrServerClosed
+			}
+			// 일시적 에러는 backoff 후 재시도 (net/http와 동일)
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				delay = min(max(2*delay, 5*time.Millisecond), time.Second)
+				time.Sleep(delay)
+				continue
+			}
+			return err
+		}
+		delay = 0
+
+		go s.ServeConn(netConn)
+	}
+}
+
+// ServeConn performs the handshake on netConn and serves it until it ends.
+// Blocks until the connection is closed; netConn is always closed on return.
+func (s *Server) ServeConn(netConn net.Conn) error {
+	ctx := context.Background()
+	if s.HandshakeTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.HandshakeTimeout)
+		defer cancel()
+	}
+
+	conn, err := AcceptConnContext(ctx, netConn)
+	if err != nil {
+		netConn.Close()
+		return err
+	}
+	conn.SetReadTimeout(s.ReadTimeout)
+	conn.SetWriteTimeout(s.WriteTimeout)
+	if !s.trackConn(conn, true) {
+		conn.Close()
+		return ErrServerClosed
+	}
+	defer s.trackConn(conn, false)
+
+	// 대역폭 확인 RPC (FMS 호환): 빈 _result로 응답
+	checkBandwidth := func(conn *Conn, cmd *Command) ([]interface{}, error) {
+		return nil, nil
+	}
+	conn.HandleCall("checkBandwidth", checkBandwidth)
+	conn.HandleCall("_checkbw", checkBandwidth)
+
+	err = s.serveConn(conn)
+
+	// 남은 스트림 정리 후 종료 알림
+	for _, stream := range conn.Streams() {
+		s.handler.OnDeleteStream(conn, stream)
+		conn.DeleteStream(stream.ID())
+	}
+	conn.Close()
+
+	if errors.Is(err, io.EOF) || s.isClosed() {
+		err = nil
+	}
+	s.handler.OnClose(conn, err)
+	return err
+}
+
+// Close closes all listeners and connections
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+
+	var err error
+	for listener := range s.listeners {
+		if cerr := listener.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	return err
+}
+
+// serveConn runs the message loop of a connection
+func (s *Server) serveConn(conn *Conn) error {
+	for {
+		msg, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+
+		err = s.handleMessage(conn, msg)
+		msg.Buffer().Release()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// handleMessage routes a message to the handler
+func (s *Server) handleMessage(conn *Conn, msg transport.Message) error {
+	switch msg.Type() {
+	case transport.MsgTypeAMF0Command, transport.MsgTypeAMF3Command:
+		return s.handleCommand(conn, msg)
+
+	case transport.MsgTypeAudio, transport.MsgTypeVideo:
+		if stream := publishingStream(conn, msg.StreamID()); stream != nil {
+			s.handler.OnMedia(conn, stream, msg)
+		}
+
+	case transport.MsgTypeAMF0Data:
+		if stream := publishingStream(conn, msg.StreamID()); stream != nil {
+			s.handler.OnMetadata(conn, stream, msg)
+		}
+
+	case transport.MsgTypeAMF3Data:
+		// 핸들러에는 AMF0 data로 변환하여 전달
+		if stream := publishingStream(conn, msg.StreamID()); stream != nil {
+			data, err := conn.amf0Data(msg)
+			if err != nil {
+				return nil // 잘못된 데이터는 무시
+			}
+			s.handler.OnMetadata(conn, stream, data)
+			data.Buffer().Release()
+		}
+	}
+
+	return nil
+}
+
+// handleCommand handles AMF0 and AMF3 command messages
+func (s *Server) handleCommand(conn *Conn, msg transport.Message) error {
+	cmd, err := conn.decodeCommand(msg)
+	if err != nil {
+		return nil // 잘못된 커맨드는 무시
+	}
+
+	if cmd.Name == "connect" {
+		return s.handleConnect(conn, cmd)
+	}
+	if conn.connectInfo == nil {
+		return fmt.Errorf("%s before connect", cmd.Name)
+	}
+
+	switch cmd.Name {
+	case "createStream":
+		return s.handleCreateStream(conn, cmd)
+
+	case "publish":
+		return s.handlePublish(conn, msg.StreamID(), cmd)
+
+	case "play":
+		return s.handlePlay(conn, msg.StreamID(), cmd)
+
+	case "deleteStream":
+		if len(cmd.Arguments) > 0 {
+			if id, ok := cmd.Arguments[0].(float64); ok {
+				s.deleteStream(conn, uint32(id))
+			}
+		}
+
+	case "closeStream":
+		s.deleteStream(conn, msg.StreamID())
+	}
+
+	// releaseStream, FCPublish, FCUnpublish 등은 응답 없이 무시
+	return nil
+}
+
+// handleConnect handles the connect command
+func (s *Server) handleConnect(conn *Conn, cmd *Command) error {
+	if conn.connectInfo != nil {
+		return nil // 중복 connect 무시
+	}
+
+	connectCmd, err := ParseConnect(cmd)
+	if err != nil {
+		return err
+	}
+
+	if err := s.handler.OnConnect(conn, connectCmd); err != nil {
+		info := errorInfo(err, "NetConnection.Connect.Rejected")
+		if serr := SendCommand(conn, 0, "_error", cmd.TransactionID, nil, info); serr != nil {
+			return serr
+		}
+		return fmt.Errorf("connect rejected: %w", err)
+	}
+
+	if err := acceptConnect(conn, cmd.TransactionID, connectCmd); err != nil {
+		return err
+	}
+
+	// 대역폭 확인 완료 알림 (FMS 호환)
+	return conn.Notify("onBWDone", nil)
+}
+
+// handleCreateStream handles the createStream command
+func (s *Server) handleCreateStream(conn *Conn, cmd *Command) error {
+	stream := conn.createStream()
+
+	if err := s.handler.OnCreateStream(conn, stream); err != nil {
+		conn.DeleteStream(stream.ID())
+		info := errorInfo(err, "NetConnection.Call.Failed")
+		return SendCommand(conn, 0, "_error", cmd.TransactionID, nil, info)
+	}
+
+	return SendCreateStreamResponse(conn, cmd.TransactionID, float64(stream.ID()))
+}
+
+// handlePublish handles the publish command
+func (s *Server) handlePublish(conn *Conn, streamID uint32, cmd *Command) error {
+	const rejectCode = "NetStream.Publish.BadName"
+
+	publishCmd, err := ParsePublish(cmd)
+	if err != nil {
+		return err
+	}
+
+	stream := conn.GetStream(streamID)
+	if stream == nil || stream.Mode() != StreamModeNone {
+		return sendStatusError(conn, streamID, fmt.Errorf("stream %d is not available", streamID), rejectCode)
+	}
+
+	if err := s.handler.OnPublish(conn, stream, publishCmd); err != nil {
+		return sendStatusError(conn, streamID, err, rejectCode)
+	}
+
+	return acceptPublish(conn, stream, publishCmd)
+}
+
+// handlePlay handles the play command
+func (s *Server) handlePlay(conn *Conn, streamID uint32, cmd *Command) error {
+	const rejectCode = "NetStream.Play.StreamNotFound"
+
+	playCmd, err := ParsePlay(cmd)
+	if err != nil {
+		return err
+	}
+
+	stream := conn.GetStream(streamID)
+	if stream == nil || stream.Mode() != StreamModeNone {
+		return sendStatusError(conn, streamID, fmt.Errorf("stream %d is not available", streamID), rejectCode)
+	}
+
+	if err := s.handler.OnPlay(conn, stream, playCmd); err != nil {
+		return sendStatusError(conn, streamID, err, rejectCode)
+	}
+
+	if err := acceptPlay(conn, stream, playCmd); err != nil {
+		return err
+	}
+	s.handler.OnPlayStart(conn, stream)
+	return nil
+}
+
+// deleteStream notifies the handler and removes the stream
+func (s *Server) deleteStream(conn *Conn, streamID uint32) {
+	stream := conn.GetStream(streamID)
+	if stream == nil {
+		return
+	}
+	s.handler.OnDeleteStream(conn, stream)
+	conn.DeleteStream(streamID)
+}
+
+// trackListener adds or removes a listener; returns false if the server is closed
+func (s *Server) trackListener(listener net.Listener, add bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !add {
+		delete(s.listeners, listener)
+		return true
+	}
+	if s.closed {
+		return false
+	}
+	s.listeners[listener] = struct{}{}
+	return true
+}
+
+// trackConn adds or removes a connection; returns false if the server is closed
+func (s *Server) trackConn(conn *Conn, add bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !add {
+		delete(s.conns, conn)
+		return true
+	}
+	if s.closed {
+		return false
+	}
+	s.conns[conn] = struct{}{}
+	return true
+}
+
+// isClosed reports whether Close has been called
+func (s *Server) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+// publishingStream returns the stream if it is in publish mode
+func publishingStream(conn *Conn, streamID uint32) *Stream {
+	stream := conn.GetStream(streamID)
+	if stream == nil || stream.Mode() != StreamModePublish {
+		return nil
+	}
+	return stream
+}
+
+// sendStatusError sends an error-level onStatus built from err
+func sendStatusError(conn *Conn, streamID uint32, err error, defaultCode string) error {
+	return SendCommand(conn, streamID, "onStatus", 0, nil, errorInfo(err, defaultCode))
+}
@@ -0,0 +1,401 @@
+package rtmp
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ssungk/ertmp/pkg/rtmp/buf"
+	"github.com/ssungk/ertmp/pkg/rtmp/transport"
+)
+
+// testHandler records server events and delegates accept decisions to optional funcs
+type testHandler struct {
+	BaseHandler
+	onConnect func(cmd *ConnectCommand) error
+	onPublish func(cmd *PublishCommand) error
+	onPlay    func(conn *Conn, stream *Stream)
+	media     chan []byte
+	deleted   chan string
+	closed    chan error
+}
+
+func newTestHandler() *testHandler {
+	return &testHandler{
+		media:   make(chan []byte, 8),
+		deleted: make(chan string, 8),
+		closed:  make(chan error, 8),
+	}
+}
+
+func (h *testHandler) OnConnect(conn *Conn, cmd *ConnectCommand) error {
+	if h.onConnect != nil {
+		return h.onConnect(cmd)
+	}
+	return nil
+}
+
+func (h *testHandler) OnPublish(conn *Conn, stream *Stream, cmd *PublishCommand) error {
+	if h.onPublish != nil {
+		return h.onPublish(cmd)
+	}
+	return nil
+}
+
+func (h *testHandler) OnPlayStart(conn *Conn, stream *Stream) {
+	if h.onPlay != nil {
+		h.onPlay(conn, stream)
+	}
+}
+
+func (h *testHandler) OnMedia(conn *Conn, stream *Stream, msg transport.Message) {
+	h.media <- bytes.Clone(msg.Data())
+}
+
+func (h *testHandler) OnDeleteStream(conn *Conn, stream *Stream) {
+	h.deleted <- stream.Key()
+}
+
+func (h *testHandler) OnClose(conn *Conn, err error) {
+	h.closed <- err
+}
+
+func TestServer_Publish(t *testing.T) {
+	h := newTestHandler()
+	addr := startHandlerServer(t, h)
+
+	client, err := Dial("rtmp://"+addr+"/live/test", DefaultClientConfig())
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer client.Close()
+
+	if info := client.Conn().HandshakeMode(); info != transport.HandshakeDigest {
+		t.Errorf("expected digest handshake, got %s", info)
+	}
+
+	stream, err := client.Publish("")
+	if err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+
+	video := []byte{0x17, 0x01, 0x00, 0x00, 0x00, 0xAA}
+	if err := stream.WriteVideo(video, 40); err != nil {
+		t.Fatalf("WriteVideo failed: %v", err)
+	}
+
+	select {
+	case got := <-h.media:
+		if !bytes.Equal(got, video) {
+			t.Errorf("expected media %x, got %x", video, got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for OnMedia")
+	}
+
+	if err := stream.Close(); err != nil {
+		t.Fatalf("stream Close failed: %v", err)
+	}
+	select {
+	case key := <-h.deleted:
+		if key != "test" {
+			t.Errorf("expected deleted stream key test, got %q", key)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for OnDeleteStream")
+	}
+}
+
+func TestServer_PlayStart(t *testing.T) {
+	h := newTestHandler()
+	video := []byte{0x17, 0x00, 0x00, 0x00, 0x00, 0x01}
+	h.onPlay = func(conn *Conn, stream *Stream) {
+		// Play.Start 이후 호출되므로 바로 미디어 전송 가능
+		if err := SendVideo(conn, stream.ID(), video, 0); err != nil {
+			t.Errorf("SendVideo failed: %v", err)
+		}
+	}
+	addr := startHandlerServer(t, h)
+
+	client, err := Dial("rtmp://"+addr+"/live/test", DefaultClientConfig())
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer client.Close()
+
+	stream, err := client.Play("")
+	if err != nil {
+		t.Fatalf("Play failed: %v", err)
+	}
+
+	msg, err := stream.ReadMessage()
+	if err != nil {
+		t.Fatalf("ReadMessage failed: %v", err)
+	}
+	defer msg.Buffer().Release()
+	if msg.Type() != transport.MsgTypeVideo || !bytes.Equal(msg.Data(), video) {
+		t.Errorf("expected video %x, got type %d %x", video, msg.Type(), msg.Data())
+	}
+}
+
+func TestServer_RejectConnect(t *testing.T) {
+	h := newTestHandler()
+	h.onConnect = func(cmd *ConnectCommand) error {
+		if cmd.App != "live" {
+			return errors.New("unknown app")
+		}
+		return nil
+	}
+	addr := startHandlerServer(t, h)
+
+	_, err := Dial("rtmp://"+addr+"/other/test", DefaultClientConfig())
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("expected StatusError, got %v", err)
+	}
+	if statusErr.Code != "NetConnection.Connect.Rejected" {
+		t.Errorf("expected NetConnection.Connect.Rejected, got %s", statusErr.Code)
+	}
+	if statusErr.Description != "unknown app" {
+		t.Errorf("expected description 'unknown app', got %q", statusErr.Description)
+	}
+
+	select {
+	case err := <-h.closed:
+		if err == nil {
+			t.Error("expected OnClose error for rejected connect")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for OnClose")
+	}
+}
+
+func TestServer_RejectPublish(t *testing.T) {
+	h := newTestHandler()
+	h.onPublish = func(cmd *PublishCommand) error {
+		if cmd.StreamKey == "taken" {
+			return &StatusError{Code: "NetStream.Publish.BadName", Description: "already publishing"}
+		}
+		return errors.New("denied")
+	}
+	addr := startHandlerServer(t, h)
+
+	client, err := Dial("rtmp://"+addr+"/live", DefaultClientConfig())
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer client.Close()
+
+	tests := []struct {
+		key         string
+		code        string
+		description string
+	}{
+		{"taken", "NetStream.Publish.BadName", "already publishing"},
+		{"other", "NetStream.Publish.BadName", "denied"},
+	}
+	for _, tt := range tests {
+		_, err := client.Publish(tt.key)
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) {
+			t.Fatalf("%s: expected StatusError, got %v", tt.key, err)
+		}
+		if statusErr.Code != tt.code || statusErr.Description != tt.description {
+			t.Errorf("%s: expected %s (%s), got %s (%s)", tt.key, tt.code, tt.description, statusErr.Code, statusErr.Description)
+		}
+	}
+
+	// 거부 후에도 연결은 유지됨
+	h.onPublish = nil
+	if _, err := client.Publish("ok"); err != nil {
+		t.Fatalf("Publish after rejection failed: %v", err)
+	}
+}
+
+func TestServer_Close(t *testing.T) {
+	h := newTestHandler()
+	srv := NewServer(h)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	served := make(chan error, 1)
+	go func() { served <- srv.Serve(listener) }()
+
+	client, err := Dial("rtmp://"+listener.Addr().String()+"/live/test", DefaultClientConfig())
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer client.Close()
+	if _, err := client.Publish(""); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+
+	if err := srv.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	select {
+	case err := <-served:
+		if !errors.Is(err, ErrServerClosed) {
+			t.Errorf("expected ErrServerClosed, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for Serve to return")
+	}
+
+	// 연결 종료 시 남은 스트림 정리 후 OnClose 호출
+	select {
+	case key := <-h.deleted:
+		if key != "test" {
+			t.Errorf("expected deleted stream key test, got %q", key)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for OnDeleteStream")
+	}
+	select {
+	case err := <-h.closed:
+		if err != nil {
+			t.Errorf("expected nil OnClose error after Server.Close, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for OnClose")
+	}
+}
+
+func TestServer_HandshakeTimeout(t *testing.T) {
+	srv := NewServer(newTestHandler())
+	srv.HandshakeTimeout = 50 * time.Millisecond
+
+	server, client := net.Pipe()
+	defer client.Close()
+
+	// 소켓만 열고 아무것도 보내지 않는 클라이언트
+	done := make(chan error, 1)
+	go func() { done <- srv.ServeConn(server) }()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, transport.ErrHandshakeTimeout) {
+			t.Errorf("expected ErrHandshakeTimeout, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ServeConn did not time out")
+	}
+}
+
+func TestServer_ReadTimeout(t *testing.T) {
+	h := newTestHandler()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	srv := NewServer(h)
+	srv.ReadTimeout = 100 * time.Millisecond
+	go srv.Serve(listener)
+	defer srv.Close()
+
+	client, err := Dial("rtmp://"+listener.Addr().String()+"/live/test", DefaultClientConfig())
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer client.Close()
+
+	// connect 후 아무것도 보내지 않으면 연결 종료
+	select {
+	case err := <-h.closed:
+		if !errors.Is(err, transport.ErrReadTimeout) {
+			t.Errorf("expected ErrReadTimeout, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for OnClose")
+	}
+}
+
+// startHandlerServer starts a Server on a loopback listener and returns its address
+func startHandlerServer(t *testing.T, handler Handler) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+
+	srv := NewServer(handler)
+	go srv.Serve(listener)
+	t.Cleanup(func() { srv.Close() })
+
+	return listener.Addr().String()
+}
+
+func TestServer_AMF3Commands(t *testing.T) {
+	addr := startHandlerServer(t, newTestHandler())
+
+	netConn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	conn, err := DialConn(netConn)
+	if err != nil {
+		t.Fatalf("handshake failed: %v", err)
+	}
+	defer conn.Close()
+
+	send := func(name string, txID float64, obj map[string]interface{}) {
+		t.Helper()
+		data, err := EncodeCommandAMF3(nil, name, txID, obj)
+		if err != nil {
+			t.Fatalf("encode %s: %v", name, err)
+		}
+		header := transport.NewMessageHeader(0, 0, transport.MsgTypeAMF3Command)
+		if err := conn.WriteMessage(transport.NewMessage(header, buf.New(data))); err != nil {
+			t.Fatalf("send %s: %v", name, err)
+		}
+	}
+	// receive returns the next _result/_error and its message type (onBWDone 등은 건너뜀)
+	receive := func() (*Command, uint8) {
+		t.Helper()
+		for {
+			msg, err := conn.ReadMessage()
+			if err != nil {
+				t.Fatalf("ReadMessage: %v", err)
+			}
+			if !isCommandMessage(msg.Type()) {
+				msg.Buffer().Release()
+				continue
+			}
+			cmd, err := conn.decodeCommand(msg)
+			msg.Buffer().Release()
+			if err != nil {
+				t.Fatalf("decode type %d: %v", msg.Type(), err)
+			}
+			if cmd.Name == "_result" || cmd.Name == "_error" {
+				return cmd, msg.Type()
+			}
+		}
+	}
+
+	send("connect", 1, map[string]interface{}{"app": "live", "objectEncoding": 3.0})
+
+	// connect 응답은 AMF0, info에 협상된 objectEncoding
+	cmd, msgType := receive()
+	if cmd.Name != "_result" || msgType != transport.MsgTypeAMF0Command {
+		t.Fatalf("connect: got %s type %d", cmd.Name, msgType)
+	}
+	if info := statusInfo(cmd); info["objectEncoding"] != ObjectEncodingAMF3 {
+		t.Errorf("objectEncoding = %v, want 3", info["objectEncoding"])
+	}
+
+	// 이후 응답은 AMF3 커맨드
+	send("createStream", 2, nil)
+	cmd, msgType = receive()
+	if cmd.Name != "_result" || msgType != transport.MsgTypeAMF3Command {
+		t.Fatalf("createStream: got %s type %d", cmd.Name, msgType)
+	}
+	if len(cmd.Arguments) != 1 || cmd.Arguments[0] != 1.0 {
+		t.Errorf("createStream arguments = %v", cmd.Arguments)
+	}
+}
@@ -11,10 +11,14 @@ const (
 
 // Stream represents an RTMP stream
 type Stream struct {
+	conn     *Conn
 	id       uint32
 	key      string
 	mode     StreamMode
 	metadata map[string]interface{}
+
+	publishType string // "live", "record", "append" (publish 모드에서만)
+	recorded    bool   // play 모드에서 녹화 파일 재생 여부
 }
 
 // NewStream creates a new stream
@@ -30,6 +34,11 @@ func (s *Stream) ID() uint32 {
 	return s.id
 }
 
+// Conn returns the connection that owns the stream (nil if created with NewStream)
+func (s *Stream) Conn() *Conn {
+	return s.conn
+}
+
 // Key returns the stream key
 func (s *Stream) Key() string {
 	return s.key
@@ -50,6 +59,27 @@ func (s *Stream) SetMode(mode StreamMode) {
 	s.mode = mode
 }
 
+// PublishType returns the publish type ("live", "record", "append") of a publishing stream
+func (s *Stream) PublishType() string {
+	return s.publishType
+}
+
+// SetPublishType sets the publish type
+func (s *Stream) SetPublishType(publishType string) {
+	s.publishType = publishType
+}
+
+// Recorded reports whether a playing stream serves a recorded file
+func (s *Stream) Recorded() bool {
+	return s.recorded
+}
+
+// SetRecorded marks a playing stream as recorded (VOD).
+// Handler.OnPlay에서 설정하면 Play.Start 전에 StreamIsRecorded 이벤트가 전송됨
+func (s *Stream) SetRecorded(recorded bool) {
+	s.recorded = recorded
+}
+
 // Metadata returns the stream metadata
 func (s *Stream) Metadata() map[string]interface{} {
 	return s.metadata
@@ -0,0 +1,29 @@
+package rtmp
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// LoadTLSConfig loads a certificate/key pair into a server TLS config
+func LoadTLSConfig(certFile, keyFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load TLS key pair: %w", err)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// ListenTLS creates an RTMPS listener
+// Accept로 얻은 연결은 AcceptConn에 그대로 전달 가능 (TLS 핸드셰이크는 첫 읽기 시 수행)
+func ListenTLS(addr string, config *tls.Config) (net.Listener, error) {
+	if config == nil || (len(config.Certificates) == 0 && config.GetCertificate == nil && config.GetConfigForClient == nil) {
+		return nil, errors.New("TLS config requires a certificate")
+	}
+	return tls.Listen("tcp", addr, config)
+}
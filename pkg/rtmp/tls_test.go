@@ -0,0 +1,171 @@
+package rtmp
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ssungk/ertmp/pkg/rtmp/transport"
+)
+
+func TestRTMPS_Loopback(t *testing.T) {
+	cert, pool := newTestCertificate(t)
+
+	serverConfig := &tls.Config{Certificates: []tls.Certificate{cert}}
+	serverNames := make(chan string, 1)
+	serverConfig.GetConfigForClient = func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+		serverNames <- hello.ServerName
+		return nil, nil
+	}
+
+	listener, err := ListenTLS("127.0.0.1:0", serverConfig)
+	if err != nil {
+		t.Fatalf("ListenTLS failed: %v", err)
+	}
+	defer listener.Close()
+
+	type result struct {
+		msg transport.Message
+		err error
+	}
+	received := make(chan result, 1)
+	go func() {
+		netConn, err := listener.Accept()
+		if err != nil {
+			received <- result{err: err}
+			return
+		}
+		conn, err := AcceptConn(netConn)
+		if err != nil {
+			received <- result{err: err}
+			return
+		}
+		defer conn.Close()
+
+		msg, err := conn.ReadMessage()
+		received <- result{msg: msg, err: err}
+	}()
+
+	port := listener.Addr().(*net.TCPAddr).Port
+	url := fmt.Sprintf("rtmps://localhost:%d/live", port)
+	conn, err := DialURL(url, &tls.Config{RootCAs: pool})
+	if err != nil {
+		t.Fatalf("DialURL failed: %v", err)
+	}
+	defer conn.Close()
+
+	if conn.HandshakeMode() != transport.HandshakeDigest {
+		t.Errorf("expected digest handshake, got %v", conn.HandshakeMode())
+	}
+
+	payload := []byte{0x17, 0x01, 0x00, 0x00, 0x00, 0xAA, 0xBB}
+	if err := SendVideo(conn, 1, payload, 40); err != nil {
+		t.Fatalf("SendVideo failed: %v", err)
+	}
+
+	res := <-received
+	if res.err != nil {
+		t.Fatalf("server read failed: %v", res.err)
+	}
+	defer res.msg.Buffer().Release()
+
+	if res.msg.Type() != transport.MsgTypeVideo {
+		t.Errorf("expected video message, got type %d", res.msg.Type())
+	}
+	if res.msg.Timestamp() != 40 {
+		t.Errorf("expected timestamp 40, got %d", res.msg.Timestamp())
+	}
+	if !bytes.Equal(res.msg.Data(), payload) {
+		t.Errorf("payload mismatch: got %x", res.msg.Data())
+	}
+
+	if name := <-serverNames; name != "localhost" {
+		t.Errorf("expected SNI 'localhost', got %q", name)
+	}
+}
+
+func TestRTMPS_UntrustedCertificate(t *testing.T) {
+	cert, _ := newTestCertificate(t)
+
+	listener, err := ListenTLS("127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Fatalf("ListenTLS failed: %v", err)
+	}
+	defer listener.Close()
+
+	go func() {
+		netConn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		_, _ = AcceptConn(netConn)
+		netConn.Close()
+	}()
+
+	port := listener.Addr().(*net.TCPAddr).Port
+	url := fmt.Sprintf("rtmps://localhost:%d/live", port)
+	if _, err := DialURL(url, nil); err == nil {
+		t.Fatal("expected certificate verification error")
+	}
+}
+
+func TestListenTLS_NoCertificate(t *testing.T) {
+	if _, err := ListenTLS("127.0.0.1:0", nil); err == nil {
+		t.Fatal("expected error for nil config")
+	}
+	if _, err := ListenTLS("127.0.0.1:0", &tls.Config{}); err == nil {
+		t.Fatal("expected error for config without certificate")
+	}
+}
+
+func TestDialURL_UnsupportedScheme(t *testing.T) {
+	if _, err := DialURL("http://localhost/live", nil); err == nil {
+		t.Fatal("expected error for unsupported scheme")
+	}
+}
+
+// newTestCertificate creates a self-signed certificate for localhost
+func newTestCertificate(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, pool
+}
@@ -0,0 +1,221 @@
+package transport
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ssungk/ertmp/pkg/rtmp/buf"
+)
+
+// Aggregate message layout: 하위 메시지마다 FLV 태그와 같은 11바이트 헤더
+// (type 1, size 3, timestamp 3 + extended 1, stream ID 3), 본문, back pointer 4바이트 (11 + size)
+const (
+	aggregateHeaderSize  = 11
+	aggregatePointerSize = 4
+
+	// DefaultAggregateSize is the default maximum body size of an aggregate built by Aggregator
+	DefaultAggregateSize = 16 * 1024
+)
+
+// SplitAggregate splits an aggregate message into its sub-messages.
+// 하위 메시지 본문은 aggregate 버퍼의 zero-copy view이며 각각 Release해야 함 (msg는 호출자가 계속 소유).
+// timestamp는 aggregate timestamp 기준으로 재계산 (첫 하위 메시지 = aggregate timestamp),
+// stream ID는 aggregate의 값을 사용
+func SplitAggregate(msg Message) ([]Message, error) {
+	if msg.Type() != MsgTypeAggregate {
+		return nil, fmt.Errorf("not an aggregate message: type %d", msg.Type())
+	}
+
+	data := msg.Data()
+	var (
+		msgs    []Message
+		firstTS uint32
+	)
+	for offset := 0; offset < len(data); {
+		if len(data)-offset < aggregateHeaderSize {
+			releaseMessages(msgs)
+			return nil, fmt.Errorf("%w: truncated sub-message header at %d", ErrInvalidAggregate, offset)
+		}
+		header := data[offset : offset+aggregateHeaderSize]
+		msgType := header[0]
+		size := int(header[1])<<16 | int(header[2])<<8 | int(header[3])
+		ts := uint32(header[7])<<24 | uint32(header[4])<<16 | uint32(header[5])<<8 | uint32(header[6])
+
+		start := offset + aggregateHeaderSize
+		end := start + size
+		if end > len(data) {
+			releaseMessages(msgs)
+			return nil, fmt.Errorf("%w: sub-message size %d exceeds aggregate at %d", ErrInvalidAggregate, size, offset)
+		}
+		if !isAggregatable(msgType) {
+			releaseMessages(msgs)
+			return nil, fmt.Errorf("%w: sub-message type %d", ErrInvalidAggregate, msgType)
+		}
+
+		if len(msgs) == 0 {
+			firstTS = ts
+		}
+		subHeader := NewMessageHeader(msg.StreamID(), msg.Timestamp()+(ts-firstTS), msgType)
+		msgs = append(msgs, NewMessage(subHeader, msg.Buffer().Slice(start, end)))
+
+		// back pointer는 마지막 하위 메시지에서 생략된 경우도 허용
+		offset = min(end+aggregatePointerSize, len(data))
+	}
+	return msgs, nil
+}
+
+// NewAggregateMessage packs messages of one stream into an aggregate message.
+// 하위 메시지는 원래 timestamp를 그대로 기록하고, aggregate timestamp는 첫 메시지의 값.
+// msgs의 버퍼는 호출자가 계속 소유
+func NewAggregateMessage(msgs []Message) (Message, error) {
+	if len(msgs) == 0 {
+		return Message{}, fmt.Errorf("%w: no messages", ErrInvalidAggregate)
+	}
+
+	size := 0
+	for i := range msgs {
+		if !isAggregatable(msgs[i].Type()) {
+			return Message{}, fmt.Errorf("%w: message type %d", ErrInvalidAggregate, msgs[i].Type())
+		}
+		if msgs[i].StreamID() != msgs[0].StreamID() {
+			return Message{}, fmt.Errorf("%w: mixed stream IDs", ErrInvalidAggregate)
+		}
+		if len(msgs[i].Data()) > 0xFFFFFF {
+			return Message{}, fmt.Errorf("%w: message too large", ErrInvalidAggregate)
+		}
+		size += aggregateSize(msgs[i])
+	}
+
+	buffer := buf.NewFromPool(size)
+	data := buffer.Data()
+	offset := 0
+	for i := range msgs {
+		offset += putAggregateEntry(data[offset:], msgs[i])
+	}
+
+	header := NewMessageHeader(msgs[0].StreamID(), msgs[0].Timestamp(), MsgTypeAggregate)
+	return NewMessage(header, buffer), nil
+}
+
+// putAggregateEntry writes msg as an aggregate entry and returns its size
+func putAggregateEntry(data []byte, msg Message) int {
+	size := len(msg.Data())
+	ts := msg.Timestamp()
+
+	data[0] = msg.Type()
+	data[1] = byte(size >> 16)
+	data[2] = byte(size >> 8)
+	data[3] = byte(size)
+	data[4] = byte(ts >> 16)
+	data[5] = byte(ts >> 8)
+	data[6] = byte(ts)
+	data[7] = byte(ts >> 24)
+	data[8], data[9], data[10] = 0, 0, 0 // stream ID (항상 0)
+	copy(data[aggregateHeaderSize:], msg.Data())
+
+	end := aggregateHeaderSize + size
+	binary.BigEndian.PutUint32(data[end:], uint32(end))
+	return end + aggregatePointerSize
+}
+
+// aggregateSize returns the encoded size of msg inside an aggregate
+func aggregateSize(msg Message) int {
+	return aggregateHeaderSize + len(msg.Data()) + aggregatePointerSize
+}
+
+// isAggregatable reports whether a message type can be carried in an aggregate
+func isAggregatable(msgType uint8) bool {
+	switch msgType {
+	case MsgTypeAudio, MsgTypeVideo, MsgTypeAMF0Data, MsgTypeAMF3Data:
+		return true
+	}
+	return false
+}
+
+// releaseMessages releases the buffers of msgs
+func releaseMessages(msgs []Message) {
+	for i := range msgs {
+		msgs[i].Buffer().Release()
+	}
+}
+
+// Aggregator packs consecutive small media messages of a stream into aggregate messages.
+// 한 번 만든 aggregate를 여러 수신자가 공유하면 수신자별 청크 헤더와 쓰기 횟수가 줄어듦.
+// 메시지를 모으는 만큼 지연이 생기므로 MaxDuration으로 한도를 둠. 고루틴 안전하지 않음
+type Aggregator struct {
+	MaxSize     int    // aggregate 본문 최대 크기 (0이면 DefaultAggregateSize)
+	MaxDuration uint32 // 첫 메시지부터의 최대 timestamp 간격 (ms, 0이면 제한 없음)
+
+	pending []Message
+	size    int
+}
+
+// NewAggregator creates an aggregator with the given limits
+func NewAggregator(maxSize int, maxDuration uint32) *Aggregator {
+	return &Aggregator{MaxSize: maxSize, MaxDuration: maxDuration}
+}
+
+// Add takes ownership of msg and returns the messages ready to send, in order.
+// msg가 현재 묶음에 들어가지 않으면 묶음을 먼저 내보내며,
+// aggregate에 넣을 수 없는 메시지(커맨드 등)나 한도보다 큰 메시지는 그대로 반환.
+// 반환된 메시지의 버퍼는 호출자가 해제
+func (a *Aggregator) Add(msg Message) []Message {
+	maxSize := a.MaxSize
+	if maxSize <= 0 {
+		maxSize = DefaultAggregateSize
+	}
+
+	if !isAggregatable(msg.Type()) || aggregateSize(msg) > maxSize {
+		return append(a.Flush(), msg)
+	}
+
+	var ready []Message
+	if len(a.pending) > 0 && !a.fits(msg, maxSize) {
+		ready = a.Flush()
+	}
+	a.pending = append(a.pending, msg)
+	a.size += aggregateSize(msg)
+	return ready
+}
+
+// fits reports whether msg can join the pending messages
+func (a *Aggregator) fits(msg Message, maxSize int) bool {
+	first := a.pending[0]
+	if msg.StreamID() != first.StreamID() || a.size+aggregateSize(msg) > maxSize {
+		return false
+	}
+	// timestamp가 역행하면 재계산된 timestamp가 어긋나므로 새 묶음 시작
+	last := a.pending[len(a.pending)-1]
+	if msg.Timestamp() < last.Timestamp() {
+		return false
+	}
+	return a.MaxDuration == 0 || msg.Timestamp()-first.Timestamp() <= a.MaxDuration
+}
+
+// Flush returns the pending messages as one message (nil if none).
+// 메시지가 하나뿐이면 aggregate 없이 그대로 반환
+func (a *Aggregator) Flush() []Message {
+	pending := a.pending
+	a.pending = nil
+	a.size = 0
+
+	switch len(pending) {
+	case 0:
+		return nil
+	case 1:
+		return pending
+	}
+
+	agg, err := NewAggregateMessage(pending)
+	if err != nil {
+		// Add에서 검증하므로 발생하지 않음: 개별 메시지로 전송
+		return pending
+	}
+	releaseMessages(pending)
+	return []Message{agg}
+}
+
+// Pending returns the number of messages waiting to be flushed
+func (a *Aggregator) Pending() int {
+	return len(a.pending)
+}
@@ -0,0 +1,317 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/ssungk/ertmp/pkg/rtmp/buf"
+)
+
+// aggregateEntry builds one aggregate sub-message (back pointer optional)
+func aggregateEntry(msgType uint8, ts uint32, data []byte, pointer bool) []byte {
+	entry := make([]byte, aggregateHeaderSize, aggregateHeaderSize+len(data)+aggregatePointerSize)
+	entry[0] = msgType
+	entry[1] = byte(len(data) >> 16)
+	entry[2] = byte(len(data) >> 8)
+	entry[3] = byte(len(data))
+	entry[4] = byte(ts >> 16)
+	entry[5] = byte(ts >> 8)
+	entry[6] = byte(ts)
+	entry[7] = byte(ts >> 24)
+	entry[10] = 1 // 무시되는 stream ID
+	entry = append(entry, data...)
+	if pointer {
+		entry = binary.BigEndian.AppendUint32(entry, uint32(aggregateHeaderSize+len(data)))
+	}
+	return entry
+}
+
+func newAggregate(streamID, ts uint32, body []byte) Message {
+	return NewMessage(NewMessageHeader(streamID, ts, MsgTypeAggregate), buf.New(body))
+}
+
+func TestSplitAggregate(t *testing.T) {
+	var body []byte
+	body = append(body, aggregateEntry(MsgTypeVideo, 5000, []byte{0x17, 0x01}, true)...)
+	body = append(body, aggregateEntry(MsgTypeAudio, 5020, []byte{0xAF, 0x01, 0x02}, true)...)
+	body = append(body, aggregateEntry(MsgTypeAMF0Data, 5040, []byte{0x02}, false)...) // 마지막 back pointer 생략
+
+	agg := newAggregate(3, 1000, body)
+	msgs, err := SplitAggregate(agg)
+	if err != nil {
+		t.Fatalf("SplitAggregate: %v", err)
+	}
+	defer releaseMessages(msgs)
+
+	want := []struct {
+		msgType uint8
+		ts      uint32
+		data    []byte
+	}{
+		{MsgTypeVideo, 1000, []byte{0x17, 0x01}},
+		{MsgTypeAudio, 1020, []byte{0xAF, 0x01, 0x02}},
+		{MsgTypeAMF0Data, 1040, []byte{0x02}},
+	}
+	if len(msgs) != len(want) {
+		t.Fatalf("got %d messages, want %d", len(msgs), len(want))
+	}
+	for i, w := range want {
+		if msgs[i].Type() != w.msgType || msgs[i].Timestamp() != w.ts || msgs[i].StreamID() != 3 {
+			t.Errorf("msg %d: type=%d ts=%d stream=%d, want type=%d ts=%d stream=3",
+				i, msgs[i].Type(), msgs[i].Timestamp(), msgs[i].StreamID(), w.msgType, w.ts)
+		}
+		if !bytes.Equal(msgs[i].Data(), w.data) {
+			t.Errorf("msg %d: data %x, want %x", i, msgs[i].Data(), w.data)
+		}
+		if msgs[i].Header.MessageLength != uint32(len(w.data)) {
+			t.Errorf("msg %d: length %d, want %d", i, msgs[i].Header.MessageLength, len(w.data))
+		}
+	}
+}
+
+func TestSplitAggregate_ExtendedTimestamp(t *testing.T) {
+	body := aggregateEntry(MsgTypeVideo, 0x01000010, []byte{0x27}, true)
+	body = append(body, aggregateEntry(MsgTypeVideo, 0x01000050, []byte{0x27}, true)...)
+
+	msgs, err := SplitAggregate(newAggregate(1, 0x01000000, body))
+	if err != nil {
+		t.Fatalf("SplitAggregate: %v", err)
+	}
+	defer releaseMessages(msgs)
+
+	if msgs[0].Timestamp() != 0x01000000 || msgs[1].Timestamp() != 0x01000040 {
+		t.Errorf("timestamps %#x %#x, want 0x1000000 0x1000040", msgs[0].Timestamp(), msgs[1].Timestamp())
+	}
+}
+
+func TestSplitAggregate_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		body []byte
+	}{
+		{"truncated header", []byte{MsgTypeVideo, 0, 0}},
+		{"size exceeds", aggregateEntry(MsgTypeVideo, 0, make([]byte, 10), true)[:15]},
+		{"command", aggregateEntry(MsgTypeAMF0Command, 0, []byte{0x02}, true)},
+		{"nested", aggregateEntry(MsgTypeAggregate, 0, []byte{0x02}, true)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := SplitAggregate(newAggregate(1, 0, tt.body))
+			if !errors.Is(err, ErrInvalidAggregate) {
+				t.Errorf("got %v, want ErrInvalidAggregate", err)
+			}
+		})
+	}
+}
+
+func TestSplitAggregate_SharesBuffer(t *testing.T) {
+	released := false
+	body := aggregateEntry(MsgTypeVideo, 0, []byte{0x17}, true)
+	agg := NewMessage(NewMessageHeader(1, 0, MsgTypeAggregate), buf.NewWithFinalizer(body, func([]byte) {
+		released = true
+	}))
+
+	msgs, err := SplitAggregate(agg)
+	if err != nil {
+		t.Fatalf("SplitAggregate: %v", err)
+	}
+	agg.Buffer().Release()
+	if released {
+		t.Fatal("aggregate buffer released while sub-messages are alive")
+	}
+	releaseMessages(msgs)
+	if !released {
+		t.Error("aggregate buffer not released")
+	}
+}
+
+func TestTransport_CloseReleasesSplit(t *testing.T) {
+	released := false
+	var body []byte
+	for i := range 3 {
+		body = append(body, aggregateEntry(MsgTypeVideo, uint32(i*40), []byte{0x17}, true)...)
+	}
+	agg := NewMessage(NewMessageHeader(1, 0, MsgTypeAggregate), buf.NewWithFinalizer(body, func([]byte) {
+		released = true
+	}))
+
+	transport := NewTransport(newTestConn())
+	msg, ok, err := transport.splitAggregate(agg)
+	if err != nil || !ok {
+		t.Fatalf("splitAggregate: ok=%v, err=%v", ok, err)
+	}
+	msg.Buffer().Release()
+	if released {
+		t.Fatal("aggregate buffer released while sub-messages are pending")
+	}
+
+	// 읽지 않은 하위 메시지 2개가 남은 채로 닫음
+	transport.Close()
+	if !released {
+		t.Error("aggregate buffer not released on Close")
+	}
+}
+
+func TestNewAggregateMessage_RoundTrip(t *testing.T) {
+	msgs := []Message{
+		NewMessage(NewMessageHeader(2, 100, MsgTypeVideo), buf.New([]byte{0x17, 0x01, 0x00})),
+		NewMessage(NewMessageHeader(2, 120, MsgTypeAudio), buf.New([]byte{0xAF, 0x01})),
+		NewMessage(NewMessageHeader(2, 140, MsgTypeVideo), buf.New([]byte{0x27, 0x01})),
+	}
+
+	agg, err := NewAggregateMessage(msgs)
+	if err != nil {
+		t.Fatalf("NewAggregateMessage: %v", err)
+	}
+	defer agg.Buffer().Release()
+	if agg.Type() != MsgTypeAggregate || agg.Timestamp() != 100 || agg.StreamID() != 2 {
+		t.Errorf("aggregate header type=%d ts=%d stream=%d", agg.Type(), agg.Timestamp(), agg.StreamID())
+	}
+
+	split, err := SplitAggregate(agg)
+	if err != nil {
+		t.Fatalf("SplitAggregate: %v", err)
+	}
+	defer releaseMessages(split)
+	if len(split) != len(msgs) {
+		t.Fatalf("got %d messages, want %d", len(split), len(msgs))
+	}
+	for i := range msgs {
+		if split[i].Type() != msgs[i].Type() || split[i].Timestamp() != msgs[i].Timestamp() ||
+			!bytes.Equal(split[i].Data(), msgs[i].Data()) {
+			t.Errorf("msg %d mismatch", i)
+		}
+	}
+}
+
+func TestNewAggregateMessage_Invalid(t *testing.T) {
+	cmd := NewMessage(NewMessageHeader(1, 0, MsgTypeAMF0Command), buf.New([]byte{0x02}))
+	if _, err := NewAggregateMessage([]Message{cmd}); !errors.Is(err, ErrInvalidAggregate) {
+		t.Errorf("command: got %v, want ErrInvalidAggregate", err)
+	}
+
+	mixed := []Message{
+		NewMessage(NewMessageHeader(1, 0, MsgTypeVideo), buf.New([]byte{0x17})),
+		NewMessage(NewMessageHeader(2, 0, MsgTypeVideo), buf.New([]byte{0x17})),
+	}
+	if _, err := NewAggregateMessage(mixed); !errors.Is(err, ErrInvalidAggregate) {
+		t.Errorf("mixed streams: got %v, want ErrInvalidAggregate", err)
+	}
+
+	if _, err := NewAggregateMessage(nil); !errors.Is(err, ErrInvalidAggregate) {
+		t.Errorf("empty: got %v, want ErrInvalidAggregate", err)
+	}
+}
+
+func TestAggregator(t *testing.T) {
+	media := func(streamID, ts uint32, size int) Message {
+		return NewMessage(NewMessageHeader(streamID, ts, MsgTypeVideo), buf.New(make([]byte, size)))
+	}
+	// 하위 메시지 하나의 크기: 11 + 100 + 4 = 115
+	a := NewAggregator(350, 100)
+
+	if ready := a.Add(media(1, 0, 100)); len(ready) != 0 {
+		t.Fatalf("first add returned %d messages", len(ready))
+	}
+	if ready := a.Add(media(1, 20, 100)); len(ready) != 0 {
+		t.Fatalf("second add returned %d messages", len(ready))
+	}
+	if ready := a.Add(media(1, 40, 100)); len(ready) != 0 {
+		t.Fatalf("third add returned %d messages", len(ready))
+	}
+
+	// 크기 초과: 앞의 3개가 aggregate로 나옴
+	ready := a.Add(media(1, 60, 100))
+	if len(ready) != 1 || ready[0].Type() != MsgTypeAggregate || ready[0].Timestamp() != 0 {
+		t.Fatalf("size flush: got %d messages", len(ready))
+	}
+	releaseMessages(ready)
+
+	// stream 변경
+	ready = a.Add(media(2, 80, 100))
+	if len(ready) != 1 || ready[0].Type() != MsgTypeVideo || ready[0].StreamID() != 1 {
+		t.Fatalf("stream flush: expected the single pending message as-is, got %d", len(ready))
+	}
+	releaseMessages(ready)
+
+	// duration 초과
+	ready = a.Add(media(2, 300, 100))
+	if len(ready) != 1 || ready[0].Timestamp() != 80 {
+		t.Fatalf("duration flush: got %d messages", len(ready))
+	}
+	releaseMessages(ready)
+
+	// 커맨드는 묶음을 내보낸 뒤 그대로 통과
+	cmd := NewMessage(NewMessageHeader(2, 310, MsgTypeAMF0Command), buf.New([]byte{0x02}))
+	ready = a.Add(cmd)
+	if len(ready) != 2 || ready[0].Timestamp() != 300 || ready[1].Type() != MsgTypeAMF0Command {
+		t.Fatalf("command: got %d messages", len(ready))
+	}
+	releaseMessages(ready)
+
+	if a.Pending() != 0 {
+		t.Errorf("pending = %d, want 0", a.Pending())
+	}
+	if ready := a.Flush(); ready != nil {
+		t.Errorf("empty flush returned %d messages", len(ready))
+	}
+}
+
+func TestTransport_ReadAggregate(t *testing.T) {
+	conn := newTestConn()
+	tr := NewTransport(conn)
+
+	body := aggregateEntry(MsgTypeVideo, 0, []byte{0x17, 0x00}, true)
+	body = append(body, aggregateEntry(MsgTypeVideo, 33, []byte{0x27, 0x01}, true)...)
+	encoded := encodeMessage(t, newAggregate(1, 500, body))
+	conn.readBuf.Write(encoded)
+
+	// 다음 메시지가 aggregate 뒤에 이어짐
+	conn.readBuf.Write(encodeMessage(t, NewMessage(NewMessageHeader(1, 600, MsgTypeAudio), buf.New([]byte{0xAF}))))
+
+	wantTS := []uint32{500, 533, 600}
+	wantType := []uint8{MsgTypeVideo, MsgTypeVideo, MsgTypeAudio}
+	for i := range wantTS {
+		msg, err := tr.ReadMessage()
+		if err != nil {
+			t.Fatalf("ReadMessage %d: %v", i, err)
+		}
+		if msg.Type() != wantType[i] || msg.Timestamp() != wantTS[i] {
+			t.Errorf("msg %d: type=%d ts=%d, want type=%d ts=%d", i, msg.Type(), msg.Timestamp(), wantType[i], wantTS[i])
+		}
+		msg.Buffer().Release()
+	}
+}
+
+func TestTransport_ReadEmptyAggregates(t *testing.T) {
+	conn := newTestConn()
+	tr := NewTransport(conn)
+
+	// 길이 0 aggregate 뒤에 같은 헤더를 재사용하는 1바이트 fmt 3 청크가 이어지면
+	// 청크마다 빈 aggregate가 하나씩 완성됨
+	const empties = 1 << 22
+	conn.readBuf.Write([]byte{
+		0x03,             // fmt 0, csid 3
+		0x00, 0x01, 0xF4, // timestamp 500
+		0x00, 0x00, 0x00, // length 0
+		MsgTypeAggregate,
+		0x01, 0x00, 0x00, 0x00, // stream ID 1
+	})
+	conn.readBuf.Write(bytes.Repeat([]byte{0xC3}, empties))
+	conn.readBuf.Write(encodeMessage(t, NewMessage(NewMessageHeader(1, 600, MsgTypeAudio), buf.New([]byte{0xAF}))))
+
+	msg, err := tr.ReadMessage()
+	if err != nil {
+		t.Fatalf("ReadMessage: %v", err)
+	}
+	defer msg.Buffer().Release()
+	if msg.Type() != MsgTypeAudio || msg.Timestamp() != 600 {
+		t.Errorf("type=%d ts=%d, want type=%d ts=600", msg.Type(), msg.Timestamp(), MsgTypeAudio)
+	}
+	if conn.readBuf.Len() != 0 {
+		t.Errorf("%d bytes left unread", conn.readBuf.Len())
+	}
+}
@@ -70,6 +70,7 @@ const (
 	AudioCodecNellymoser   = 0x06
 	AudioCodecALaw         = 0x07
 	AudioCodecMuLaw        = 0x08
+	AudioCodecExHeader     = 0x09 // E-RTMP ExAudioTagHeader (FourCC 기반)
 	AudioCodecAAC          = 0x0A
 	AudioCodecSpeex        = 0x0B
 	AudioCodecMP38kHz      = 0x0E
@@ -0,0 +1,216 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// deadlineSetter is implemented by connections with deadlines (net.Conn 등)
+type deadlineSetter interface {
+	SetReadDeadline(t time.Time) error
+	SetWriteDeadline(t time.Time) error
+}
+
+// ServerHandshakeContext performs the server-side handshake bounded by ctx.
+// rw가 deadline을 지원하면 ctx의 deadline/취소를 연결 deadline으로 적용하며,
+// 기한 초과 시 ErrHandshakeTimeout을 반환
+func ServerHandshakeContext(ctx context.Context, rw io.ReadWriter) (HandshakeMode, error) {
+	var mode HandshakeMode
+	err := withContext(ctx, rw, func() error {
+		var err error
+		mode, err = ServerHandshakeMode(rw)
+		return err
+	})
+	return mode, err
+}
+
+// ClientHandshakeContext performs the client-side handshake bounded by ctx
+// (ServerHandshakeContext와 동일하게 deadline 적용)
+func ClientHandshakeContext(ctx context.Context, rw io.ReadWriter, mode HandshakeMode) (HandshakeMode, error) {
+	var negotiated HandshakeMode
+	err := withContext(ctx, rw, func() error {
+		var err error
+		negotiated, err = ClientHandshakeMode(rw, mode)
+		return err
+	})
+	return negotiated, err
+}
+
+// withContext runs a handshake with ctx mapped onto the deadlines of rw, then clears them
+func withContext(ctx context.Context, rw io.ReadWriter, fn func() error) error {
+	if ctx.Err() != nil {
+		return contextError(ctx, ctx.Err(), ErrHandshakeTimeout)
+	}
+	conn, ok := rw.(deadlineSetter)
+	if !ok || ctx.Done() == nil {
+		return fn()
+	}
+
+	var mu sync.Mutex
+	setDeadline := func(t time.Time) {
+		_ = conn.SetReadDeadline(t)
+		_ = conn.SetWriteDeadline(t)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		setDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() {
+		mu.Lock()
+		defer mu.Unlock()
+		setDeadline(aLongTimeAgo)
+	})
+
+	err := fn()
+
+	stop()
+	mu.Lock()
+	setDeadline(time.Time{})
+	mu.Unlock()
+
+	if err != nil {
+		return contextError(ctx, err, ErrHandshakeTimeout)
+	}
+	return nil
+}
+
+// contextError maps an I/O error of an operation bounded by ctx.
+// 취소는 context.Cause, 기한 초과는 timeout 에러 (context.DeadlineExceeded도 포함), 그 외는 err 그대로.
+// 연결 deadline이 context 타이머보다 먼저 만료될 수 있으므로 deadline 시각도 확인
+func contextError(ctx context.Context, err, timeout error) error {
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return context.Cause(ctx)
+	}
+	if ctx.Err() != nil {
+		return fmt.Errorf("%w: %w", timeout, context.DeadlineExceeded)
+	}
+	if deadline, ok := ctx.Deadline(); ok && !time.Now().Before(deadline) && errors.Is(err, os.ErrDeadlineExceeded) {
+		return fmt.Errorf("%w: %w", timeout, context.DeadlineExceeded)
+	}
+	return err
+}
+
+// aLongTimeAgo is a deadline in the past that interrupts blocked I/O immediately
+var aLongTimeAgo = time.Unix(1, 0)
+
+// deadlineConn applies idle timeouts and per-call contexts to the deadlines of a connection.
+// 매 Read/Write 직전에 deadline을 갱신하므로 timeout은 데이터가 오가지 않는 시간의 한도.
+// 연결이 deadline을 지원하지 않으면 timeout과 context deadline은 무시됨 (취소는 Close로만 가능)
+type deadlineConn struct {
+	io.ReadWriteCloser
+	read  *ioDeadline
+	write *ioDeadline
+}
+
+// newDeadlineConn wraps rwc
+func newDeadlineConn(rwc io.ReadWriteCloser) *deadlineConn {
+	c := &deadlineConn{
+		ReadWriteCloser: rwc,
+		read:            &ioDeadline{timeoutErr: ErrReadTimeout},
+		write:           &ioDeadline{timeoutErr: ErrWriteTimeout},
+	}
+	if conn, ok := rwc.(deadlineSetter); ok {
+		c.read.set = conn.SetReadDeadline
+		c.write.set = conn.SetWriteDeadline
+	}
+	return c
+}
+
+// Read reads with the read deadline refreshed
+func (c *deadlineConn) Read(p []byte) (int, error) {
+	c.read.arm()
+	n, err := c.ReadWriteCloser.Read(p)
+	return n, c.read.wrap(err)
+}
+
+// Write writes with the write deadline refreshed
+func (c *deadlineConn) Write(p []byte) (int, error) {
+	c.write.arm()
+	n, err := c.ReadWriteCloser.Write(p)
+	return n, c.write.wrap(err)
+}
+
+// ioDeadline manages the deadline of one direction
+type ioDeadline struct {
+	set        func(time.Time) error // nil이면 deadline 미지원
+	timeoutErr error
+	timeout    atomic.Int64 // idle timeout (time.Duration, 0이면 없음)
+
+	mu    sync.Mutex
+	ctx   context.Context // 진행 중인 호출의 context
+	armed bool            // 연결에 deadline이 설정되어 있음
+}
+
+// setTimeout sets the idle timeout
+func (d *ioDeadline) setTimeout(timeout time.Duration) {
+	d.timeout.Store(int64(timeout))
+}
+
+// arm sets the connection deadline from the idle timeout and the bound context
+func (d *ioDeadline) arm() {
+	if d.set == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var deadline time.Time
+	if d.ctx != nil {
+		if d.ctx.Err() != nil {
+			deadline = aLongTimeAgo
+		} else if t, ok := d.ctx.Deadline(); ok {
+			deadline = t
+		}
+	}
+	if timeout := time.Duration(d.timeout.Load()); timeout > 0 {
+		if t := time.Now().Add(timeout); deadline.IsZero() || t.Before(deadline) {
+			deadline = t
+		}
+	}
+
+	if deadline.IsZero() && !d.armed {
+		return
+	}
+	_ = d.set(deadline)
+	d.armed = !deadline.IsZero()
+}
+
+// bind applies ctx to the I/O until the returned function is called
+func (d *ioDeadline) bind(ctx context.Context) (unbind func()) {
+	if d.set == nil || ctx.Done() == nil {
+		return func() {}
+	}
+
+	d.mu.Lock()
+	d.ctx = ctx
+	d.mu.Unlock()
+
+	// 대기 중인 I/O를 즉시 깨움
+	stop := context.AfterFunc(ctx, func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if d.ctx == ctx {
+			_ = d.set(aLongTimeAgo)
+			d.armed = true
+		}
+	})
+	return func() {
+		stop()
+		d.mu.Lock()
+		d.ctx = nil
+		d.mu.Unlock()
+	}
+}
+
+// wrap marks deadline errors with the timeout error of the direction
+func (d *ioDeadline) wrap(err error) error {
+	if err != nil && errors.Is(err, os.ErrDeadlineExceeded) && !errors.Is(err, d.timeoutErr) {
+		return fmt.Errorf("%w: %w", d.timeoutErr, err)
+	}
+	return err
+}
@@ -0,0 +1,215 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ssungk/ertmp/pkg/rtmp/buf"
+)
+
+func TestServerHandshakeContext_Timeout(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// 클라이언트가 아무것도 보내지 않음
+	_, err := ServerHandshakeContext(ctx, server)
+	if !errors.Is(err, ErrHandshakeTimeout) {
+		t.Fatalf("got %v, want ErrHandshakeTimeout", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want context.DeadlineExceeded", err)
+	}
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Errorf("expected net.Error with Timeout() = true, got %v", err)
+	}
+}
+
+func TestServerHandshakeContext_Cancel(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	_, err := ServerHandshakeContext(ctx, server)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+}
+
+func TestHandshakeContext_Success(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := ClientHandshakeContext(ctx, client, HandshakeDigest)
+		errCh <- err
+	}()
+
+	if _, err := ServerHandshakeContext(ctx, server); err != nil {
+		t.Fatalf("server handshake: %v", err)
+	}
+	if err := <-errCh; err != nil {
+		t.Fatalf("client handshake: %v", err)
+	}
+
+	// 핸드셰이크 후 deadline이 해제되어야 함
+	cancel()
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		client.Write([]byte{0x01})
+	}()
+	if _, err := server.Read(make([]byte, 1)); err != nil {
+		t.Fatalf("read after handshake: %v", err)
+	}
+}
+
+func TestTransport_ReadTimeout(t *testing.T) {
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	tr := NewTransport(a)
+	tr.SetReadTimeout(50 * time.Millisecond)
+
+	_, err := tr.ReadMessage()
+	if !errors.Is(err, ErrReadTimeout) {
+		t.Fatalf("got %v, want ErrReadTimeout", err)
+	}
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("got %v, want os.ErrDeadlineExceeded", err)
+	}
+}
+
+func TestTransport_ReadTimeoutIdle(t *testing.T) {
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	tr := NewTransport(a)
+	tr.SetReadTimeout(100 * time.Millisecond)
+
+	// 메시지 전체는 timeout보다 오래 걸리지만 데이터가 계속 도착
+	data := make([]byte, 300)
+	encoded := encodeMessage(t, NewMessage(NewMessageHeader(1, 0, MsgTypeVideo), buf.New(data)))
+	go func() {
+		for i := 0; i < len(encoded); i += 50 {
+			end := min(i+50, len(encoded))
+			if _, err := b.Write(encoded[i:end]); err != nil {
+				return
+			}
+			time.Sleep(30 * time.Millisecond)
+		}
+	}()
+
+	msg, err := tr.ReadMessage()
+	if err != nil {
+		t.Fatalf("ReadMessage: %v", err)
+	}
+	defer msg.Buffer().Release()
+	if len(msg.Data()) != len(data) {
+		t.Errorf("got %d bytes, want %d", len(msg.Data()), len(data))
+	}
+}
+
+func TestTransport_ReadMessageContext(t *testing.T) {
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	tr := NewTransport(a)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := tr.ReadMessageContext(ctx)
+	if !errors.Is(err, ErrReadTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want ErrReadTimeout and context.DeadlineExceeded", err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	_, err = tr.ReadMessageContext(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+}
+
+func TestTransport_WriteTimeout(t *testing.T) {
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	// 상대가 읽지 않음
+	tr := NewTransport(a)
+	tr.SetWriteTimeout(50 * time.Millisecond)
+
+	msg := NewMessage(NewMessageHeader(1, 0, MsgTypeVideo), buf.New(make([]byte, 100)))
+	err := tr.WriteMessage(msg)
+	if !errors.Is(err, ErrWriteTimeout) {
+		t.Fatalf("got %v, want ErrWriteTimeout", err)
+	}
+}
+
+func TestTransport_WriteMessageContext(t *testing.T) {
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	tr := NewTransport(a)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	msg := NewMessage(NewMessageHeader(1, 0, MsgTypeVideo), buf.New(make([]byte, 100)))
+	err := tr.WriteMessageContext(ctx, msg)
+	if !errors.Is(err, ErrWriteTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want ErrWriteTimeout and context.DeadlineExceeded", err)
+	}
+}
+
+func TestTransport_FlowWaitContext(t *testing.T) {
+	sender, peer := setupFlow(t)
+
+	for sender.BytesInFlight() < 2000 {
+		if err := sender.WriteMessage(videoMessage(0x17)); err != nil {
+			t.Fatalf("WriteMessage: %v", err)
+		}
+		peer.next()
+	}
+
+	// 창 대기도 context로 중단
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := sender.WriteMessageContext(ctx, videoMessage(0x17))
+	if !errors.Is(err, ErrWriteTimeout) {
+		t.Fatalf("got %v, want ErrWriteTimeout", err)
+	}
+}
+
+// encodeMessage returns the chunk encoding of msg with the default chunk size
+func encodeMessage(t *testing.T, msg Message) []byte {
+	t.Helper()
+	tc := newTestConn()
+	w := NewWriter(newMeteredConn(tc))
+	if err := w.WriteMessage(msg); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	return tc.writeBuf.Bytes()
+}
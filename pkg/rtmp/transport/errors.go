@@ -10,6 +10,31 @@ var (
 	// Protocol errors
 	ErrUnsupportedVersion = errors.New("unsupported RTMP version")
 
+	// Timeout errors (net.Error Timeout()도 true)
+	ErrHandshakeTimeout error = &timeoutError{"rtmp handshake timeout"}
+	ErrReadTimeout      error = &timeoutError{"rtmp read timeout"}
+	ErrWriteTimeout     error = &timeoutError{"rtmp write timeout"}
+
+	// Aggregate message errors
+	ErrInvalidAggregate = errors.New("invalid aggregate message")
+
+	// Flow control errors
+	ErrWindowFull = errors.New("peer window full")
+
 	// Message header errors
 	ErrNoPreviousHeader = errors.New("format type requires previous header")
 )
+
+// timeoutError is a timeout error comparable with errors.Is
+type timeoutError struct {
+	msg string
+}
+
+// Error implements the error interface
+func (e *timeoutError) Error() string { return e.msg }
+
+// Timeout reports that the error is a timeout (net.Error)
+func (e *timeoutError) Timeout() bool { return true }
+
+// Temporary implements net.Error
+func (e *timeoutError) Temporary() bool { return true }
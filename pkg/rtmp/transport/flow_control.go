@@ -0,0 +1,172 @@
+package transport
+
+import (
+	"context"
+	"math"
+	"sync"
+)
+
+// FlowMode selects how media writes behave when the peer's window is exhausted
+type FlowMode int
+
+const (
+	FlowBlock FlowMode = iota // 상대의 Acknowledgement로 창이 열릴 때까지 미디어 전송 대기 (기본값)
+	FlowShed                  // non-key video는 ErrWindowFull로 버리고, 나머지 미디어는 대기
+	FlowOff                   // 창을 무시하고 항상 전송
+)
+
+// flowControl tracks unacknowledged outbound bytes against the window set by the peer.
+// 상대가 SetPeerBandwidth로 창을 지정했고 이쪽이 WindowAckSize를 알린 경우에만 적용
+// (ACK를 받을 수 없는 상태에서 대기하면 교착되므로).
+// 대기는 audio/video/aggregate에만 적용되며, 제어/커맨드 메시지는 항상 즉시 전송
+type flowControl struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+
+	mode      FlowMode
+	window    uint32 // 상대가 지정한 송신 창 (0이면 제한 없음)
+	limitType uint8  // 마지막으로 적용된 limit type
+	ackWindow uint32 // 상대에게 알린 WindowAckSize
+	acked     uint32 // 상대가 수신했다고 알린 바이트 수 (uint32 wrap-around)
+	closed    bool
+}
+
+// newFlowControl creates a flow control state with no window
+func newFlowControl() *flowControl {
+	f := &flowControl{}
+	f.cond = sync.NewCond(&f.mu)
+	return f
+}
+
+// setMode changes the flow mode and wakes blocked writers
+func (f *flowControl) setMode(mode FlowMode) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.mode = mode
+	f.cond.Broadcast()
+}
+
+// setPeerBandwidth applies a SetPeerBandwidth message from the peer.
+// Hard: 창을 size로 설정, Soft: 현재 창과 size 중 작은 값,
+// Dynamic: 이전 limit type이 Hard이면 Hard로 처리하고 아니면 무시
+func (f *flowControl) setPeerBandwidth(size uint32, limitType uint8) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch limitType {
+	case LimitTypeHard:
+		f.window = size
+	case LimitTypeSoft:
+		if f.window == 0 || size < f.window {
+			f.window = size
+		}
+	case LimitTypeDynamic:
+		if f.window == 0 || f.limitType != LimitTypeHard {
+			return
+		}
+		f.window = size
+		limitType = LimitTypeHard
+	default:
+		return
+	}
+	f.limitType = limitType
+	f.cond.Broadcast()
+}
+
+// setAckWindow records the WindowAckSize announced to the peer
+func (f *flowControl) setAckWindow(size uint32) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ackWindow = size
+	f.cond.Broadcast()
+}
+
+// acknowledge records an Acknowledgement sequence number from the peer
+func (f *flowControl) acknowledge(sequence uint32) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.acked = sequence
+	f.cond.Broadcast()
+}
+
+// close wakes blocked writers; 이후 쓰기는 연결 에러로 실패
+func (f *flowControl) close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	f.cond.Broadcast()
+}
+
+// limit returns the effective send window (0이면 제한 없음) (f.mu must be held).
+// 상대는 ackWindow마다 ACK하므로 창이 그보다 작으면 ackWindow를 사용
+func (f *flowControl) limit() uint32 {
+	if f.mode == FlowOff || f.window == 0 || f.ackWindow == 0 {
+		return 0
+	}
+	return max(f.window, f.ackWindow)
+}
+
+// inFlight returns the bytes written but not yet acknowledged (f.mu must be held)
+func (f *flowControl) inFlight(written uint64) uint64 {
+	n := uint32(written) - f.acked
+	// 상대가 핸드셰이크 등을 포함해 더 많이 센 경우 음수가 되어 wrap됨
+	if uint64(n) > written || n > math.MaxInt32 {
+		return 0
+	}
+	return uint64(n)
+}
+
+// wait blocks a media message until the window has room or ctx is done.
+// FlowShed이면 non-key video는 대기 대신 ErrWindowFull 반환.
+// 창 확인 후 전송하므로 마지막 메시지만큼 창을 넘을 수 있음
+func (f *flowControl) wait(ctx context.Context, msg Message, written func() uint64) error {
+	switch msg.Type() {
+	case MsgTypeAudio, MsgTypeVideo, MsgTypeAggregate:
+	default:
+		return nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for {
+		limit := f.limit()
+		if f.closed || limit == 0 || f.inFlight(written()) < uint64(limit) {
+			return nil
+		}
+		if f.mode == FlowShed && isDroppableVideo(msg) {
+			return ErrWindowFull
+		}
+		if ctx.Err() != nil {
+			return contextError(ctx, ctx.Err(), ErrWriteTimeout)
+		}
+		f.waitContext(ctx)
+	}
+}
+
+// waitContext waits for a state change or for ctx to be done (f.mu must be held)
+func (f *flowControl) waitContext(ctx context.Context) {
+	if ctx.Done() == nil {
+		f.cond.Wait()
+		return
+	}
+	stop := context.AfterFunc(ctx, func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.cond.Broadcast()
+	})
+	f.cond.Wait()
+	stop()
+}
+
+// isDroppableVideo reports whether msg is a non-key video frame (inter/disposable/generated).
+// legacy와 ExVideoTagHeader 모두 bit 4-6이 frame type
+func isDroppableVideo(msg Message) bool {
+	if msg.Type() != MsgTypeVideo || len(msg.Data()) == 0 {
+		return false
+	}
+	switch (msg.Data()[0] >> 4) & 0x07 {
+	case VideoFrameTypeInter, VideoFrameTypeDisposable, VideoFrameTypeGenerated:
+		return true
+	}
+	return false
+}
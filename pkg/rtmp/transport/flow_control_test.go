@@ -0,0 +1,328 @@
+package transport
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ssungk/ertmp/pkg/rtmp/buf"
+)
+
+func TestFlowControl_LimitTypes(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps [][2]uint32 // {size, limitType}
+		want  uint32
+	}{
+		{"hard", [][2]uint32{{5000, LimitTypeHard}}, 5000},
+		{"hard replaces", [][2]uint32{{5000, LimitTypeHard}, {8000, LimitTypeHard}}, 8000},
+		{"soft first", [][2]uint32{{5000, LimitTypeSoft}}, 5000},
+		{"soft keeps smaller", [][2]uint32{{5000, LimitTypeHard}, {8000, LimitTypeSoft}}, 5000},
+		{"soft lowers", [][2]uint32{{5000, LimitTypeHard}, {3000, LimitTypeSoft}}, 3000},
+		{"dynamic ignored first", [][2]uint32{{5000, LimitTypeDynamic}}, 0},
+		{"dynamic after hard", [][2]uint32{{5000, LimitTypeHard}, {8000, LimitTypeDynamic}}, 8000},
+		{"dynamic after soft", [][2]uint32{{5000, LimitTypeSoft}, {8000, LimitTypeDynamic}}, 5000},
+		{"unknown type", [][2]uint32{{5000, 7}}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFlowControl()
+			f.setAckWindow(1000)
+			for _, step := range tt.steps {
+				f.setPeerBandwidth(step[0], uint8(step[1]))
+			}
+			if got := f.limit(); got != tt.want {
+				t.Errorf("limit = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlowControl_Limit(t *testing.T) {
+	f := newFlowControl()
+	f.setPeerBandwidth(500, LimitTypeHard)
+	if got := f.limit(); got != 0 {
+		t.Errorf("limit without ack window = %d, want 0", got)
+	}
+
+	// 창이 ACK 간격보다 작으면 ACK 간격 사용
+	f.setAckWindow(1000)
+	if got := f.limit(); got != 1000 {
+		t.Errorf("limit = %d, want 1000", got)
+	}
+
+	f.setMode(FlowOff)
+	if got := f.limit(); got != 0 {
+		t.Errorf("limit with FlowOff = %d, want 0", got)
+	}
+}
+
+func TestFlowControl_InFlight(t *testing.T) {
+	tests := []struct {
+		name    string
+		written uint64
+		acked   uint32
+		want    uint64
+	}{
+		{"no ack", 3000, 0, 3000},
+		{"partial ack", 3000, 1000, 2000},
+		{"all acked", 3000, 3000, 0},
+		{"peer counted more", 3000, 6073, 0},
+		{"wrap-around", 1<<32 + 500, 0xFFFFFF00, 756},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFlowControl()
+			f.acknowledge(tt.acked)
+			if got := f.inFlight(tt.written); got != tt.want {
+				t.Errorf("inFlight = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsDroppableVideo(t *testing.T) {
+	tests := []struct {
+		name    string
+		msgType uint8
+		data    []byte
+		want    bool
+	}{
+		{"avc keyframe", MsgTypeVideo, []byte{0x17, 0x01}, false},
+		{"avc inter", MsgTypeVideo, []byte{0x27, 0x01}, true},
+		{"disposable", MsgTypeVideo, []byte{0x32}, true},
+		{"ex keyframe", MsgTypeVideo, []byte{0x91}, false},
+		{"ex inter", MsgTypeVideo, []byte{0xA1}, true},
+		{"empty", MsgTypeVideo, nil, false},
+		{"audio", MsgTypeAudio, []byte{0xAF, 0x01}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := NewMessage(NewMessageHeader(1, 0, tt.msgType), buf.New(tt.data))
+			if got := isDroppableVideo(msg); got != tt.want {
+				t.Errorf("isDroppableVideo = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// flowPeer is the receiving side of a flow control test.
+// 자동 ACK 없이 수신하고, 테스트가 원할 때만 Acknowledgement 전송
+type flowPeer struct {
+	conn     *meteredConn
+	reader   *Reader
+	writer   *Writer
+	received chan Message
+}
+
+func newFlowPeer(c net.Conn) *flowPeer {
+	mc := newMeteredConn(c)
+	p := &flowPeer{
+		conn:     mc,
+		reader:   NewReader(mc),
+		writer:   NewWriter(mc),
+		received: make(chan Message, 64),
+	}
+	go func() {
+		for {
+			msg, err := p.reader.ReadMessage()
+			if err != nil {
+				close(p.received)
+				return
+			}
+			p.received <- msg
+		}
+	}()
+	return p
+}
+
+// send writes a protocol control message to the sender
+func (p *flowPeer) send(t *testing.T, msgType uint8, data []byte) {
+	t.Helper()
+	msg := NewMessage(NewMessageHeader(0, 0, msgType), buf.New(data))
+	if err := p.writer.WriteMessage(msg); err != nil {
+		t.Fatalf("peer write: %v", err)
+	}
+	if err := p.writer.Flush(); err != nil {
+		t.Fatalf("peer flush: %v", err)
+	}
+}
+
+// next discards the next received message
+func (p *flowPeer) next() {
+	msg := <-p.received
+	msg.Buffer().Release()
+}
+
+// ack acknowledges all bytes received so far
+func (p *flowPeer) ack(t *testing.T) {
+	data := make([]byte, 4)
+	binary.BigEndian.PutUint32(data, uint32(p.conn.BytesRead()))
+	p.send(t, MsgTypeAcknowledgement, data)
+}
+
+// setupFlow connects a sender with a 1000 byte ack window and a 2000 byte hard peer window
+func setupFlow(t *testing.T) (*Transport, *flowPeer) {
+	t.Helper()
+	a, b := net.Pipe()
+	sender := NewTransport(a)
+	peer := newFlowPeer(b)
+	t.Cleanup(func() {
+		sender.Close()
+		b.Close()
+	})
+
+	// 송신 측 읽기 루프 (ACK, SetPeerBandwidth 처리)
+	go func() {
+		for {
+			msg, err := sender.ReadMessage()
+			if err != nil {
+				return
+			}
+			msg.Buffer().Release()
+		}
+	}()
+
+	if err := sender.SetWindowAckSize(1000); err != nil {
+		t.Fatalf("SetWindowAckSize: %v", err)
+	}
+	peer.next()
+
+	data := make([]byte, 5)
+	binary.BigEndian.PutUint32(data, 2000)
+	data[4] = LimitTypeHard
+	peer.send(t, MsgTypeSetPeerBW, data)
+
+	waitFor(t, func() bool { return sender.SendWindow() == 2000 })
+	return sender, peer
+}
+
+// waitFor polls cond until it holds or the test times out
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// videoMessage creates a 500 byte video message with the given first byte
+func videoMessage(first byte) Message {
+	data := make([]byte, 500)
+	data[0] = first
+	return NewMessage(NewMessageHeader(1, 0, MsgTypeVideo), buf.New(data))
+}
+
+func TestTransport_FlowBlock(t *testing.T) {
+	sender, peer := setupFlow(t)
+
+	// 창(2000)을 넘을 때까지 전송
+	for sender.BytesInFlight() < 2000 {
+		if err := sender.WriteMessage(videoMessage(0x17)); err != nil {
+			t.Fatalf("WriteMessage: %v", err)
+		}
+		peer.next()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- sender.WriteMessage(videoMessage(0x27))
+	}()
+
+	select {
+	case err := <-done:
+		t.Fatalf("WriteMessage returned %v before acknowledgement", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// 커맨드는 창과 무관하게 전송
+	cmd := NewMessage(NewMessageHeader(0, 0, MsgTypeAMF0Command), buf.New([]byte{0x05}))
+	if err := sender.WriteMessage(cmd); err != nil {
+		t.Fatalf("command write: %v", err)
+	}
+	peer.next()
+
+	peer.ack(t)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("WriteMessage: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("WriteMessage still blocked after acknowledgement")
+	}
+	peer.next()
+
+	if got := sender.BytesInFlight(); got >= 2000 {
+		t.Errorf("BytesInFlight = %d after acknowledgement", got)
+	}
+}
+
+func TestTransport_FlowShed(t *testing.T) {
+	sender, peer := setupFlow(t)
+	sender.SetFlowMode(FlowShed)
+
+	for sender.BytesInFlight() < 2000 {
+		if err := sender.WriteMessage(videoMessage(0x17)); err != nil {
+			t.Fatalf("WriteMessage: %v", err)
+		}
+		peer.next()
+	}
+
+	// non-key video는 즉시 폐기
+	if err := sender.WriteMessage(videoMessage(0x27)); !errors.Is(err, ErrWindowFull) {
+		t.Fatalf("inter frame: got %v, want ErrWindowFull", err)
+	}
+
+	// keyframe은 대기 후 전송
+	done := make(chan error, 1)
+	go func() {
+		done <- sender.WriteMessage(videoMessage(0x17))
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("keyframe returned %v before acknowledgement", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	peer.ack(t)
+	if err := <-done; err != nil {
+		t.Fatalf("keyframe: %v", err)
+	}
+	peer.next()
+}
+
+func TestTransport_FlowCloseUnblocks(t *testing.T) {
+	sender, peer := setupFlow(t)
+
+	for sender.BytesInFlight() < 2000 {
+		if err := sender.WriteMessage(videoMessage(0x17)); err != nil {
+			t.Fatalf("WriteMessage: %v", err)
+		}
+		peer.next()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- sender.WriteMessage(videoMessage(0x17))
+	}()
+	time.Sleep(20 * time.Millisecond)
+	sender.Close()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("expected write error after Close")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("WriteMessage still blocked after Close")
+	}
+}
@@ -7,101 +7,163 @@ import (
 )
 
 // ClientHandshake performs client-side RTMP handshake
+// simple 핸드셰이크를 수행 (digest 협상은 ClientHandshakeMode 사용)
 func ClientHandshake(rw io.ReadWriter) error {
+	_, err := ClientHandshakeMode(rw, HandshakeSimple)
+	return err
+}
+
+// ClientHandshakeMode performs client-side RTMP handshake in the given mode
+// mode가 HandshakeDigest이면 digest C1을 전송하고, 서버 S1이 digest를 포함하지 않으면 simple로 fallback
+// Returns the handshake mode actually negotiated with the server
+func ClientHandshakeMode(rw io.ReadWriter, mode HandshakeMode) (HandshakeMode, error) {
 	// Send C0
 	c0 := []byte{RTMPVersion}
 	if _, err := rw.Write(c0); err != nil {
-		return fmt.Errorf("handshake c0: %w: %w", ErrRtmpWrite, err)
+		return HandshakeSimple, fmt.Errorf("handshake c0: %w: %w", ErrRtmpWrite, err)
 	}
 
-	// Send C1 (time + zero + random bytes)
+	// Send C1
 	c1 := make([]byte, HandshakeSize)
-	// First 4 bytes: time (epoch seconds)
-	// Note: Using 0 is valid for simple handshake
-	// Second 4 bytes: zero
-	// Remaining 1528 bytes: random
-	_, _ = rand.Read(c1[8:])
+	var c1Digest []byte
+	if mode == HandshakeDigest {
+		// time + client version + digest (schema 1)
+		c1Digest = makeDigestPacket(c1, clientHandshakeVersion, digestBaseSchema1, genuineFPKeyText)
+	} else {
+		// First 4 bytes: time (epoch seconds)
+		// Note: Using 0 is valid for simple handshake
+		// Second 4 bytes: zero
+		// Remaining 1528 bytes: random
+		_, _ = rand.Read(c1[8:])
+	}
 	if _, err := rw.Write(c1); err != nil {
-		return fmt.Errorf("handshake c1: %w: %w", ErrRtmpWrite, err)
+		return HandshakeSimple, fmt.Errorf("handshake c1: %w: %w", ErrRtmpWrite, err)
 	}
 
 	// Read S0
 	s0 := make([]byte, 1)
 	if _, err := io.ReadFull(rw, s0); err != nil {
-		return fmt.Errorf("handshake s0: %w: %w", ErrRtmpRead, err)
+		return HandshakeSimple, fmt.Errorf("handshake s0: %w: %w", ErrRtmpRead, err)
 	}
 
 	if s0[0] != RTMPVersion {
-		return fmt.Errorf("handshake s0 version: got %d, want %d: %w", s0[0], RTMPVersion, ErrUnsupportedVersion)
+		return HandshakeSimple, fmt.Errorf("handshake s0 version: got %d, want %d: %w", s0[0], RTMPVersion, ErrUnsupportedVersion)
 	}
 
 	// Read S1 and save for C2
 	s1 := make([]byte, HandshakeSize)
 	if _, err := io.ReadFull(rw, s1); err != nil {
-		return fmt.Errorf("handshake s1: %w: %w", ErrRtmpRead, err)
+		return HandshakeSimple, fmt.Errorf("handshake s1: %w: %w", ErrRtmpRead, err)
 	}
 
 	// Read S2 (reuse c1 buffer)
 	s2 := c1
 	if _, err := io.ReadFull(rw, s2); err != nil {
-		return fmt.Errorf("handshake s2: %w: %w", ErrRtmpRead, err)
+		return HandshakeSimple, fmt.Errorf("handshake s2: %w: %w", ErrRtmpRead, err)
 	}
 
-	// Send C2 (echo S1)
-	c2 := s1
+	// S1 digest 검증: 실패 시 simple 핸드셰이크로 fallback
+	// S2는 서버 구현마다 편차가 커서 검증하지 않음 (librtmp, ffmpeg 동일)
+	var s1Digest []byte
+	if c1Digest != nil {
+		s1Digest, _ = findDigest(s1, genuineFMSKeyText)
+	}
+
+	// Send C2
+	var c2 []byte
+	if s1Digest != nil {
+		c2 = make([]byte, HandshakeSize)
+		makeDigestResponse(c2, s1Digest, genuineFPKey)
+		mode = HandshakeDigest
+	} else {
+		c2 = s1 // echo S1
+		mode = HandshakeSimple
+	}
 	if _, err := rw.Write(c2); err != nil {
-		return fmt.Errorf("handshake c2: %w: %w", ErrRtmpWrite, err)
+		return HandshakeSimple, fmt.Errorf("handshake c2: %w: %w", ErrRtmpWrite, err)
 	}
 
-	return nil
+	return mode, nil
 }
 
 // ServerHandshake performs server-side RTMP handshake
+// digest/simple 클라이언트 모두 지원 (협상된 모드는 ServerHandshakeMode로 확인)
 func ServerHandshake(rw io.ReadWriter) error {
+	_, err := ServerHandshakeMode(rw)
+	return err
+}
+
+// ServerHandshakeMode performs server-side RTMP handshake
+// C1이 digest를 포함하면 digest S1/S2로 응답하고, 아니면 simple 핸드셰이크로 응답
+// Returns the handshake mode detected from the client
+func ServerHandshakeMode(rw io.ReadWriter) (HandshakeMode, error) {
 	// Read C0
 	c0 := make([]byte, 1)
 	if _, err := io.ReadFull(rw, c0); err != nil {
-		return fmt.Errorf("handshake c0: %w: %w", ErrRtmpRead, err)
+		return HandshakeSimple, fmt.Errorf("handshake c0: %w: %w", ErrRtmpRead, err)
 	}
 
 	if c0[0] != RTMPVersion {
-		return fmt.Errorf("handshake c0 version: got %d, want %d: %w", c0[0], RTMPVersion, ErrUnsupportedVersion)
+		return HandshakeSimple, fmt.Errorf("handshake c0 version: got %d, want %d: %w", c0[0], RTMPVersion, ErrUnsupportedVersion)
 	}
 
 	// Read C1 and save for S2
 	c1 := make([]byte, HandshakeSize)
 	if _, err := io.ReadFull(rw, c1); err != nil {
-		return fmt.Errorf("handshake c1: %w: %w", ErrRtmpRead, err)
+		return HandshakeSimple, fmt.Errorf("handshake c1: %w: %w", ErrRtmpRead, err)
+	}
+
+	// C1 digest 감지 (version 필드가 0이면 simple 클라이언트)
+	var c1Digest []byte
+	var schema int
+	if c1[4] != 0 || c1[5] != 0 || c1[6] != 0 || c1[7] != 0 {
+		c1Digest, schema = findDigest(c1, genuineFPKeyText)
+	}
+
+	mode := HandshakeSimple
+	s1 := make([]byte, HandshakeSize)
+	s2 := c1
+	if c1Digest != nil {
+		mode = HandshakeDigest
+
+		// S1: 클라이언트와 동일한 schema로 digest 삽입
+		makeDigestPacket(s1, serverHandshakeVersion, schema, genuineFMSKeyText)
+
+		// S2: C1 digest로 파생한 키로 서명
+		s2 = make([]byte, HandshakeSize)
+		makeDigestResponse(s2, c1Digest, genuineFMSKey)
+	} else {
+		// S1 (time + zero + random bytes)
+		// First 4 bytes: time (epoch seconds)
+		// Note: Using 0 is valid for simple handshake
+		// Second 4 bytes: zero
+		// Remaining 1528 bytes: random
+		_, _ = rand.Read(s1[8:])
+		// S2: echo C1
 	}
 
 	// Send S0 (reuse c0 buffer)
 	s0 := c0
 	if _, err := rw.Write(s0); err != nil {
-		return fmt.Errorf("handshake s0: %w: %w", ErrRtmpWrite, err)
+		return HandshakeSimple, fmt.Errorf("handshake s0: %w: %w", ErrRtmpWrite, err)
 	}
 
-	// Send S1 (time + zero + random bytes)
-	s1 := make([]byte, HandshakeSize)
-	// First 4 bytes: time (epoch seconds)
-	// Note: Using 0 is valid for simple handshake
-	// Second 4 bytes: zero
-	// Remaining 1528 bytes: random
-	_, _ = rand.Read(s1[8:])
+	// Send S1
 	if _, err := rw.Write(s1); err != nil {
-		return fmt.Errorf("handshake s1: %w: %w", ErrRtmpWrite, err)
+		return HandshakeSimple, fmt.Errorf("handshake s1: %w: %w", ErrRtmpWrite, err)
 	}
 
-	// Send S2 (echo C1)
-	s2 := c1
+	// Send S2
 	if _, err := rw.Write(s2); err != nil {
-		return fmt.Errorf("handshake s2: %w: %w", ErrRtmpWrite, err)
+		return HandshakeSimple, fmt.Errorf("handshake s2: %w: %w", ErrRtmpWrite, err)
 	}
 
 	// Read C2 (reuse s1 buffer)
+	// C2 digest는 클라이언트마다 편차가 커서 검증하지 않음
 	c2 := s1
 	if _, err := io.ReadFull(rw, c2); err != nil {
-		return fmt.Errorf("handshake c2: %w: %w", ErrRtmpRead, err)
+		return HandshakeSimple, fmt.Errorf("handshake c2: %w: %w", ErrRtmpRead, err)
 	}
 
-	return nil
+	return mode, nil
 }
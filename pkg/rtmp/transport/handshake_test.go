@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"errors"
 	"io"
+	"net"
 	"testing"
 )
 
@@ -49,6 +50,131 @@ func TestServerHandshake(t *testing.T) {
 	testServerHandshake(t, h0, h1, failAfterC0C1, noLimit, ErrRtmpRead)            // C2 read fails (readLimit)
 }
 
+func TestHandshakeMode_Negotiation(t *testing.T) {
+	testHandshakePair(t, HandshakeSimple, HandshakeSimple)
+	testHandshakePair(t, HandshakeDigest, HandshakeDigest)
+}
+
+func TestHandshakeDigest_ServerResponse(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+
+	go func() {
+		_, _ = ServerHandshakeMode(serverConn)
+	}()
+
+	// C0 + digest C1 (schema 0)
+	c1 := make([]byte, HandshakeSize)
+	c1Digest := makeDigestPacket(c1, clientHandshakeVersion, digestBaseSchema0, genuineFPKeyText)
+	if _, err := clientConn.Write(append([]byte{RTMPVersion}, c1...)); err != nil {
+		t.Fatalf("write C0C1 failed: %v", err)
+	}
+
+	resp := make([]byte, 1+2*HandshakeSize)
+	if _, err := io.ReadFull(clientConn, resp); err != nil {
+		t.Fatalf("read S0S1S2 failed: %v", err)
+	}
+	s1 := resp[1 : 1+HandshakeSize]
+	s2 := resp[1+HandshakeSize:]
+
+	// S1 must be signed with FMS key using the client's schema
+	s1Digest, base := findDigest(s1, genuineFMSKeyText)
+	if s1Digest == nil {
+		t.Fatal("S1 has no valid digest")
+	}
+	if base != digestBaseSchema0 {
+		t.Errorf("expected S1 schema base %d, got %d", digestBaseSchema0, base)
+	}
+
+	// S2 must be signed with key derived from C1 digest
+	if !validDigestResponse(s2, c1Digest, genuineFMSKey) {
+		t.Error("S2 digest is invalid")
+	}
+
+	c2 := make([]byte, HandshakeSize)
+	makeDigestResponse(c2, s1Digest, genuineFPKey)
+	if _, err := clientConn.Write(c2); err != nil {
+		t.Fatalf("write C2 failed: %v", err)
+	}
+}
+
+func TestHandshakeDigest_ClientFallback(t *testing.T) {
+	// testReadWriter는 digest 없는 S1을 제공하므로 simple로 fallback해야 함
+	rw := newTestReadWriter(h0, h1, noLimit, noLimit)
+
+	mode, err := ClientHandshakeMode(rw, HandshakeDigest)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mode != HandshakeSimple {
+		t.Errorf("expected fallback to %v, got %v", HandshakeSimple, mode)
+	}
+
+	// C2 must echo S1
+	written := rw.writeBuf.Bytes()
+	c2 := written[1+HandshakeSize:]
+	if !bytes.Equal(c2, h1) {
+		t.Error("C2 does not echo S1 in simple fallback")
+	}
+}
+
+func TestHandshakeDigest_SimpleC1WithVersion(t *testing.T) {
+	// version 필드가 0이 아니어도 digest가 유효하지 않으면 simple로 처리
+	c1 := makeTestHandshakeData()
+	c1[4] = 0x0A
+	rw := newTestReadWriter(h0, c1, noLimit, noLimit)
+
+	mode, err := ServerHandshakeMode(rw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mode != HandshakeSimple {
+		t.Errorf("expected %v, got %v", HandshakeSimple, mode)
+	}
+
+	// S2 must echo C1
+	written := rw.writeBuf.Bytes()
+	s2 := written[1+HandshakeSize:]
+	if !bytes.Equal(s2, c1) {
+		t.Error("S2 does not echo C1 in simple mode")
+	}
+}
+
+func testHandshakePair(t *testing.T, clientMode, wantMode HandshakeMode) {
+	t.Helper()
+
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+
+	type result struct {
+		mode HandshakeMode
+		err  error
+	}
+	serverDone := make(chan result, 1)
+	go func() {
+		mode, err := ServerHandshakeMode(serverConn)
+		serverDone <- result{mode, err}
+	}()
+
+	gotClient, err := ClientHandshakeMode(clientConn, clientMode)
+	if err != nil {
+		t.Fatalf("client handshake failed: %v", err)
+	}
+	server := <-serverDone
+	if server.err != nil {
+		t.Fatalf("server handshake failed: %v", server.err)
+	}
+
+	if gotClient != wantMode {
+		t.Errorf("client mode: expected %v, got %v", wantMode, gotClient)
+	}
+	if server.mode != wantMode {
+		t.Errorf("server mode: expected %v, got %v", wantMode, server.mode)
+	}
+}
+
 func testClientHandshake(t *testing.T, s0, s1 []byte, readLimit, writeLimit int, wantErr error) {
 	t.Helper()
 
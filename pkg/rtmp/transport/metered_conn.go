@@ -3,17 +3,20 @@ package transport
 import (
 	"bufio"
 	"io"
+	"sync/atomic"
 )
 
 // meteredConn wraps a connection and meters all bytes read and written
 // for RTMP acknowledgement and flow control.
 // Counts all bytes including RTMP protocol overhead (chunk headers, etc).
-// Not thread-safe: designed for single-goroutine usage.
+// 읽기와 쓰기는 서로 다른 고루틴에서 동시에 수행 가능 (버퍼가 분리됨).
+// 같은 방향의 호출은 직렬화되어야 함: 읽기는 단일 고루틴, 쓰기는 Transport.writeMu.
+// 카운터는 atomic이므로 어느 고루틴에서든 조회 가능
 type meteredConn struct {
 	*bufio.ReadWriter
 	rwc          io.ReadWriteCloser
-	bytesRead    uint64
-	bytesWritten uint64
+	bytesRead    atomic.Uint64
+	bytesWritten atomic.Uint64
 }
 
 // newMeteredConn creates a new metered connection
@@ -32,7 +35,7 @@ func newMeteredConn(rwc io.ReadWriteCloser) *meteredConn {
 func (mc *meteredConn) Read(p []byte) (int, error) {
 	n, err := mc.Reader.Read(p)
 	if n > 0 {
-		mc.bytesRead += uint64(n)
+		mc.bytesRead.Add(uint64(n))
 	}
 	return n, err
 }
@@ -41,7 +44,7 @@ func (mc *meteredConn) Read(p []byte) (int, error) {
 func (mc *meteredConn) ReadByte() (byte, error) {
 	b, err := mc.Reader.ReadByte()
 	if err == nil {
-		mc.bytesRead++
+		mc.bytesRead.Add(1)
 	}
 	return b, err
 }
@@ -51,7 +54,7 @@ func (mc *meteredConn) ReadByte() (byte, error) {
 func (mc *meteredConn) Write(p []byte) (int, error) {
 	n, err := mc.Writer.Write(p)
 	if n > 0 {
-		mc.bytesWritten += uint64(n)
+		mc.bytesWritten.Add(uint64(n))
 	}
 	return n, err
 }
@@ -60,7 +63,7 @@ func (mc *meteredConn) Write(p []byte) (int, error) {
 func (mc *meteredConn) WriteByte(c byte) error {
 	err := mc.Writer.WriteByte(c)
 	if err == nil {
-		mc.bytesWritten++
+		mc.bytesWritten.Add(1)
 	}
 	return err
 }
@@ -72,12 +75,12 @@ func (mc *meteredConn) Flush() error {
 
 // BytesRead returns the total number of bytes read
 func (mc *meteredConn) BytesRead() uint64 {
-	return mc.bytesRead
+	return mc.bytesRead.Load()
 }
 
 // BytesWritten returns the total number of bytes written
 func (mc *meteredConn) BytesWritten() uint64 {
-	return mc.bytesWritten
+	return mc.bytesWritten.Load()
 }
 
 // Close closes the underlying connection
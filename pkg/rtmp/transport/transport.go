@@ -1,63 +1,192 @@
 package transport
 
 import (
+	"context"
 	"encoding/binary"
 	"fmt"
 	"io"
+	"sync"
+	"time"
 
 	"github.com/ssungk/ertmp/pkg/rtmp/buf"
 )
 
-// Transport represents a bidirectional RTMP protocol handler
+// Transport represents a bidirectional RTMP protocol handler.
+// 쓰기 메서드는 여러 고루틴에서 동시에 호출 가능하며, 메시지 단위로 직렬화되어
+// 한 메시지의 청크가 다른 메시지와 섞이지 않음 (같은 고루틴의 쓰기 순서 유지).
+// ReadMessage는 한 고루틴에서만 호출해야 함.
+// 상대가 SetPeerBandwidth로 창을 지정하면 미디어 전송은 FlowMode에 따라 대기하거나 버려짐
 type Transport struct {
-	conn   *meteredConn
-	reader *Reader
-	writer *Writer
+	deadlines *deadlineConn
+	conn      *meteredConn
+	reader    *Reader
+	writer    *Writer
+
+	writeMu sync.Mutex // writer, meteredConn 쓰기 버퍼 보호
 
 	// 프로토콜 제어
 	windowAckSize uint32
-	peerBandwidth uint32
 	lastAckSent   uint64
+
+	flow *flowControl // 송신 flow control (상대 ACK, SetPeerBandwidth)
+
+	splitMu sync.Mutex // split, closed 보호 (Close는 다른 고루틴에서 호출 가능)
+	split   []Message  // 분해된 aggregate 중 아직 반환하지 않은 하위 메시지
+	closed  bool
 }
 
 // NewTransport creates a new Transport
 func NewTransport(rwc io.ReadWriteCloser) *Transport {
-	mc := newMeteredConn(rwc)
+	dc := newDeadlineConn(rwc)
+	mc := newMeteredConn(dc)
 	return &Transport{
+		deadlines:     dc,
 		conn:          mc,
 		reader:        NewReader(mc),
 		writer:        NewWriter(mc),
 		windowAckSize: 0,
+		flow:          newFlowControl(),
 	}
 }
 
-// Close closes the transport
+// Close closes the transport.
+// 반환하지 않은 aggregate 하위 메시지는 aggregate의 풀 버퍼를 붙잡고 있으므로 해제
 func (t *Transport) Close() error {
+	t.flow.close()
+
+	t.splitMu.Lock()
+	t.closed = true
+	for _, msg := range t.split {
+		msg.Buffer().Release()
+	}
+	t.split = nil
+	t.splitMu.Unlock()
+
 	return t.conn.Close()
 }
 
-// ReadMessage reads a message and handles protocol control automatically
+// ReadMessage reads a message and handles protocol control automatically.
+// Aggregate 메시지는 하위 audio/video/data 메시지로 분해되어 순서대로 반환됨
 func (t *Transport) ReadMessage() (Message, error) {
-	msg, err := t.reader.ReadMessage()
+	return t.ReadMessageContext(context.Background())
+}
+
+// ReadMessageContext reads a message like ReadMessage, bounded by ctx.
+// ctx의 deadline/취소는 연결의 read deadline으로 적용되며 (net.Conn 등 deadline 지원 시),
+// 기한 초과 시 ErrReadTimeout, 취소 시 context.Cause(ctx) 반환.
+// 메시지를 읽는 도중 중단되면 청크 상태가 어긋나므로 연결을 닫아야 함
+func (t *Transport) ReadMessageContext(ctx context.Context) (Message, error) {
+	if msg, ok := t.nextSplit(); ok {
+		return msg, nil
+	}
+
+	unbind := t.deadlines.read.bind(ctx)
+	msg, err := t.readMessage()
+	unbind()
 	if err != nil {
-		return Message{}, err
+		return Message{}, contextError(ctx, err, ErrReadTimeout)
 	}
+	return msg, nil
+}
 
-	if err := t.handleProtocolControl(msg); err != nil {
-		msg.Buffer().Release()
-		return Message{}, err
+// readMessage reads a message and handles protocol control
+func (t *Transport) readMessage() (Message, error) {
+	for {
+		msg, err := t.reader.ReadMessage()
+		if err != nil {
+			return Message{}, err
+		}
+
+		if err := t.handleProtocolControl(msg); err != nil {
+			msg.Buffer().Release()
+			return Message{}, err
+		}
+
+		if err := t.handleAckWindow(); err != nil {
+			msg.Buffer().Release()
+			return Message{}, err
+		}
+
+		if msg.Type() != MsgTypeAggregate {
+			return msg, nil
+		}
+		first, ok, err := t.splitAggregate(msg)
+		if err != nil {
+			return Message{}, err
+		}
+		if ok {
+			return first, nil
+		}
+		// 빈 aggregate는 건너뜀 (재귀하면 연속된 빈 aggregate로 스택이 넘침)
 	}
+}
 
-	if err := t.handleAckWindow(); err != nil {
-		msg.Buffer().Release()
-		return Message{}, err
+// splitAggregate returns the first sub-message of an aggregate and keeps the rest for later reads.
+// 하위 메시지가 없으면 false 반환
+func (t *Transport) splitAggregate(msg Message) (Message, bool, error) {
+	msgs, err := SplitAggregate(msg)
+	msg.Buffer().Release() // 하위 메시지가 버퍼를 참조
+	if err != nil {
+		return Message{}, false, err
+	}
+	if len(msgs) == 0 {
+		return Message{}, false, nil
 	}
 
-	return msg, nil
+	t.splitMu.Lock()
+	defer t.splitMu.Unlock()
+	if t.closed {
+		for _, sub := range msgs[1:] {
+			sub.Buffer().Release()
+		}
+		return msgs[0], true, nil
+	}
+	t.split = append(t.split[:0], msgs[1:]...)
+	return msgs[0], true, nil
+}
+
+// nextSplit removes and returns the next pending aggregate sub-message
+func (t *Transport) nextSplit() (Message, bool) {
+	t.splitMu.Lock()
+	defer t.splitMu.Unlock()
+	if len(t.split) == 0 {
+		return Message{}, false
+	}
+	msg := t.split[0]
+	t.split[0] = Message{}
+	t.split = t.split[1:]
+	return msg, true
 }
 
-// WriteMessage writes a message with automatic flush
+// WriteMessage writes a message with automatic flush; safe for concurrent use.
+// 상대의 송신 창이 가득 차면 audio/video는 FlowMode에 따라 대기하거나 ErrWindowFull 반환
 func (t *Transport) WriteMessage(msg Message) error {
+	return t.WriteMessageContext(context.Background(), msg)
+}
+
+// WriteMessageContext writes a message like WriteMessage, bounded by ctx.
+// 송신 창 대기와 전송 모두에 적용되며, 기한 초과 시 ErrWriteTimeout, 취소 시 context.Cause(ctx) 반환.
+// 전송 도중 중단된 연결은 더 이상 쓸 수 없음
+func (t *Transport) WriteMessageContext(ctx context.Context, msg Message) error {
+	// writeMu를 잡기 전에 대기해야 읽기 경로의 ACK/PingResponse 전송이 막히지 않음
+	if err := t.flow.wait(ctx, msg, t.conn.BytesWritten); err != nil {
+		return err
+	}
+
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+
+	unbind := t.deadlines.write.bind(ctx)
+	err := t.writeMessage(msg)
+	unbind()
+	if err != nil {
+		return contextError(ctx, err, ErrWriteTimeout)
+	}
+	return nil
+}
+
+// writeMessage writes and flushes a message (writeMu must be held)
+func (t *Transport) writeMessage(msg Message) error {
 	if err := t.writer.WriteMessage(msg); err != nil {
 		return err
 	}
@@ -86,8 +215,8 @@ func (t *Transport) handleProtocolControl(msg Message) error {
 		if len(msg.Data()) != 4 {
 			return fmt.Errorf("invalid Acknowledgement message length: expected 4, got %d", len(msg.Data()))
 		}
-		// TODO: 상대방 ACK 추적하여 송신 flow control 구현 필요 (선택적)
 		// Acknowledgement는 상대방이 받은 바이트 수를 알려줌
+		t.flow.acknowledge(binary.BigEndian.Uint32(msg.Data()))
 	case MsgTypeUserControl:
 		if len(msg.Data()) < 2 {
 			return fmt.Errorf("invalid UserControl message length: expected >= 2, got %d", len(msg.Data()))
@@ -105,7 +234,7 @@ func (t *Transport) handleProtocolControl(msg Message) error {
 			}
 
 			// PingResponse 전송 (동일한 timestamp)
-			pongMsg := createUserControl(UserControlPingResponse, eventData)
+			pongMsg := NewUserControlMessage(UserControlPingResponse, eventData)
 			defer pongMsg.Buffer().Release()
 
 			if err := t.WriteMessage(pongMsg); err != nil {
@@ -126,7 +255,7 @@ func (t *Transport) handleProtocolControl(msg Message) error {
 		if len(msg.Data()) != 5 {
 			return fmt.Errorf("invalid SetPeerBandwidth message length: expected 5, got %d", len(msg.Data()))
 		}
-		t.peerBandwidth = binary.BigEndian.Uint32(msg.Data())
+		t.flow.setPeerBandwidth(binary.BigEndian.Uint32(msg.Data()), msg.Data()[4])
 	}
 
 	return nil
@@ -185,8 +314,12 @@ func (t *Transport) SetOutChunkSize(size uint32) error {
 	msg := NewMessage(header, buffer)
 	defer msg.Buffer().Release()
 
+	// 전송과 청크 크기 변경 사이에 다른 메시지가 끼어들지 않도록 함께 잠금
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+
 	// Send message
-	if err := t.WriteMessage(msg); err != nil {
+	if err := t.writeMessage(msg); err != nil {
 		return fmt.Errorf("send SetChunkSize: %w", err)
 	}
 
@@ -209,7 +342,8 @@ func (t *Transport) SetWindowAckSize(size uint32) error {
 		return fmt.Errorf("send WindowAckSize: %w", err)
 	}
 
-	// Update local window ack size (no state needed on send side)
+	// 상대는 이 크기마다 ACK하므로 flow control에 반영
+	t.flow.setAckWindow(size)
 	return nil
 }
 
@@ -233,6 +367,37 @@ func (t *Transport) SetPeerBandwidth(size uint32, limitType uint8) error {
 	return nil
 }
 
+// SetReadTimeout sets the read idle timeout (0이면 없음).
+// 연결이 deadline을 지원하면 이 시간 동안 데이터를 받지 못한 읽기는 ErrReadTimeout으로 실패
+func (t *Transport) SetReadTimeout(timeout time.Duration) {
+	t.deadlines.read.setTimeout(timeout)
+}
+
+// SetWriteTimeout sets the write timeout (0이면 없음).
+// 연결이 deadline을 지원하면 이 시간 동안 진행하지 못한 쓰기는 ErrWriteTimeout으로 실패
+func (t *Transport) SetWriteTimeout(timeout time.Duration) {
+	t.deadlines.write.setTimeout(timeout)
+}
+
+// SetFlowMode sets how media writes behave when the peer's window is exhausted
+func (t *Transport) SetFlowMode(mode FlowMode) {
+	t.flow.setMode(mode)
+}
+
+// BytesInFlight returns the bytes written but not yet acknowledged by the peer
+func (t *Transport) BytesInFlight() uint64 {
+	t.flow.mu.Lock()
+	defer t.flow.mu.Unlock()
+	return t.flow.inFlight(t.conn.BytesWritten())
+}
+
+// SendWindow returns the effective send window set by the peer (0이면 제한 없음)
+func (t *Transport) SendWindow() uint32 {
+	t.flow.mu.Lock()
+	defer t.flow.mu.Unlock()
+	return t.flow.limit()
+}
+
 // parseUserControl parses UserControl message data
 func parseUserControl(data []byte) (eventType uint16, eventData []byte, err error) {
 	if len(data) < 2 {
@@ -243,8 +408,8 @@ func parseUserControl(data []byte) (eventType uint16, eventData []byte, err erro
 	return
 }
 
-// createUserControl creates a UserControl message
-func createUserControl(eventType uint16, eventData []byte) Message {
+// NewUserControlMessage creates a UserControl message
+func NewUserControlMessage(eventType uint16, eventData []byte) Message {
 	data := make([]byte, 2+len(eventData))
 	binary.BigEndian.PutUint16(data[0:2], eventType)
 	copy(data[2:], eventData)
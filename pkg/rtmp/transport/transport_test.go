@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"encoding/binary"
 	"io"
+	"net"
+	"sync"
 	"testing"
 
 	"github.com/ssungk/ertmp/pkg/rtmp/buf"
@@ -313,6 +315,75 @@ func TestTransportAbort_ClearChunkStream(t *testing.T) {
 	t.Logf("Abort message successfully cleared message assembler")
 }
 
+func TestTransport_ConcurrentWrite(t *testing.T) {
+	client, server := net.Pipe()
+	sender := NewTransport(client)
+	receiver := NewTransport(server)
+	defer sender.Close()
+	defer receiver.Close()
+
+	const writers = 4
+	const perWriter = 50
+
+	var wg sync.WaitGroup
+	errs := make(chan error, writers+1)
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			msgType := uint8(MsgTypeVideo)
+			if w%2 == 1 {
+				msgType = MsgTypeAudio
+			}
+			for i := 0; i < perWriter; i++ {
+				// 여러 청크로 나뉘도록 기본 청크 크기보다 크게
+				data := bytes.Repeat([]byte{byte(i)}, 300+w)
+				header := NewMessageHeader(uint32(w+1), uint32(i*10), msgType)
+				if err := sender.WriteMessage(NewMessage(header, buf.New(data))); err != nil {
+					errs <- err
+					return
+				}
+				// 쓰기 도중 청크 크기 변경
+				if w == 0 && i == perWriter/2 {
+					if err := sender.SetOutChunkSize(1024); err != nil {
+						errs <- err
+						return
+					}
+				}
+			}
+		}(w)
+	}
+
+	next := make(map[uint32]int)
+	for received := 0; received < writers*perWriter; received++ {
+		msg, err := receiver.ReadMessage()
+		if err != nil {
+			t.Fatalf("ReadMessage failed after %d messages: %v", received, err)
+		}
+		if msg.Type() == MsgTypeSetChunkSize {
+			msg.Buffer().Release()
+			received--
+			continue
+		}
+
+		streamID := msg.StreamID()
+		i := next[streamID]
+		want := bytes.Repeat([]byte{byte(i)}, 300+int(streamID)-1)
+		if !bytes.Equal(msg.Data(), want) || msg.Timestamp() != uint32(i*10) {
+			t.Fatalf("stream %d message %d: unexpected data (%d bytes) or timestamp %d",
+				streamID, i, len(msg.Data()), msg.Timestamp())
+		}
+		next[streamID] = i + 1
+		msg.Buffer().Release()
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("WriteMessage failed: %v", err)
+	}
+}
+
 // Helper functions and types
 
 // testConn implements io.ReadWriteCloser with separate read/write buffers
@@ -162,7 +162,7 @@ func (w *Writer) getChunkStreamID(msgType uint8) uint32 {
 		return ChunkStreamCommand
 	case MsgTypeAudio:
 		return ChunkStreamAudio
-	case MsgTypeVideo:
+	case MsgTypeVideo, MsgTypeAggregate:
 		return ChunkStreamVideo
 	case MsgTypeAMF0Data, MsgTypeAMF3Data:
 		return ChunkStreamData
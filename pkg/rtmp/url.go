@@ -0,0 +1,98 @@
+package rtmp
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// URL represents a parsed rtmp[s]://host[:port]/app[/instance]/streamKey URL
+type URL struct {
+	Scheme    string // "rtmp" or "rtmps"
+	Host      string // 호스트명 (포트 제외)
+	Port      string // 포트 (생략 시 scheme 기본값)
+	App       string // app[/instance]
+	StreamKey string // 마지막 경로 요소 (쿼리 포함)
+}
+
+// ParseURL parses an RTMP URL
+// 경로 요소가 하나면 app만, 둘 이상이면 마지막 요소가 stream key이고 나머지가 app[/instance]
+func ParseURL(rawURL string) (*URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL %q: %w", rawURL, err)
+	}
+
+	ru := &URL{
+		Scheme: u.Scheme,
+		Host:   u.Hostname(),
+		Port:   u.Port(),
+	}
+
+	switch ru.Scheme {
+	case SchemeRTMP:
+		if ru.Port == "" {
+			ru.Port = DefaultPort
+		}
+	case SchemeRTMPS:
+		if ru.Port == "" {
+			ru.Port = DefaultTLSPort
+		}
+	default:
+		return nil, fmt.Errorf("unsupported URL scheme: %q", u.Scheme)
+	}
+
+	if ru.Host == "" {
+		return nil, fmt.Errorf("missing host in URL %q", rawURL)
+	}
+
+	path := strings.Trim(u.Path, "/")
+	if path == "" {
+		return nil, fmt.Errorf("missing app in URL %q", rawURL)
+	}
+
+	segments := strings.Split(path, "/")
+	if len(segments) == 1 {
+		ru.App = segments[0]
+	} else {
+		ru.App = strings.Join(segments[:len(segments)-1], "/")
+		ru.StreamKey = segments[len(segments)-1]
+	}
+
+	// 쿼리는 stream key에 포함 (토큰 인증 등)
+	if u.RawQuery != "" {
+		if ru.StreamKey != "" {
+			ru.StreamKey += "?" + u.RawQuery
+		} else {
+			ru.App += "?" + u.RawQuery
+		}
+	}
+
+	return ru, nil
+}
+
+// Address returns host:port for dialing
+func (u *URL) Address() string {
+	return net.JoinHostPort(u.Host, u.Port)
+}
+
+// TcURL returns the tcUrl sent in the connect command (scheme://host[:port]/app)
+func (u *URL) TcURL() string {
+	host := u.Host
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]" // IPv6
+	}
+	if (u.Scheme == SchemeRTMP && u.Port != DefaultPort) || (u.Scheme == SchemeRTMPS && u.Port != DefaultTLSPort) {
+		host += ":" + u.Port
+	}
+	return u.Scheme + "://" + host + "/" + u.App
+}
+
+// String returns the full URL
+func (u *URL) String() string {
+	if u.StreamKey == "" {
+		return u.TcURL()
+	}
+	return u.TcURL() + "/" + u.StreamKey
+}